@@ -0,0 +1,102 @@
+package generichttp
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// AccessLogOptions configures the AccessLog middleware.
+type AccessLogOptions struct {
+	// Logger is the logger to use. It defaults to slog.Default().
+	Logger *slog.Logger
+	// SampleRate logs one in SampleRate requests that don't fail, to reduce
+	// the log volume of high-traffic endpoints. Zero or one logs all
+	// requests.
+	SampleRate int
+	// Sampler, if set, decides whether to log a request that doesn't fail,
+	// instead of SampleRate, e.g. to follow the sampled flag of an incoming
+	// traceparent header. It is called before the request is handled.
+	Sampler func(r *http.Request) bool
+	// IsError reports whether a response with the given status code is an
+	// error. Errors are always logged, regardless of sampling. It defaults
+	// to status codes of 400 and above. Panics are always errors.
+	IsError func(status int) bool
+}
+
+// logSampledKey is the context key of the sampling decision of AccessLog.
+type logSampledKey struct{}
+
+// AccessLog returns a middleware that logs a line per request with the
+// method, path, status code, size of the response body, and duration.
+// Successful requests are logged at info level, client errors at warn
+// level, and server errors at error level.
+//
+// To control the log volume, successful requests may be sampled, see
+// AccessLogOptions; errors are never dropped. The sampling decision is
+// made before the request is handled and stored in the request context,
+// so that e.g. tracing can align with it, see LogSampled.
+func AccessLog(opts AccessLogOptions) Middleware {
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	isError := opts.IsError
+	if isError == nil {
+		isError = func(status int) bool { return status >= http.StatusBadRequest }
+	}
+	sampler := opts.Sampler
+	if sampler == nil {
+		var n atomic.Uint64
+		rate := uint64(max(opts.SampleRate, 1))
+		sampler = func(*http.Request) bool { return (n.Add(1)-1)%rate == 0 }
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sampled := sampler(r)
+			r = r.WithContext(context.WithValue(r.Context(), logSampledKey{}, sampled))
+			tw := NewTeeResponseWriter(w, nil)
+			panicked := true
+			defer func() {
+				status := tw.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+				if panicked {
+					status = http.StatusInternalServerError
+				}
+				if !sampled && !panicked && !isError(status) {
+					return
+				}
+				level := slog.LevelInfo
+				switch {
+				case status >= http.StatusInternalServerError:
+					level = slog.LevelError
+				case status >= http.StatusBadRequest:
+					level = slog.LevelWarn
+				}
+				logger.LogAttrs(r.Context(), level, "generichttp: request",
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.Int("status", status),
+					slog.Int64("bytes", tw.Written()),
+					slog.Duration("duration", time.Since(start)),
+					slog.Bool("sampled", sampled),
+				)
+			}()
+			next.ServeHTTP(tw, r)
+			panicked = false
+		})
+	}
+}
+
+// LogSampled reports whether the AccessLog middleware sampled the request
+// with the given context, i.e. logs it even if it succeeds. It returns
+// false if there is no AccessLog middleware.
+func LogSampled(ctx context.Context) bool {
+	sampled, _ := ctx.Value(logSampledKey{}).(bool)
+	return sampled
+}
@@ -0,0 +1,144 @@
+package generichttp_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/olivere/generichttp"
+)
+
+// accessLogLine is a line written by AccessLog with a JSON handler.
+type accessLogLine struct {
+	Level   string `json:"level"`
+	Msg     string `json:"msg"`
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Status  int    `json:"status"`
+	Bytes   int64  `json:"bytes"`
+	Sampled bool   `json:"sampled"`
+}
+
+func parseAccessLog(t *testing.T, buf *bytes.Buffer) []accessLogLine {
+	t.Helper()
+	var lines []accessLogLine
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var line accessLogLine
+		if err := dec.Decode(&line); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// statusHandler responds with the status code in the path, e.g. /404, and
+// records whether the request was sampled.
+func statusHandler(sampled map[string]bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sampled[r.URL.Path] = generichttp.LogSampled(r.Context())
+		code, _ := strconv.Atoi(r.URL.Path[1:])
+		w.WriteHeader(code)
+		w.Write([]byte("body"))
+	})
+}
+
+func TestAccessLogSampling(t *testing.T) {
+	var buf bytes.Buffer
+	sampled := make(map[string]bool)
+	h := generichttp.AccessLog(generichttp.AccessLogOptions{
+		Logger:     slog.New(slog.NewJSONHandler(&buf, nil)),
+		SampleRate: 4,
+	})(statusHandler(sampled))
+
+	// Requests 1 and 5 of every 4 are sampled; errors are logged anyway.
+	paths := []string{"/200", "/201", "/500", "/204", "/404", "/200", "/202", "/503", "/200"}
+	for _, path := range paths {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	want := []accessLogLine{
+		{Level: "INFO", Status: 200, Sampled: true},
+		{Level: "ERROR", Status: 500, Sampled: false},
+		{Level: "WARN", Status: 404, Sampled: true},
+		{Level: "ERROR", Status: 503, Sampled: false},
+		{Level: "INFO", Status: 200, Sampled: true},
+	}
+	lines := parseAccessLog(t, &buf)
+	if len(lines) != len(want) {
+		t.Fatalf("logged %d lines, want %d: %+v", len(lines), len(want), lines)
+	}
+	for i, line := range lines {
+		w := want[i]
+		if line.Level != w.Level || line.Status != w.Status || line.Sampled != w.Sampled ||
+			line.Msg != "generichttp: request" || line.Method != http.MethodGet || line.Path != "/"+strconv.Itoa(w.Status) {
+			t.Errorf("line %d = %+v, want %+v", i, line, w)
+		}
+		if line.Bytes != 4 && line.Status != http.StatusNoContent {
+			t.Errorf("line %d: bytes = %d, want 4", i, line.Bytes)
+		}
+	}
+
+	for path, want := range map[string]bool{"/200": true, "/201": false, "/500": false, "/404": true} {
+		if sampled[path] != want {
+			t.Errorf("LogSampled for %s = %v, want %v", path, sampled[path], want)
+		}
+	}
+}
+
+func TestAccessLogSampler(t *testing.T) {
+	var buf bytes.Buffer
+	h := generichttp.AccessLog(generichttp.AccessLogOptions{
+		Logger:  slog.New(slog.NewJSONHandler(&buf, nil)),
+		Sampler: func(r *http.Request) bool { return r.Header.Get("X-Sampled") == "1" },
+		IsError: func(status int) bool { return status >= http.StatusInternalServerError },
+	})(statusHandler(make(map[string]bool)))
+
+	for _, tt := range []struct {
+		path    string
+		sampled bool
+	}{{"/200", true}, {"/200", false}, {"/404", false}, {"/502", false}} {
+		r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.sampled {
+			r.Header.Set("X-Sampled", "1")
+		}
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	lines := parseAccessLog(t, &buf)
+	if len(lines) != 2 || lines[0].Status != 200 || !lines[0].Sampled || lines[1].Status != 502 {
+		t.Errorf("lines = %+v, want the sampled 200 and the 502", lines)
+	}
+}
+
+func TestAccessLogPanic(t *testing.T) {
+	var buf bytes.Buffer
+	h := generichttp.AccessLog(generichttp.AccessLogOptions{
+		Logger:     slog.New(slog.NewJSONHandler(&buf, nil)),
+		SampleRate: 1000,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	// Use up the sampled first request.
+	func() {
+		defer func() { recover() }()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+	buf.Reset()
+
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("recovered %v, want the panic to be passed on", p)
+			}
+		}()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+	lines := parseAccessLog(t, &buf)
+	if len(lines) != 1 || lines[0].Level != "ERROR" || lines[0].Status != http.StatusInternalServerError || lines[0].Sampled {
+		t.Errorf("lines = %+v, want an unsampled error", lines)
+	}
+}
@@ -0,0 +1,63 @@
+package generichttp
+
+import (
+	"context"
+	"net/http"
+)
+
+// attributes stores values of a single request by key.
+type attributes map[string]any
+
+// attributesKey is the context key of the attributes of a request.
+type attributesKey struct{}
+
+// withAttributes returns r with attributes in its context. If r already
+// has attributes, it returns r unchanged.
+func withAttributes(r *http.Request) *http.Request {
+	if _, ok := r.Context().Value(attributesKey{}).(attributes); ok {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), attributesKey{}, make(attributes)))
+}
+
+// SetAttribute sets the value of an attribute of the request, e.g. the
+// authenticated user or tenant, to pass it from middleware to handlers.
+// It returns the request to pass to the next handler, which carries the
+// attributes in its context:
+//
+//	r = generichttp.SetAttribute(r, "tenant", tenant)
+//	next.ServeHTTP(w, r)
+//
+// Attributes live as long as the request and are shared by all handlers
+// that see the returned request or a request derived from it. They are
+// not safe for concurrent use, so don't set them from other goroutines.
+func SetAttribute(r *http.Request, key string, value any) *http.Request {
+	r = withAttributes(r)
+	r.Context().Value(attributesKey{}).(attributes)[key] = value
+	return r
+}
+
+// Attribute returns the value of an attribute of the request, if it is
+// set and of type V. See SetAttribute.
+func Attribute[V any](r *http.Request, key string) (V, bool) {
+	attrs, _ := r.Context().Value(attributesKey{}).(attributes)
+	v, ok := attrs[key].(V)
+	return v, ok
+}
+
+// Set sets the value of an attribute of the request. See SetAttribute.
+// JSON and Auto make sure the request has attributes, so the value is
+// visible to all handlers of the request.
+func (r Request[T]) Set(key string, value any) {
+	if attrs, ok := r.Context().Value(attributesKey{}).(attributes); ok {
+		attrs[key] = value
+	}
+}
+
+// Get returns the value of an attribute of the request. See SetAttribute,
+// and Attribute for a typed accessor.
+func (r Request[T]) Get(key string) (any, bool) {
+	attrs, _ := r.Context().Value(attributesKey{}).(attributes)
+	v, ok := attrs[key]
+	return v, ok
+}
@@ -0,0 +1,256 @@
+package generichttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// AuditRecord is a request and its response, as recorded by the Audit
+// middleware. Bodies are redacted, see AuditOptions.
+type AuditRecord struct {
+	Time     time.Time     `json:"time"`
+	Method   string        `json:"method"`
+	Path     string        `json:"path"`
+	Status   int           `json:"status"`
+	Duration time.Duration `json:"duration"`
+	// RequestBody is the redacted request body, or nil if it was empty,
+	// too large, or of a media type that can't be redacted.
+	RequestBody []byte `json:"requestBody,omitempty"`
+	// RequestSize is the size of the request body read by the handler.
+	RequestSize int64 `json:"requestSize"`
+	// ResponseBody is the redacted response body, or nil if it was empty,
+	// too large, or of a media type that can't be redacted.
+	ResponseBody []byte `json:"responseBody,omitempty"`
+	// ResponseSize is the size of the response body.
+	ResponseSize int64 `json:"responseSize"`
+}
+
+// AuditSink receives the records of the Audit middleware, e.g. to write
+// them to an append-only store. Implementations must be safe for
+// concurrent use.
+type AuditSink interface {
+	Audit(ctx context.Context, rec *AuditRecord)
+}
+
+// AuditOptions configures the Audit middleware.
+type AuditOptions struct {
+	// Sink receives the records. It defaults to a sink that logs them with
+	// slog.Default(), see NewSlogAuditSink.
+	Sink AuditSink
+	// Match reports whether to audit a request, e.g. by path. It defaults
+	// to all requests.
+	Match func(r *http.Request) bool
+	// MaxSize is the maximum size of a body to record, in bytes. Larger
+	// bodies are not recorded, only their size. It defaults to 64 KiB.
+	MaxSize int64
+	// RedactFields are the names of fields whose values are replaced with
+	// "[REDACTED]" in JSON bodies, at any depth, and in form bodies. Names
+	// are compared case-insensitively. It defaults to DefaultRedactFields.
+	RedactFields []string
+	// Redactor, if set, replaces the redaction by field name. It returns
+	// the redacted body for the given media type, or nil to omit the body.
+	Redactor func(mediaType string, body []byte) []byte
+}
+
+// DefaultRedactFields are the fields redacted by the Audit middleware by
+// default.
+var DefaultRedactFields = []string{
+	"password", "secret", "token", "access_token", "refresh_token",
+	"api_key", "apikey", "authorization", "client_secret",
+}
+
+// Audit returns a middleware that records requests and their responses,
+// including the bodies, and passes them to a sink, e.g. for compliance.
+// Sensitive fields are redacted by name in JSON and form bodies; bodies of
+// other media types are omitted unless opts.Redactor handles them. Bodies
+// that are larger than opts.MaxSize are omitted as well, as they can't be
+// redacted reliably.
+//
+// The request body is recorded as the handler reads it, so the handler's
+// view is unaffected, but a body the handler doesn't read isn't recorded.
+//
+// Auditing is expensive: bodies are buffered in memory and parsed for
+// redaction, and the sink is called synchronously after the response has
+// been written, delaying the next request on the connection. Use Match to
+// audit only the routes that need it. Keep in mind that the records contain
+// personal data unless it is redacted, and store them accordingly.
+func Audit(opts AuditOptions) Middleware {
+	if opts.Sink == nil {
+		opts.Sink = NewSlogAuditSink(nil)
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = 64 << 10
+	}
+	if opts.RedactFields == nil {
+		opts.RedactFields = DefaultRedactFields
+	}
+	redact := opts.Redactor
+	if redact == nil {
+		fields := make(map[string]bool, len(opts.RedactFields))
+		for _, f := range opts.RedactFields {
+			fields[strings.ToLower(f)] = true
+		}
+		redact = func(mediaType string, body []byte) []byte {
+			return redactBody(mediaType, body, fields)
+		}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if opts.Match != nil && !opts.Match(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			start := time.Now()
+			var reqBody, respBody bytes.Buffer
+			var body *auditReader
+			if r.Body != nil && r.Body != http.NoBody {
+				body = &auditReader{
+					ReadCloser: r.Body,
+					tee:        limitedWriter{w: &reqBody, n: opts.MaxSize},
+				}
+				r.Body = body
+			}
+			tw := NewTeeResponseWriter(w, &limitedWriter{w: &respBody, n: opts.MaxSize})
+			next.ServeHTTP(tw, r)
+
+			rec := &AuditRecord{
+				Time:         start,
+				Method:       r.Method,
+				Path:         r.URL.Path,
+				Status:       tw.Status(),
+				Duration:     time.Since(start),
+				ResponseSize: tw.Written(),
+			}
+			if rec.Status == 0 {
+				rec.Status = http.StatusOK
+			}
+			if body != nil {
+				rec.RequestSize = body.n
+				if body.n > 0 && body.n <= opts.MaxSize {
+					rec.RequestBody = redact(auditMediaType(r.Header), reqBody.Bytes())
+				}
+			}
+			if rec.ResponseSize > 0 && rec.ResponseSize <= opts.MaxSize {
+				rec.ResponseBody = redact(auditMediaType(w.Header()), respBody.Bytes())
+			}
+			opts.Sink.Audit(r.Context(), rec)
+		})
+	}
+}
+
+// auditReader duplicates a request body as it is read.
+type auditReader struct {
+	io.ReadCloser
+	tee limitedWriter
+	n   int64
+}
+
+// Read implements io.Reader.
+func (ar *auditReader) Read(p []byte) (int, error) {
+	n, err := ar.ReadCloser.Read(p)
+	if n > 0 {
+		ar.n += int64(n)
+		_, _ = ar.tee.Write(p[:n])
+	}
+	return n, err
+}
+
+// auditMediaType returns the media type of the Content-Type header in h.
+func auditMediaType(h http.Header) string {
+	mediaType, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	return mediaType
+}
+
+// redactBody redacts the given fields in a JSON or form body. It returns
+// nil for other media types and for bodies that can't be parsed.
+func redactBody(mediaType string, body []byte, fields map[string]bool) []byte {
+	switch {
+	case mediaType == mediaTypeJSON || strings.HasSuffix(mediaType, "+json"):
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return nil
+		}
+		out, err := json.Marshal(redactValue(v, fields))
+		if err != nil {
+			return nil
+		}
+		return out
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil
+		}
+		for k, vs := range values {
+			if fields[strings.ToLower(k)] {
+				for i := range vs {
+					vs[i] = redacted
+				}
+			}
+		}
+		return []byte(values.Encode())
+	}
+	return nil
+}
+
+// redacted replaces the values of redacted fields.
+const redacted = "[REDACTED]"
+
+// redactValue replaces the values of the given fields in v, a decoded JSON
+// value, at any depth.
+func redactValue(v any, fields map[string]bool) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			if fields[strings.ToLower(k)] {
+				v[k] = redacted
+			} else {
+				v[k] = redactValue(e, fields)
+			}
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = redactValue(e, fields)
+		}
+	}
+	return v
+}
+
+// slogAuditSink is an AuditSink that logs records.
+type slogAuditSink struct {
+	logger *slog.Logger
+}
+
+// NewSlogAuditSink returns an AuditSink that logs records at info level
+// with logger, or slog.Default() if logger is nil. Bodies are logged as
+// strings.
+func NewSlogAuditSink(logger *slog.Logger) AuditSink {
+	return slogAuditSink{logger: logger}
+}
+
+// Audit implements AuditSink.
+func (s slogAuditSink) Audit(ctx context.Context, rec *AuditRecord) {
+	logger := s.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "generichttp: audit",
+		slog.String("method", rec.Method),
+		slog.String("path", rec.Path),
+		slog.Int("status", rec.Status),
+		slog.Duration("duration", rec.Duration),
+		slog.String("request_body", string(rec.RequestBody)),
+		slog.Int64("request_size", rec.RequestSize),
+		slog.String("response_body", string(rec.ResponseBody)),
+		slog.Int64("response_size", rec.ResponseSize),
+	)
+}
@@ -0,0 +1,172 @@
+package generichttp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/olivere/generichttp"
+)
+
+// auditRecords is an AuditSink that collects the records.
+type auditRecords []*generichttp.AuditRecord
+
+func (s *auditRecords) Audit(ctx context.Context, rec *generichttp.AuditRecord) {
+	*s = append(*s, rec)
+}
+
+type auditLogin struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+	Device   struct {
+		Token string `json:"Token"`
+	} `json:"device"`
+	Keys []struct {
+		APIKey string `json:"api_key"`
+	} `json:"keys"`
+}
+
+type auditSession struct {
+	User        string `json:"user"`
+	AccessToken string `json:"access_token"`
+}
+
+func TestAuditRedaction(t *testing.T) {
+	var records auditRecords
+	var seen auditLogin
+	h := generichttp.Audit(generichttp.AuditOptions{Sink: &records})(
+		generichttp.JSON(func(w http.ResponseWriter, req generichttp.Request[auditLogin]) (*generichttp.Response[auditSession], error) {
+			seen = *req.Data
+			return generichttp.Created(&auditSession{User: req.Data.User, AccessToken: "xyz"}), nil
+		}))
+
+	body := `{"user":"alice","password":"hunter2","device":{"Token":"abc"},"keys":[{"api_key":"k1"},{"api_key":"k2"}]}`
+	r := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if seen.Password != "hunter2" || seen.Device.Token != "abc" || len(seen.Keys) != 2 || seen.Keys[1].APIKey != "k2" {
+		t.Errorf("handler saw %+v, want the unredacted request", seen)
+	}
+	if !strings.Contains(w.Body.String(), `"access_token":"xyz"`) {
+		t.Errorf("client got %s, want the unredacted response", w.Body)
+	}
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+	rec := records[0]
+	if rec.Method != http.MethodPost || rec.Path != "/login" || rec.Status != http.StatusCreated {
+		t.Errorf("record = %s %s %d, want POST /login 201", rec.Method, rec.Path, rec.Status)
+	}
+	wantReq := `{"device":{"Token":"[REDACTED]"},"keys":[{"api_key":"[REDACTED]"},{"api_key":"[REDACTED]"}],"password":"[REDACTED]","user":"alice"}`
+	if string(rec.RequestBody) != wantReq {
+		t.Errorf("request body = %s, want %s", rec.RequestBody, wantReq)
+	}
+	if rec.RequestSize != int64(len(body)) {
+		t.Errorf("request size = %d, want %d", rec.RequestSize, len(body))
+	}
+	wantResp := `{"access_token":"[REDACTED]","user":"alice"}`
+	if string(rec.ResponseBody) != wantResp {
+		t.Errorf("response body = %s, want %s", rec.ResponseBody, wantResp)
+	}
+	if rec.ResponseSize != int64(w.Body.Len()) {
+		t.Errorf("response size = %d, want %d", rec.ResponseSize, w.Body.Len())
+	}
+}
+
+func TestAuditRedactionOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        generichttp.AuditOptions
+		contentType string
+		body        string
+		want        string
+	}{
+		{
+			name:        "form",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "user=alice&Password=hunter2&password=again",
+			want:        "Password=%5BREDACTED%5D&password=%5BREDACTED%5D&user=alice",
+		},
+		{
+			name:        "custom fields",
+			opts:        generichttp.AuditOptions{RedactFields: []string{"SSN"}},
+			contentType: "application/vnd.api+json",
+			body:        `{"ssn":"123-45-6789","password":"kept"}`,
+			want:        `{"password":"kept","ssn":"[REDACTED]"}`,
+		},
+		{
+			name: "redactor",
+			opts: generichttp.AuditOptions{Redactor: func(mediaType string, body []byte) []byte {
+				return []byte(mediaType + ":" + strings.ToUpper(string(body)))
+			}},
+			contentType: "text/plain",
+			body:        "secret",
+			want:        "text/plain:SECRET",
+		},
+		{
+			name:        "other media type",
+			contentType: "text/plain",
+			body:        "password=hunter2",
+			want:        "",
+		},
+		{
+			name:        "invalid JSON",
+			contentType: "application/json",
+			body:        `{"password":"hunter2"`,
+			want:        "",
+		},
+		{
+			name:        "too large",
+			opts:        generichttp.AuditOptions{MaxSize: 10},
+			contentType: "application/json",
+			body:        `{"password":"hunter2"}`,
+			want:        "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var records auditRecords
+			tt.opts.Sink = &records
+			var seen []byte
+			h := generichttp.Audit(tt.opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = make([]byte, len(tt.body)+1)
+				n, _ := r.Body.Read(seen)
+				seen = seen[:n]
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			h.ServeHTTP(httptest.NewRecorder(), r)
+			if string(seen) != tt.body {
+				t.Errorf("handler read %q, want %q", seen, tt.body)
+			}
+			if len(records) != 1 {
+				t.Fatalf("got %d records, want 1", len(records))
+			}
+			if got := string(records[0].RequestBody); got != tt.want {
+				t.Errorf("request body = %q, want %q", got, tt.want)
+			}
+			if records[0].RequestSize != int64(len(tt.body)) {
+				t.Errorf("request size = %d, want %d", records[0].RequestSize, len(tt.body))
+			}
+		})
+	}
+}
+
+func TestAuditMatch(t *testing.T) {
+	var records auditRecords
+	h := generichttp.Audit(generichttp.AuditOptions{
+		Sink:  &records,
+		Match: func(r *http.Request) bool { return strings.HasPrefix(r.URL.Path, "/admin/") },
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for _, path := range []string{"/items", "/admin/users", "/admin"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if len(records) != 1 || records[0].Path != "/admin/users" || records[0].Status != http.StatusOK {
+		t.Errorf("records = %+v, want one for /admin/users with status 200", records)
+	}
+}
@@ -0,0 +1,60 @@
+package generichttp
+
+import (
+	"context"
+	"net/http"
+)
+
+// Scoped is implemented by principals that hold scopes or permissions,
+// e.g. from the scope claim of an OAuth 2.0 access token.
+type Scoped interface {
+	HasScope(scope string) bool
+}
+
+// principalKey is the context key of the principal.
+type principalKey struct{}
+
+// WithPrincipal returns a shallow copy of r with the authenticated
+// principal, e.g. a user, stored in its context. Call it in your
+// authentication middleware after verifying the credentials of a request.
+func WithPrincipal(r *http.Request, principal any) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), principalKey{}, principal))
+}
+
+// PrincipalFromContext returns the principal stored by WithPrincipal.
+func PrincipalFromContext(ctx context.Context) (any, bool) {
+	p := ctx.Value(principalKey{})
+	return p, p != nil
+}
+
+// RequireScopes returns a middleware that only passes requests whose
+// principal holds all of the given scopes. The principal is the one stored
+// by WithPrincipal, so use RequireScopes after your authentication
+// middleware.
+//
+// Requests without a principal are rejected with an UnauthorizedError.
+// Requests whose principal doesn't implement Scoped or lacks a scope are
+// rejected with a ForbiddenError.
+func RequireScopes(scopes ...string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := PrincipalFromContext(r.Context())
+			if !ok {
+				writeJSONError(w, r, UnauthorizedError{})
+				return
+			}
+			scoped, ok := p.(Scoped)
+			if !ok && len(scopes) > 0 {
+				writeJSONError(w, r, ForbiddenError{})
+				return
+			}
+			for _, scope := range scopes {
+				if !scoped.HasScope(scope) {
+					writeJSONError(w, r, ForbiddenError{Message: "Missing scope " + scope})
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
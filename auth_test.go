@@ -0,0 +1,72 @@
+package generichttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/olivere/generichttp"
+	"github.com/olivere/generichttp/testutil"
+)
+
+type scopedUser struct {
+	Name   string
+	Scopes []string
+}
+
+func (u scopedUser) HasScope(scope string) bool {
+	for _, s := range u.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRequireScopes(t *testing.T) {
+	h := generichttp.RequireScopes("items:read", "items:write")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name      string
+		principal any
+		code      int
+		want      any
+	}{
+		{"all scopes", scopedUser{"alice", []string{"items:write", "admin", "items:read"}}, http.StatusNoContent, nil},
+		{"missing scope", scopedUser{"bob", []string{"items:read"}}, http.StatusForbidden, map[string]any{"message": "Missing scope items:write"}},
+		{"no scopes", scopedUser{"carol", nil}, http.StatusForbidden, map[string]any{"message": "Missing scope items:read"}},
+		{"not scoped", "dave", http.StatusForbidden, map[string]any{"message": "Forbidden"}},
+		{"no principal", nil, http.StatusUnauthorized, map[string]any{"message": "Unauthorized"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []testutil.ReqOption
+			if tt.principal != nil {
+				opts = append(opts, testutil.WithPrincipal(tt.principal))
+			}
+			req := testutil.NewRequest(struct{}{}, opts...)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req.Request)
+			if w.Code != tt.code {
+				t.Fatalf("status = %d, want %d", w.Code, tt.code)
+			}
+			if tt.want != nil {
+				testutil.AssertJSON(t, w, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireScopesNone(t *testing.T) {
+	h := generichttp.RequireScopes()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	req := testutil.NewRequest(struct{}{}, testutil.WithPrincipal("dave"))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req.Request)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want %d for any authenticated principal", w.Code, http.StatusNoContent)
+	}
+}
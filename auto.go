@@ -0,0 +1,271 @@
+package generichttp
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// Media types supported by Auto.
+const (
+	mediaTypeJSON = "application/json"
+	mediaTypeXML  = "application/xml"
+	mediaTypeForm = "application/x-www-form-urlencoded"
+)
+
+// autoOffers are the media types Auto can render, in order of preference.
+var autoOffers = []string{mediaTypeJSON, mediaTypeXML}
+
+// jsonOffers are the media types JSON can render.
+var jsonOffers = []string{mediaTypeJSON}
+
+// Auto handles a request and returns a http.Handler, just like JSON, but
+// negotiates the format of the request and response data.
+//
+// The request body is decoded by its Content-Type:
+//
+//   - application/json and any type with a +json suffix as JSON,
+//   - application/xml, text/xml, and any type with a +xml suffix as XML,
+//   - application/x-www-form-urlencoded as a form, see below,
+//   - anything else, including a missing Content-Type, as JSON.
+//
+// Form fields are mapped to the struct fields of R by the form struct tag,
+// falling back to the json struct tag and the field name. Fields may be
+// strings, booleans, integers, floats, and pointers and slices thereof.
+//
+// The response is encoded as JSON or XML, whichever the Accept header
+// prefers, honoring q values and wildcards. Ties and a missing Accept
+// header prefer JSON, as does an Accept header that matches neither.
+// Forms are not supported as a response format. Errors are rendered in
+// the negotiated format as well.
+//
+// JSON and Auto handle request bodies the same way: a body that cannot be
+// decoded results in a BadRequestError, naming the field and expected type
+// for JSON bodies, see WithVerboseDecodeErrors, and an empty body is not
+// an error. The request data is normalized and validated like in JSON.
+// Options apply like in JSON.
+func Auto[R, W any](h Handler[R, W], opts ...Option) http.Handler {
+	return autoHandler[R, W]{h: h, own: opts, opts: newOptions(*defaultOptions, opts)}
+}
+
+// autoHandler is the http.Handler returned by Auto.
+type autoHandler[R, W any] struct {
+	h    Handler[R, W]
+	own  []Option
+	opts *Options
+}
+
+// withOptions returns the handler with its own options applied on top of
+// base.
+func (ah autoHandler[R, W]) withOptions(base Options) http.Handler {
+	ah.opts = newOptions(base, ah.own)
+	return ah
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (ah autoHandler[R, W]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r = withAttributes(r)
+	mediaType := NegotiateContentType(r.Header.Get("Accept"), autoOffers)
+	if mediaType == "" {
+		if err := checkAccept[W](r, autoOffers, ah.opts); err != nil {
+			writeJSONError(w, r, err)
+			return
+		}
+		mediaType = mediaTypeJSON
+	}
+	if err := checkRequestLimits(r, ah.opts); err != nil {
+		writeAutoError(w, r, mediaType, err)
+		return
+	}
+	if err := checkPrecondition(r, ah.opts); err != nil {
+		writeAutoError(w, r, mediaType, err)
+		return
+	}
+	var (
+		fields fieldSet
+		policy NamingPolicy
+		sr     = r
+	)
+	if mediaType == mediaTypeJSON {
+		var settings *EncoderSettings
+		settings, sr = encoderSettings(r, ah.opts)
+		policy = settings.namingPolicy(ah.opts.NamingPolicy)
+		var err error
+		if fields, err = responseFields[W](r, ah.opts, policy); err != nil {
+			writeAutoError(w, r, mediaType, err)
+			return
+		}
+	}
+	req, err := decodeAuto[R](w, r, ah.opts)
+	if err == nil {
+		err = validateRequest(req.Data, ah.opts)
+	}
+	if err != nil {
+		writeAutoError(w, r, mediaType, err)
+		return
+	}
+	resp, err := ah.h(w, req)
+	if err != nil {
+		writeAutoError(w, r, mediaType, devErrorFor(bodyError(err), nil, ah.opts))
+		return
+	}
+	if resp == nil {
+		// No response at all is rendered like one without data.
+		resp = &Response[W]{}
+	}
+	writeWarnings(w, r)
+	if resp.writeNotModified(w, r) {
+		return
+	}
+	if resp.Data == nil {
+		writeEmpty(w, resp, ah.opts.EmptyResponse, mediaType)
+		return
+	}
+	if ah.opts.EmptyCollections {
+		fillEmptyCollections(resp.Data)
+	}
+	var data any = resp.Data
+	if mediaType == mediaTypeJSON {
+		data = applyNaming(data, policy)
+	}
+	data, err = selectResponseFields(data, fields)
+	if err != nil {
+		writeAutoError(w, r, mediaType, err)
+		return
+	}
+	resp.writeHeader(w)
+	writeAuto(w, sr, mediaType, resp.StatusCode, data)
+}
+
+// dataTypes returns the types of the request and response data.
+func (autoHandler[R, W]) dataTypes() (reflect.Type, reflect.Type) {
+	return reflect.TypeOf((*R)(nil)).Elem(), reflect.TypeOf((*W)(nil)).Elem()
+}
+
+// decodeAuto decodes the request body by its Content-Type.
+func decodeAuto[T any](w http.ResponseWriter, r *http.Request, opts *Options) (Request[T], error) {
+	req := Request[T]{Request: r}
+	if err := limitBody(w, r, opts); err != nil {
+		return req, err
+	}
+	if opts.StreamBody {
+		return req, nil
+	}
+	if opts.ValidateUTF8 {
+		if err := checkBodyUTF8(r); err != nil {
+			return req, err
+		}
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch {
+	case mediaType == mediaTypeForm:
+		if err := r.ParseForm(); err != nil {
+			if err, ok := bodyError(err).(RequestEntityTooLargeError); ok {
+				return req, err
+			}
+			return req, BadRequestError{Message: "Invalid form data"}
+		}
+		data := new(T)
+		if err := bindValues(data, r.PostForm, "form", true); err != nil {
+			return req, err
+		}
+		req.Data = data
+		if err := bindQuery(r, &req); err != nil {
+			return req, err
+		}
+		return req, normalizeRequest(req.Data)
+	case isXMLMediaType(mediaType):
+		err := bodyError(xml.NewDecoder(r.Body).Decode(&req.Data))
+		var tle RequestEntityTooLargeError
+		if errors.As(err, &tle) {
+			return req, err
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return req, decodeError(err, opts.VerboseDecodeErrors, nil)
+		}
+	default:
+		if err := decodeJSONRequest(r, &req.Data, opts); err != nil {
+			return req, err
+		}
+	}
+	if err := bindQuery(r, &req); err != nil {
+		return req, err
+	}
+	return req, normalizeRequest(req.Data)
+}
+
+// isXMLMediaType reports whether mediaType denotes XML.
+func isXMLMediaType(mediaType string) bool {
+	return mediaType == mediaTypeXML || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// writeAuto renders data in the given media type, unless it renders
+// itself.
+func writeAuto(w http.ResponseWriter, r *http.Request, mediaType string, code int, data any) {
+	if rd, ok := data.(renderer); ok {
+		rd.render(w, r, code)
+		return
+	}
+	if mediaType == mediaTypeXML {
+		WriteXMLCode(w, code, data)
+		return
+	}
+	writeJSONCode(requestContext(r), w, code, data)
+}
+
+// writeAutoError renders err in the given media type.
+func writeAutoError(w http.ResponseWriter, r *http.Request, mediaType string, err error) {
+	if mediaType == mediaTypeXML {
+		writeXMLError(w, r, err)
+		return
+	}
+	writeJSONError(w, r, err)
+}
+
+// WriteXMLCode renders XML to the HTTP response body with the given
+// HTTP status code.
+func WriteXMLCode(w http.ResponseWriter, code int, data any) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	if code == 0 {
+		code = http.StatusOK
+	}
+	w.WriteHeader(code)
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		handleWriteError(err)
+		return
+	}
+	handleWriteError(xml.NewEncoder(w).Encode(data))
+}
+
+// WriteXMLError renders the error as XML. See WriteJSONError for how the
+// HTTP status code and message are determined.
+// Like in WriteJSONError, the body is sent with a Content-Length header.
+func WriteXMLError(w http.ResponseWriter, err error) {
+	writeXMLError(w, nil, err)
+}
+
+// writeXMLError renders the error as XML like WriteXMLError, translating
+// the message like writeJSONError.
+func writeXMLError(w http.ResponseWriter, r *http.Request, err error) {
+	if h, ok := asHalt(err); ok {
+		copyHeader(w.Header(), h.header)
+		if h.data != nil {
+			writeAuto(w, r, mediaTypeXML, h.code, h.data)
+		} else if h.code != 0 {
+			w.WriteHeader(h.code)
+		}
+		return
+	}
+	logError(r, errorCode(err), err)
+	msg := newErrorBody(r, err)
+	body, merr := xml.Marshal(msg)
+	if merr != nil {
+		WriteXMLCode(w, errorCode(err), msg)
+		return
+	}
+	writeBody(w, errorCode(err), "application/xml; charset=utf-8", append([]byte(xml.Header), body...))
+}
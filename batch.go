@@ -0,0 +1,224 @@
+package generichttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BatchRequest is the request data of a batch endpoint, see Batch.
+type BatchRequest struct {
+	Requests []BatchItem `json:"requests"`
+}
+
+// BatchItem is a single request in a BatchRequest.
+type BatchItem struct {
+	// ID is an optional client-chosen identifier, returned in the result.
+	ID string `json:"id,omitempty"`
+	// Method is the HTTP method, e.g. "POST". It defaults to "GET".
+	Method string `json:"method,omitempty"`
+	// Path is the path of the request including the query string, e.g.
+	// "/users/1?fields=name".
+	Path string `json:"path"`
+	// Header holds additional request headers.
+	Header map[string]string `json:"header,omitempty"`
+	// Body is the request body.
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// BatchResponse is the response data of a batch endpoint, see Batch.
+type BatchResponse struct {
+	Responses []BatchResult `json:"responses"`
+}
+
+// BatchResult is the result of a single BatchItem.
+type BatchResult struct {
+	// ID is the ID of the BatchItem.
+	ID string `json:"id,omitempty"`
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int `json:"status"`
+	// Body is the response body. Errors are in the standard error format,
+	// e.g. {"message":"Not found"}. Bodies that are not JSON are returned
+	// as a JSON string.
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// BatchOptions configures Batch.
+type BatchOptions struct {
+	// Concurrency is the maximum number of items handled concurrently.
+	// It defaults to 4. Set it to 1 to handle items sequentially.
+	Concurrency int
+	// Timeout is the maximum duration of a single item. An item that
+	// times out results in a GatewayTimeoutError. Zero means no timeout.
+	Timeout time.Duration
+	// MaxItems is the maximum number of items in a batch. Larger batches
+	// are rejected with a BadRequestError. It defaults to 100.
+	MaxItems int
+}
+
+// Batch returns a handler for a batch endpoint that fans out the items of
+// a BatchRequest to h, typically the Router the batch endpoint is
+// registered with, and returns their results in the same order, e.g.:
+//
+//	router.Post("/batch", generichttp.JSON(generichttp.Batch(router, generichttp.BatchOptions{})))
+//
+// Each item is handled as a separate request with the headers of the batch
+// request, e.g. for authentication, plus the headers of the item. Items
+// that are invalid or time out get a result with the error in the standard
+// error format, so a single failing item doesn't fail the batch.
+func Batch(h http.Handler, opts BatchOptions) Handler[BatchRequest, BatchResponse] {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	if opts.MaxItems <= 0 {
+		opts.MaxItems = 100
+	}
+	return func(w http.ResponseWriter, req Request[BatchRequest]) (*Response[BatchResponse], error) {
+		if req.Data == nil {
+			return nil, BadRequestError{Message: "Missing batch requests"}
+		}
+		items := req.Data.Requests
+		if len(items) > opts.MaxItems {
+			return nil, BadRequestError{Message: fmt.Sprintf("Batch exceeds the maximum of %d requests", opts.MaxItems)}
+		}
+		results := make([]BatchResult, len(items))
+		sem := make(chan struct{}, opts.Concurrency)
+		var wg sync.WaitGroup
+		for i, item := range items {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int, item BatchItem) {
+				defer func() { <-sem; wg.Done() }()
+				results[i] = serveBatchItem(h, req.Request, item, opts.Timeout)
+			}(i, item)
+		}
+		wg.Wait()
+		return NewResponse(&BatchResponse{Responses: results}), nil
+	}
+}
+
+// serveBatchItem serves a single item of a batch request with h.
+func serveBatchItem(h http.Handler, parent *http.Request, item BatchItem, timeout time.Duration) BatchResult {
+	ctx, cancel := parent.Context(), context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	defer cancel()
+
+	r, err := newBatchItemRequest(ctx, parent, item)
+	if err != nil {
+		return batchErrorResult(parent, item.ID, err)
+	}
+	// The handler runs in its own goroutine, so that the item can time
+	// out even if the handler ignores the context. Its recorder is
+	// abandoned in that case.
+	rec := &batchResponseWriter{header: make(http.Header)}
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("generichttp: panic in batch item: %v", p)
+			}
+		}()
+		h.ServeHTTP(rec, r)
+		done <- nil
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return batchErrorResult(parent, item.ID, err)
+		}
+		return rec.result(item.ID)
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return batchErrorResult(parent, item.ID, GatewayTimeoutError{})
+		}
+		return batchErrorResult(parent, item.ID, ctx.Err())
+	}
+}
+
+// newBatchItemRequest creates the request for a batch item.
+func newBatchItemRequest(ctx context.Context, parent *http.Request, item BatchItem) (*http.Request, error) {
+	method := strings.ToUpper(item.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	if !strings.HasPrefix(item.Path, "/") {
+		return nil, BadRequestError{Message: "Invalid batch request path"}
+	}
+	r, err := http.NewRequestWithContext(ctx, method, item.Path, bytes.NewReader(item.Body))
+	if err != nil {
+		return nil, BadRequestError{Message: "Invalid batch request"}
+	}
+	r.Host = parent.Host
+	r.RemoteAddr = parent.RemoteAddr
+	r.RequestURI = item.Path
+	r.TLS = parent.TLS
+	for k, v := range parent.Header {
+		switch k {
+		case "Content-Length", "Content-Type", "Content-Encoding", "Content-Md5", "Digest":
+		default:
+			r.Header[k] = v
+		}
+	}
+	if len(item.Body) > 0 {
+		r.Header.Set("Content-Type", mediaTypeJSON)
+	}
+	for k, v := range item.Header {
+		r.Header.Set(k, v)
+	}
+	return r, nil
+}
+
+// batchErrorResult returns the result for an item that failed with err.
+func batchErrorResult(r *http.Request, id string, err error) BatchResult {
+	body, _ := Marshal(newErrorBody(r, err))
+	return BatchResult{ID: id, StatusCode: errorCode(err), Body: body}
+}
+
+// batchResponseWriter records the response of a batch item.
+type batchResponseWriter struct {
+	header      http.Header
+	code        int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+// Header implements http.ResponseWriter.
+func (w *batchResponseWriter) Header() http.Header { return w.header }
+
+// WriteHeader implements http.ResponseWriter.
+func (w *batchResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.code, w.wroteHeader = code, true
+}
+
+// Write implements http.ResponseWriter.
+func (w *batchResponseWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(p)
+}
+
+// result returns the recorded response as a BatchResult.
+func (w *batchResponseWriter) result(id string) BatchResult {
+	res := BatchResult{ID: id, StatusCode: w.code}
+	if !w.wroteHeader {
+		res.StatusCode = http.StatusOK
+	}
+	body := bytes.TrimSpace(w.body.Bytes())
+	switch {
+	case len(body) == 0:
+	case json.Valid(body):
+		res.Body = body
+	default:
+		res.Body, _ = Marshal(string(body))
+	}
+	return res
+}
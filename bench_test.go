@@ -0,0 +1,198 @@
+package generichttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// benchUser is the request and response data of the benchmarks, a small
+// object as typical for JSON APIs.
+type benchUser struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Tags      []string  `json:"tags"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+var benchData = benchUser{
+	ID:        42,
+	Name:      "Alice",
+	Email:     "alice@example.com",
+	Tags:      []string{"admin", "beta"},
+	Active:    true,
+	CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+}
+
+func benchBody(b *testing.B) []byte {
+	b.Helper()
+	body, err := json.Marshal(benchData)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return body
+}
+
+// benchRequest returns a function that resets r to a fresh POST request
+// with body, without allocating a new request per iteration.
+func benchRequest(body []byte) (*http.Request, func()) {
+	rd := bytes.NewReader(body)
+	r := httptest.NewRequest(http.MethodPost, "/users", nil)
+	r.Header.Set("Content-Type", "application/json")
+	return r, func() {
+		rd.Reset(body)
+		r.Body = io.NopCloser(rd)
+		r.ContentLength = int64(len(body))
+	}
+}
+
+// discardResponseWriter is a http.ResponseWriter that discards the
+// response, so that benchmarks measure the encoding only.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header         { return w.header }
+func (w *discardResponseWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w *discardResponseWriter) WriteHeader(int)             {}
+
+// useCountingCodec replaces the JSON functions of the package with ones
+// that count their calls, as a stand-in for a faster JSON library, and
+// restores them when the benchmark ends.
+func useCountingCodec(b *testing.B) *atomic.Int64 {
+	calls := new(atomic.Int64)
+	marshal, unmarshal, newDecoder, newEncoder := Marshal, Unmarshal, NewDecoder, NewEncoder
+	// Pooled encoders are created by NewEncoder, so start with an empty
+	// pool, and don't leave encoders of this codec behind.
+	encoderPool = sync.Pool{New: encoderPool.New}
+	b.Cleanup(func() {
+		Marshal, Unmarshal, NewDecoder, NewEncoder = marshal, unmarshal, newDecoder, newEncoder
+		encoderPool = sync.Pool{New: encoderPool.New}
+	})
+	Marshal = func(v any) ([]byte, error) {
+		calls.Add(1)
+		return json.Marshal(v)
+	}
+	Unmarshal = func(data []byte, v any) error {
+		calls.Add(1)
+		return json.Unmarshal(data, v)
+	}
+	NewDecoder = func(r io.Reader) Decoder {
+		calls.Add(1)
+		return json.NewDecoder(r)
+	}
+	NewEncoder = func(w io.Writer) Encoder {
+		calls.Add(1)
+		return json.NewEncoder(w)
+	}
+	return calls
+}
+
+// BenchmarkCodec decodes a request and encodes a response with the
+// default JSON functions and with replaced ones, which must be called.
+func BenchmarkCodec(b *testing.B) {
+	body := benchBody(b)
+	for _, codec := range []string{"encoding/json", "custom"} {
+		b.Run(codec, func(b *testing.B) {
+			var calls *atomic.Int64
+			if codec == "custom" {
+				calls = useCountingCodec(b)
+			}
+			opts := newOptions(DefaultOptions(), nil)
+			r, reset := benchRequest(body)
+			w := &discardResponseWriter{header: make(http.Header)}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				reset()
+				req, err := newRequest[benchUser](w, r, opts)
+				if err != nil {
+					b.Fatal(err)
+				}
+				WriteJSONCode(w, http.StatusOK, req.Data)
+			}
+			if calls != nil {
+				if calls.Load() == 0 {
+					b.Fatal("the custom codec wasn't called")
+				}
+				b.ReportMetric(float64(calls.Load())/float64(b.N), "calls/op")
+			}
+		})
+	}
+}
+
+// BenchmarkNewRequest decodes a small JSON body with a known
+// Content-Length, which is read into a pooled buffer, and with an unknown
+// one, which is streamed into a Decoder as without the pool.
+//
+// go test -run '^$' -bench NewRequest -benchmem -count 3 (Xeon, 1 CPU):
+//
+//	BenchmarkNewRequest/pooled    1881-2093 ns/op  360 B/op  11 allocs/op
+//	BenchmarkNewRequest/unpooled  2970-3173 ns/op  952 B/op  17 allocs/op
+func BenchmarkNewRequest(b *testing.B) {
+	body := benchBody(b)
+	for _, bench := range []struct {
+		name          string
+		contentLength int64
+	}{
+		{"pooled", int64(len(body))},
+		{"unpooled", -1},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			opts := newOptions(DefaultOptions(), nil)
+			r, reset := benchRequest(body)
+			w := &discardResponseWriter{header: make(http.Header)}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				reset()
+				r.ContentLength = bench.contentLength
+				req, err := newRequest[benchUser](w, r, opts)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if req.Data == nil || req.Data.ID != benchData.ID {
+					b.Fatalf("Data = %+v, want %+v", req.Data, benchData)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkWriteJSONCode encodes a small object with the pooled encoder
+// of WriteJSONCode, and with a new encoder per response, as without the
+// pool.
+//
+// go test -run '^$' -bench WriteJSONCode -benchmem -count 3 (Xeon, 1 CPU):
+//
+//	BenchmarkWriteJSONCode/pooled    799-886 ns/op  208 B/op  3 allocs/op
+//	BenchmarkWriteJSONCode/unpooled  804-847 ns/op  336 B/op  4 allocs/op
+func BenchmarkWriteJSONCode(b *testing.B) {
+	w := &discardResponseWriter{header: make(http.Header)}
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			WriteJSONCode(w, http.StatusOK, benchData)
+		}
+	})
+	b.Run("unpooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			w.Header().Set("Content-Type", "application/json")
+			code, data := postProcess(context.Background(), http.StatusOK, benchData)
+			w.WriteHeader(code)
+			if err := NewEncoder(w).Encode(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,180 @@
+package generichttp
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// BindQuery sets the fields of the struct pointed to by dst from the query
+// string of r. Only fields with a query struct tag are set, e.g.
+// `query:"page"`. Fields may be strings, booleans, integers, floats, types
+// that implement encoding.TextUnmarshaler, e.g. time.Time or a custom Money
+// type, and pointers and slices thereof.
+//
+// A malformed query string, e.g. with invalid percent-encoding, or a value
+// that cannot be parsed into its field results in a BadRequestError.
+//
+// JSON and Auto call BindQuery for request data with query struct tags
+// after decoding the request body.
+func BindQuery(r *http.Request, dst any) error {
+	values, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		return BadRequestError{Message: "Invalid query string"}
+	}
+	return bindValues(dst, values, "query", false)
+}
+
+// bindQuery binds the query string of r into the request data in req, if
+// its type has query struct tags. It allocates the data if necessary.
+func bindQuery[T any](r *http.Request, req *Request[T]) error {
+	if !typeHasTag(reflect.TypeOf(req.Data), "query") {
+		return nil
+	}
+	if req.Data == nil {
+		req.Data = new(T)
+	}
+	return BindQuery(r, req.Data)
+}
+
+// bindValues sets the fields of the struct pointed to by dst from values.
+// The name of a field is taken from the given struct tag. If fallback is
+// true, fields without that tag are bound as well, with their names taken
+// from the json struct tag, then from the field name. Fields tagged "-"
+// are skipped. Supported field types are strings, booleans, integers,
+// floats, types that implement encoding.TextUnmarshaler, and pointers and
+// slices thereof.
+func bindValues(dst any, values url.Values, tag string, fallback bool) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("generichttp: cannot bind into %T", dst)
+	}
+	return bindStruct(v.Elem(), values, tag, fallback)
+}
+
+func bindStruct(v reflect.Value, values url.Values, tag string, fallback bool) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && !isTextUnmarshaler(f.Type) {
+			if err := bindStruct(v.Field(i), values, tag, fallback); err != nil {
+				return err
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if _, found := f.Tag.Lookup(tag); !found && !fallback {
+			continue
+		}
+		name := fieldName(f, tag)
+		if name == "" {
+			continue
+		}
+		vals, found := values[name]
+		if !found || len(vals) == 0 {
+			continue
+		}
+		if err := setValues(v.Field(i), vals); err != nil {
+			return BadRequestError{Message: fmt.Sprintf("Invalid value for %s", name)}
+		}
+	}
+	return nil
+}
+
+// fieldName returns the name of f as given by the struct tag, falling
+// back to the json struct tag and the field name. It returns an empty
+// string if the field is to be skipped.
+func fieldName(f reflect.StructField, tag string) string {
+	for _, key := range []string{tag, "json"} {
+		if value, found := f.Tag.Lookup(key); found {
+			name, _, _ := strings.Cut(value, ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+	}
+	return f.Name
+}
+
+// setValues sets v from vals. Slices get all values, other types the
+// first one. Slice types that implement encoding.TextUnmarshaler, e.g.
+// net.IP, are not slices in this sense.
+func setValues(v reflect.Value, vals []string) error {
+	if v.Kind() == reflect.Slice && !isTextUnmarshaler(v.Type()) {
+		s := reflect.MakeSlice(v.Type(), len(vals), len(vals))
+		for i, val := range vals {
+			if err := setValue(s.Index(i), val); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+	return setValue(v, vals[0])
+}
+
+// setValue parses s into v. Types that implement encoding.TextUnmarshaler
+// parse s themselves.
+func setValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Pointer {
+		ptr := reflect.New(v.Type().Elem())
+		if err := setValue(ptr.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	}
+	if v.CanAddr() {
+		if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return u.UnmarshalText([]byte(s))
+		}
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("generichttp: unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// isTextUnmarshaler reports whether a pointer to t implements
+// encoding.TextUnmarshaler.
+func isTextUnmarshaler(t reflect.Type) bool {
+	return reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
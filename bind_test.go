@@ -0,0 +1,162 @@
+package generichttp_test
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/olivere/generichttp"
+	"github.com/olivere/generichttp/testutil"
+)
+
+type bindSearch struct {
+	X    string `query:"x"`
+	Page int    `query:"page"`
+}
+
+func echoBindSearch(w http.ResponseWriter, req generichttp.Request[bindSearch]) (*generichttp.Response[bindSearch], error) {
+	return generichttp.OK(req.Data), nil
+}
+
+func TestBindQueryMalformed(t *testing.T) {
+	for kind, h := range map[string]http.Handler{
+		"JSON": generichttp.JSON(echoBindSearch),
+		"Auto": generichttp.Auto(echoBindSearch),
+	} {
+		t.Run(kind, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/search?page=2&x=%zz", nil))
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+			testutil.AssertJSON(t, w, map[string]any{"message": "Invalid query string"})
+		})
+	}
+
+	var dst bindSearch
+	err := generichttp.BindQuery(httptest.NewRequest(http.MethodGet, "/search?x=%zz", nil), &dst)
+	var bre generichttp.BadRequestError
+	if !errors.As(err, &bre) {
+		t.Errorf("BindQuery error = %v, want a BadRequestError", err)
+	}
+}
+
+func TestBindQuery(t *testing.T) {
+	w := httptest.NewRecorder()
+	generichttp.JSON(echoBindSearch).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/search?page=2&x=%C3%BC", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	testutil.AssertJSON(t, w, bindSearch{X: "ü", Page: 2})
+}
+
+// Money is an amount in cents that binds from values like "$10.50".
+type Money int64
+
+func (m *Money) UnmarshalText(text []byte) error {
+	s, ok := strings.CutPrefix(string(text), "$")
+	if !ok {
+		return errors.New("missing currency")
+	}
+	dollars, cents, _ := strings.Cut(s, ".")
+	d, err := strconv.ParseInt(dollars, 10, 64)
+	if err != nil {
+		return err
+	}
+	if len(cents) > 2 {
+		return errors.New("invalid cents")
+	}
+	c, err := strconv.ParseInt(cents+"00"[len(cents):], 10, 64)
+	if err != nil {
+		return err
+	}
+	*m = Money(d*100 + c)
+	return nil
+}
+
+func (m Money) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("$%d.%02d", m/100, m%100)), nil
+}
+
+type bindOrder struct {
+	Amount Money     `query:"amount" json:"amount"`
+	Max    *Money    `query:"max" json:"max,omitempty"`
+	Prices []Money   `query:"price" json:"prices,omitempty"`
+	IP     net.IP    `query:"ip" json:"ip,omitempty"`
+	Since  time.Time `query:"since" json:"since"`
+}
+
+func echoBindOrder(w http.ResponseWriter, req generichttp.Request[bindOrder]) (*generichttp.Response[bindOrder], error) {
+	return generichttp.OK(req.Data), nil
+}
+
+func TestBindQueryTextUnmarshaler(t *testing.T) {
+	var dst bindOrder
+	r := httptest.NewRequest(http.MethodGet, "/orders?amount=$10.50", nil)
+	if err := generichttp.BindQuery(r, &dst); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Amount != 1050 {
+		t.Errorf("Amount = %d, want 1050 cents", dst.Amount)
+	}
+
+	// An embedded type that unmarshals itself is bound as a value.
+	var total struct {
+		Money `query:"total"`
+	}
+	if err := generichttp.BindQuery(httptest.NewRequest(http.MethodGet, "/orders?total=$99", nil), &total); err != nil || total.Money != 9900 {
+		t.Errorf("embedded Money = %d, %v; want 9900 cents", total.Money, err)
+	}
+
+	w := httptest.NewRecorder()
+	generichttp.JSON(echoBindOrder).ServeHTTP(w, httptest.NewRequest(http.MethodGet,
+		"/orders?amount=$10.5&max=$20.00&price=$1.25&price=$0.99&ip=10.0.0.1&ip=10.0.0.2&since=2024-03-01T12:00:00Z", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	testutil.AssertJSON(t, w, map[string]any{
+		"amount": "$10.50",
+		"max":    "$20.00",
+		"prices": []any{"$1.25", "$0.99"},
+		"ip":     "10.0.0.1",
+		"since":  "2024-03-01T12:00:00Z",
+	})
+}
+
+func TestBindFormTextUnmarshaler(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("amount=%2410.50&max=%245"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	generichttp.Auto(echoBindOrder).ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	testutil.AssertJSON(t, w, map[string]any{
+		"amount": "$10.50",
+		"max":    "$5.00",
+		"since":  "0001-01-01T00:00:00Z",
+	})
+}
+
+func TestBindQueryTextUnmarshalerError(t *testing.T) {
+	for target, field := range map[string]string{
+		"/orders?amount=10.50":     "amount",
+		"/orders?max=$1.999":       "max",
+		"/orders?price=$1&price=x": "price",
+		"/orders?since=yesterday":  "since",
+	} {
+		w := httptest.NewRecorder()
+		generichttp.JSON(echoBindOrder).ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", target, w.Code, http.StatusBadRequest)
+			continue
+		}
+		testutil.AssertJSON(t, w, map[string]any{"message": "Invalid value for " + field})
+	}
+}
@@ -0,0 +1,76 @@
+package generichttp
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// TeeRequestBody returns a shallow copy of r whose body duplicates the
+// first limit bytes read from it to w, e.g. a bytes.Buffer. Use it in
+// middleware to inspect the raw body after the handler ran, while JSON,
+// Auto, or NewRequest still decode it as usual and the body is read only
+// once. A limit of 0 or less duplicates all bytes read.
+//
+// Only bytes actually read are duplicated, so w gets no more than the
+// body limit of the handler, 1 MiB unless set with WithMaxBodySize, also
+// for handlers that read the body themselves, see WithStreamBody. Memory
+// use is up to w; with a bytes.Buffer and a limit, it is bounded by the
+// limit.
+func TeeRequestBody(r *http.Request, w io.Writer, limit int64) *http.Request {
+	r2 := r.WithContext(r.Context())
+	if r.Body == nil || r.Body == http.NoBody {
+		return r2
+	}
+	if limit > 0 {
+		w = &limitedWriter{w: w, n: limit}
+	}
+	r2.Body = readCloser{Reader: io.TeeReader(r.Body, w), Closer: r.Body}
+	return r2
+}
+
+// PeekRequestBody returns up to the first n bytes of the body of r without
+// consuming them, e.g. for content sniffing. The body of r is replaced by
+// one that returns the peeked bytes first, so only n bytes are buffered
+// instead of the whole body. A read error is returned, but the bytes read
+// until then are not lost.
+func PeekRequestBody(r *http.Request, n int) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody || n <= 0 {
+		return nil, nil
+	}
+	prefix := make([]byte, n)
+	m, err := io.ReadFull(r.Body, prefix)
+	prefix = prefix[:m]
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(prefix), r.Body), Closer: r.Body}
+	return prefix, err
+}
+
+// readCloser combines a Reader and a Closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// limitedWriter writes up to n bytes to w and discards the rest.
+type limitedWriter struct {
+	w io.Writer
+	n int64
+}
+
+// Write implements io.Writer. It always reports success, so that the
+// reader of an io.TeeReader doesn't fail.
+func (lw *limitedWriter) Write(p []byte) (int, error) {
+	if lw.n <= 0 {
+		return len(p), nil
+	}
+	q := p
+	if int64(len(q)) > lw.n {
+		q = q[:lw.n]
+	}
+	lw.n -= int64(len(q))
+	_, _ = lw.w.Write(q)
+	return len(p), nil
+}
@@ -0,0 +1,223 @@
+package generichttp
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a circuit breaker, see CircuitBreaker.
+type CircuitState int
+
+const (
+	// CircuitClosed passes requests through and counts failures.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects requests.
+	CircuitOpen
+	// CircuitHalfOpen passes a limited number of probe requests through
+	// to decide whether to close or open the circuit again.
+	CircuitHalfOpen
+)
+
+// String returns the name of the state, e.g. "open".
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreakerOptions configures the CircuitBreaker middleware.
+type CircuitBreakerOptions struct {
+	// FailureRatio is the ratio of failed requests in a window that opens
+	// the circuit, e.g. 0.5 for half of the requests. It defaults to 0.5.
+	FailureRatio float64
+	// MinRequests is the number of requests in a window before the ratio
+	// is evaluated, so that a few failures after a quiet period don't open
+	// the circuit. It defaults to 10.
+	MinRequests int
+	// ConsecutiveFailures opens the circuit after this many failures in a
+	// row, regardless of the ratio. Zero disables it.
+	ConsecutiveFailures int
+	// Window is the duration over which requests are counted while the
+	// circuit is closed. Counts are reset at the end of each window. It
+	// defaults to one minute.
+	Window time.Duration
+	// OpenTimeout is how long the circuit stays open before probing. It
+	// defaults to 30 seconds.
+	OpenTimeout time.Duration
+	// HalfOpenRequests is the number of probe requests passed through in
+	// the half-open state. If all of them succeed, the circuit closes; a
+	// single failure opens it again. It defaults to 1.
+	HalfOpenRequests int
+	// IsFailure reports whether a response with the given status code is
+	// a failure. It defaults to status codes of 500 and above, which
+	// includes errors without a HTTPCode returned by handlers. Panics are
+	// always failures.
+	IsFailure func(status int) bool
+	// OnStateChange, if set, is called on every state transition, e.g. to
+	// update metrics. It is called synchronously, so it must be fast, and
+	// must not call into the circuit breaker.
+	OnStateChange func(from, to CircuitState)
+}
+
+// CircuitBreaker returns a middleware that protects the next handler, and
+// the dependencies behind it, from cascading failures. While the circuit
+// is closed, requests pass through and failures are counted. When the
+// failures exceed the thresholds in opts, the circuit opens and requests
+// are rejected with a ServiceUnavailableError and a Retry-After header,
+// without calling the next handler. After OpenTimeout, the circuit is
+// half-open and lets a few probe requests through to decide whether to
+// close again.
+//
+// Use a separate circuit breaker per dependency or group of routes, so
+// that a failing endpoint doesn't reject requests to healthy ones.
+func CircuitBreaker(opts CircuitBreakerOptions) Middleware {
+	if opts.FailureRatio <= 0 {
+		opts.FailureRatio = 0.5
+	}
+	if opts.MinRequests <= 0 {
+		opts.MinRequests = 10
+	}
+	if opts.Window <= 0 {
+		opts.Window = time.Minute
+	}
+	if opts.OpenTimeout <= 0 {
+		opts.OpenTimeout = 30 * time.Second
+	}
+	if opts.HalfOpenRequests <= 0 {
+		opts.HalfOpenRequests = 1
+	}
+	if opts.IsFailure == nil {
+		opts.IsFailure = func(status int) bool { return status >= http.StatusInternalServerError }
+	}
+	cb := &circuitBreaker{opts: opts, windowStart: time.Now()}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gen, retryAfter, ok := cb.allow()
+			if !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				writeJSONError(w, r, ServiceUnavailableError{Message: "Service temporarily unavailable"})
+				return
+			}
+			tw := NewTeeResponseWriter(w, nil)
+			failed := true
+			defer func() {
+				cb.record(gen, failed)
+			}()
+			next.ServeHTTP(tw, r)
+			status := tw.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			failed = opts.IsFailure(status)
+		})
+	}
+}
+
+// circuitBreaker is the state of a CircuitBreaker.
+type circuitBreaker struct {
+	opts CircuitBreakerOptions
+
+	mu          sync.Mutex
+	state       CircuitState
+	gen         uint64    // incremented on every state transition
+	windowStart time.Time // start of the counting window while closed
+	requests    int       // requests in the window
+	failures    int       // failures in the window
+	consecutive int       // consecutive failures
+	openedAt    time.Time // time the circuit opened
+	probes      int       // probe requests let through while half-open
+	successes   int       // successful probe requests
+}
+
+// allow reports whether a request may pass, and returns the generation of
+// the state to record its outcome with. If the request may not pass, it
+// returns the duration until the circuit is half-open.
+func (cb *circuitBreaker) allow() (uint64, time.Duration, bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	now := time.Now()
+	switch cb.state {
+	case CircuitOpen:
+		if elapsed := now.Sub(cb.openedAt); elapsed < cb.opts.OpenTimeout {
+			return 0, cb.opts.OpenTimeout - elapsed, false
+		}
+		cb.setState(CircuitHalfOpen)
+		fallthrough
+	case CircuitHalfOpen:
+		if cb.probes >= cb.opts.HalfOpenRequests {
+			return 0, time.Second, false
+		}
+		cb.probes++
+	default:
+		if now.Sub(cb.windowStart) >= cb.opts.Window {
+			cb.windowStart, cb.requests, cb.failures = now, 0, 0
+		}
+	}
+	return cb.gen, 0, true
+}
+
+// record records the outcome of a request that was allowed in the given
+// generation. Outcomes of requests that started in an earlier state are
+// ignored, e.g. of slow requests that finish after the circuit opened.
+func (cb *circuitBreaker) record(gen uint64, failed bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if gen != cb.gen {
+		return
+	}
+	switch cb.state {
+	case CircuitHalfOpen:
+		if failed {
+			cb.open()
+			return
+		}
+		cb.successes++
+		if cb.successes >= cb.opts.HalfOpenRequests {
+			cb.setState(CircuitClosed)
+		}
+	case CircuitClosed:
+		cb.requests++
+		if failed {
+			cb.failures++
+			cb.consecutive++
+		} else {
+			cb.consecutive = 0
+		}
+		// The ratio is checked after successes as well, as the request
+		// that reaches MinRequests may be one.
+		if (cb.opts.ConsecutiveFailures > 0 && cb.consecutive >= cb.opts.ConsecutiveFailures) ||
+			(cb.requests >= cb.opts.MinRequests && float64(cb.failures) >= cb.opts.FailureRatio*float64(cb.requests)) {
+			cb.open()
+		}
+	}
+}
+
+// open opens the circuit.
+func (cb *circuitBreaker) open() {
+	cb.openedAt = time.Now()
+	cb.setState(CircuitOpen)
+}
+
+// setState changes the state and resets the counters of the new state.
+// The caller must hold the lock.
+func (cb *circuitBreaker) setState(state CircuitState) {
+	from := cb.state
+	cb.state = state
+	cb.gen++
+	cb.probes, cb.successes = 0, 0
+	if state == CircuitClosed {
+		cb.windowStart, cb.requests, cb.failures, cb.consecutive = time.Now(), 0, 0, 0
+	}
+	if cb.opts.OnStateChange != nil && from != state {
+		cb.opts.OnStateChange(from, state)
+	}
+}
@@ -0,0 +1,140 @@
+package generichttp_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/olivere/generichttp"
+	"github.com/olivere/generichttp/testutil"
+)
+
+// flakyBackend is a handler that fails while fail is set and counts its
+// calls.
+type flakyBackend struct {
+	fail  bool
+	calls int
+}
+
+func (b *flakyBackend) handler() http.Handler {
+	return generichttp.JSON(func(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[struct{}], error) {
+		b.calls++
+		if b.fail {
+			return nil, errors.New("connection refused")
+		}
+		return nil, nil
+	})
+}
+
+func serveBreaker(h http.Handler) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	return w
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var transitions []string
+	backend := &flakyBackend{}
+	h := generichttp.CircuitBreaker(generichttp.CircuitBreakerOptions{
+		MinRequests:      4,
+		OpenTimeout:      50 * time.Millisecond,
+		HalfOpenRequests: 2,
+		OnStateChange: func(from, to generichttp.CircuitState) {
+			transitions = append(transitions, fmt.Sprintf("%v->%v", from, to))
+		},
+	})(backend.handler())
+
+	// Failures below MinRequests don't open the circuit.
+	for i, fail := range []bool{false, true, true} {
+		backend.fail = fail
+		if w := serveBreaker(h); w.Code == http.StatusServiceUnavailable {
+			t.Fatalf("request %d: circuit opened before MinRequests", i)
+		}
+	}
+	backend.fail = false
+	serveBreaker(h) // 2 of 4 failed, which meets the ratio of 0.5
+	if len(transitions) != 1 || transitions[0] != "closed->open" {
+		t.Fatalf("transitions = %q, want the circuit to open", transitions)
+	}
+
+	calls := backend.calls
+	w := serveBreaker(h)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("open: status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("open: Retry-After = %q, want %q", got, "1")
+	}
+	testutil.AssertJSON(t, w, map[string]any{"message": "Service temporarily unavailable"})
+	if backend.calls != calls {
+		t.Errorf("open: backend called %d times, want none", backend.calls-calls)
+	}
+
+	// After OpenTimeout, successful probes close the circuit again.
+	time.Sleep(60 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if w := serveBreaker(h); w.Code != http.StatusOK {
+			t.Fatalf("probe %d: status = %d, want %d", i, w.Code, http.StatusOK)
+		}
+	}
+	want := []string{"closed->open", "open->half-open", "half-open->closed"}
+	if fmt.Sprint(transitions) != fmt.Sprint(want) {
+		t.Fatalf("transitions = %q, want %q", transitions, want)
+	}
+	if w := serveBreaker(h); w.Code != http.StatusOK {
+		t.Errorf("closed: status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestCircuitBreakerProbeFails(t *testing.T) {
+	var transitions []string
+	backend := &flakyBackend{fail: true}
+	h := generichttp.CircuitBreaker(generichttp.CircuitBreakerOptions{
+		ConsecutiveFailures: 3,
+		OpenTimeout:         50 * time.Millisecond,
+		OnStateChange: func(from, to generichttp.CircuitState) {
+			transitions = append(transitions, fmt.Sprintf("%v->%v", from, to))
+		},
+	})(backend.handler())
+
+	for i := 0; i < 3; i++ {
+		if w := serveBreaker(h); w.Code != http.StatusInternalServerError {
+			t.Fatalf("request %d: status = %d, want %d", i, w.Code, http.StatusInternalServerError)
+		}
+	}
+	if w := serveBreaker(h); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("after 3 consecutive failures: status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if w := serveBreaker(h); w.Code != http.StatusInternalServerError {
+		t.Fatalf("probe: status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if w := serveBreaker(h); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("after failed probe: status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	want := []string{"closed->open", "open->half-open", "half-open->open"}
+	if fmt.Sprint(transitions) != fmt.Sprint(want) {
+		t.Errorf("transitions = %q, want %q", transitions, want)
+	}
+}
+
+func TestCircuitBreakerClientErrors(t *testing.T) {
+	calls := 0
+	h := generichttp.CircuitBreaker(generichttp.CircuitBreakerOptions{ConsecutiveFailures: 1})(
+		generichttp.JSON(func(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[struct{}], error) {
+			calls++
+			return nil, generichttp.NotFoundError{}
+		}))
+	for i := 0; i < 5; i++ {
+		if w := serveBreaker(h); w.Code != http.StatusNotFound {
+			t.Fatalf("request %d: status = %d, want %d", i, w.Code, http.StatusNotFound)
+		}
+	}
+	if calls != 5 {
+		t.Errorf("calls = %d, want 5", calls)
+	}
+}
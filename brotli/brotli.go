@@ -0,0 +1,27 @@
+// Package brotli registers the Brotli content coding ("br") for the
+// Compress middleware of generichttp. Import it for its side effect:
+//
+//	import _ "github.com/olivere/generichttp/brotli"
+//
+// Clients that accept both Brotli and gzip with the same q value get
+// Brotli. It is a separate module so that generichttp itself doesn't
+// depend on a Brotli implementation.
+package brotli
+
+import (
+	"io"
+
+	"github.com/andybalholm/brotli"
+	"github.com/olivere/generichttp"
+)
+
+// Level is the compression level of responses, from 0 to 11. It defaults
+// to 6, which trades some compression for speed, as suits dynamic
+// responses. Set it on startup, before serving requests.
+var Level = brotli.DefaultCompression
+
+func init() {
+	generichttp.RegisterCompressor("br", func() generichttp.Compressor {
+		return brotli.NewWriterLevel(io.Discard, Level)
+	})
+}
@@ -0,0 +1,59 @@
+package brotli_test
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/olivere/generichttp"
+	_ "github.com/olivere/generichttp/brotli"
+)
+
+func TestCompress(t *testing.T) {
+	body := strings.Repeat(`{"name":"Alice"}`, 100)
+	h := generichttp.Compress()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	}))
+	tests := []struct {
+		header   string
+		encoding string
+	}{
+		{"gzip, deflate, br", "br"},
+		{"br;q=0.5, gzip", "gzip"},
+		{"br;q=0, *", "gzip"},
+		{"*", "br"},
+		{"identity", ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Encoding", tt.header)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if got := w.Header().Get("Content-Encoding"); got != tt.encoding {
+			t.Fatalf("Accept-Encoding %q: Content-Encoding = %q, want %q", tt.header, got, tt.encoding)
+		}
+		var rd io.Reader = w.Body
+		switch tt.encoding {
+		case "br":
+			rd = brotli.NewReader(w.Body)
+		case "gzip":
+			zr, err := gzip.NewReader(w.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rd = zr
+		}
+		got, err := io.ReadAll(rd)
+		if err != nil {
+			t.Fatalf("Accept-Encoding %q: %v", tt.header, err)
+		}
+		if string(got) != body {
+			t.Errorf("Accept-Encoding %q: body = %q, want %q", tt.header, got, body)
+		}
+	}
+}
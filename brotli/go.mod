@@ -0,0 +1,12 @@
+module github.com/olivere/generichttp/brotli
+
+go 1.22
+
+require (
+	github.com/andybalholm/brotli v1.2.5
+	github.com/olivere/generichttp v0.0.0-00010101000000-000000000000
+)
+
+require golang.org/x/text v0.22.0 // indirect
+
+replace github.com/olivere/generichttp => ../
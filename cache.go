@@ -0,0 +1,117 @@
+package generichttp
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CacheOptions configures the Cache middleware.
+type CacheOptions struct {
+	// Store stores the responses. It defaults to a store in memory, see
+	// NewMemoryResponseStore. It may be shared with the Idempotency
+	// middleware, as the keys don't collide.
+	Store ResponseStore
+	// TTL is how long a response is stored. It defaults to one minute.
+	TTL time.Duration
+	// Key returns the cache key of a request. It defaults to the path and
+	// query string, plus the Accept, Accept-Encoding, and Accept-Language
+	// headers, which select the representation in this package. Requests
+	// with an Authorization header are not cached unless Key is set, as
+	// their responses are usually specific to the user; include the user
+	// in the key in that case.
+	Key func(r *http.Request) string
+	// MaxSize is the maximum size of a response body to store, in bytes.
+	// Larger responses are passed through. It defaults to 1 MiB.
+	MaxSize int64
+}
+
+// Cache returns a middleware that caches responses to GET requests in a
+// ResponseStore, e.g. for expensive endpoints whose data changes rarely.
+// Responses are served from the store until their TTL expires, with an
+// X-Cache: HIT header; other responses get an X-Cache: MISS header.
+//
+// Only responses with status code 200 are stored, and only if they have
+// no Set-Cookie header and their Cache-Control header doesn't contain
+// no-store, no-cache, or private. Requests with a Cache-Control: no-cache
+// header bypass the cache, but their response is stored.
+func Cache(opts CacheOptions) Middleware {
+	if opts.Store == nil {
+		opts.Store = NewMemoryResponseStore()
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = time.Minute
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = defaultMaxBodySize
+	}
+	keyFunc := opts.Key
+	if keyFunc == nil {
+		keyFunc = defaultCacheKey
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet || (opts.Key == nil && r.Header.Get("Authorization") != "") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key := "cache " + keyFunc(r)
+			if !strings.Contains(strings.ToLower(r.Header.Get("Cache-Control")), "no-cache") {
+				stored, err := opts.Store.Get(r.Context(), key)
+				if err == nil && stored != nil && stored.StatusCode != 0 {
+					for k, v := range stored.Header {
+						w.Header()[k] = v
+					}
+					w.Header().Set("X-Cache", "HIT")
+					w.WriteHeader(stored.StatusCode)
+					_, err := w.Write(stored.Body)
+					handleWriteError(err)
+					return
+				}
+			}
+
+			w.Header().Set("X-Cache", "MISS")
+			var body bytes.Buffer
+			tw := NewTeeResponseWriter(w, &limitedWriter{w: &body, n: opts.MaxSize})
+			next.ServeHTTP(tw, r)
+			status, header := tw.Status(), tw.WrittenHeader()
+			if status == 0 {
+				status, header = http.StatusOK, w.Header().Clone()
+			}
+			if status != http.StatusOK || tw.Written() > opts.MaxSize || !cacheableHeader(header) {
+				return
+			}
+			header.Del("X-Cache")
+			resp := &StoredResponse{
+				StatusCode: status,
+				Header:     header,
+				Body:       body.Bytes(),
+			}
+			_ = opts.Store.Set(context.WithoutCancel(r.Context()), key, resp, opts.TTL)
+		})
+	}
+}
+
+// defaultCacheKey returns the default cache key of r, see CacheOptions.
+func defaultCacheKey(r *http.Request) string {
+	return r.URL.RequestURI() +
+		"\x00" + r.Header.Get("Accept") +
+		"\x00" + r.Header.Get("Accept-Encoding") +
+		"\x00" + r.Header.Get("Accept-Language")
+}
+
+// cacheableHeader reports whether a response with header h may be stored.
+func cacheableHeader(h http.Header) bool {
+	if h.Get("Set-Cookie") != "" {
+		return false
+	}
+	cc := strings.ToLower(strings.Join(h.Values("Cache-Control"), ","))
+	for _, directive := range []string{"no-store", "no-cache", "private"} {
+		if strings.Contains(cc, directive) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package generichttp
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// digestAlgorithms are the algorithms of the Digest header (RFC 3230)
+// supported by VerifyChecksum.
+var digestAlgorithms = map[string]func() hash.Hash{
+	"md5":     md5.New,
+	"sha-256": sha256.New,
+}
+
+// ChecksumOptions configures the VerifyChecksum middleware.
+type ChecksumOptions struct {
+	// MaxBodySize is the maximum size of a request body with a checksum,
+	// in bytes, as the body is read into memory to verify it. Larger
+	// bodies are rejected with a RequestEntityTooLargeError. It defaults
+	// to 1 MiB, the default of WithMaxBodySize; raise it together with
+	// WithMaxBodySize, e.g. for uploads.
+	MaxBodySize int64
+}
+
+// VerifyChecksum returns a middleware that verifies the request body
+// against the Content-MD5 and Digest headers, if present. The Digest
+// header may contain MD5 and SHA-256 digests as specified in RFC 3230;
+// other algorithms are ignored.
+//
+// The body of a request with a checksum is read into memory, up to
+// MaxBodySize, and handed to the next handler unchanged. A checksum
+// mismatch results in a BadRequestError, a larger body in a
+// RequestEntityTooLargeError. Requests without a checksum are passed
+// through without buffering.
+func VerifyChecksum(opts ChecksumOptions) Middleware {
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = defaultMaxBodySize
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			contentMD5 := r.Header.Get("Content-MD5")
+			digest := r.Header.Get("Digest")
+			if (contentMD5 == "" && digest == "") || r.Body == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			body, err := io.ReadAll(io.LimitReader(r.Body, opts.MaxBodySize+1))
+			if err != nil {
+				writeJSONError(w, r, BadRequestError{Message: "Unable to read request body"})
+				return
+			}
+			if int64(len(body)) > opts.MaxBodySize {
+				writeJSONError(w, r, bodyTooLarge(opts.MaxBodySize))
+				return
+			}
+			if contentMD5 != "" && !checksumMatches(md5.New, body, contentMD5) {
+				writeJSONError(w, r, BadRequestError{Message: "Content-MD5 mismatch"})
+				return
+			}
+			for _, part := range strings.Split(digest, ",") {
+				alg, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+				if !ok {
+					continue
+				}
+				newHash, found := digestAlgorithms[strings.ToLower(alg)]
+				if !found {
+					continue
+				}
+				if !checksumMatches(newHash, body, value) {
+					writeJSONError(w, r, BadRequestError{Message: "Digest mismatch"})
+					return
+				}
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// checksumMatches reports whether the base64-encoded checksum matches
+// the hash of body.
+func checksumMatches(newHash func() hash.Hash, body []byte, checksum string) bool {
+	want, err := base64.StdEncoding.DecodeString(strings.TrimSpace(checksum))
+	if err != nil {
+		return false
+	}
+	h := newHash()
+	h.Write(body)
+	return subtle.ConstantTimeCompare(h.Sum(nil), want) == 1
+}
@@ -0,0 +1,149 @@
+package generichttp_test
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/olivere/generichttp"
+	"github.com/olivere/generichttp/testutil"
+)
+
+const checksumBody = `{"name":"Alice"}`
+
+func checksumOf(sum []byte) string {
+	return base64.StdEncoding.EncodeToString(sum)
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	md5Sum := md5.Sum([]byte(checksumBody))
+	sha256Sum := sha256.Sum256([]byte(checksumBody))
+	sha512Sum := sha512.Sum512([]byte(checksumBody))
+	otherSum := sha256.Sum256([]byte(`{"name":"Bob"}`))
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		code   int
+		msg    string
+	}{
+		{name: "no checksum", code: http.StatusOK},
+		{
+			name:   "Content-MD5",
+			header: map[string]string{"Content-MD5": checksumOf(md5Sum[:])},
+			code:   http.StatusOK,
+		},
+		{
+			name:   "Content-MD5 mismatch",
+			header: map[string]string{"Content-MD5": checksumOf(otherSum[:16])},
+			code:   http.StatusBadRequest,
+			msg:    "Content-MD5 mismatch",
+		},
+		{
+			name:   "Digest MD5",
+			header: map[string]string{"Digest": "MD5=" + checksumOf(md5Sum[:])},
+			code:   http.StatusOK,
+		},
+		{
+			name:   "Digest SHA-256",
+			header: map[string]string{"Digest": "SHA-256=" + checksumOf(sha256Sum[:])},
+			code:   http.StatusOK,
+		},
+		{
+			name:   "Digest SHA-256 mismatch",
+			header: map[string]string{"Digest": "sha-256=" + checksumOf(otherSum[:])},
+			code:   http.StatusBadRequest,
+			msg:    "Digest mismatch",
+		},
+		{
+			name:   "Digest with MD5 and SHA-256",
+			header: map[string]string{"Digest": "md5=" + checksumOf(md5Sum[:]) + ", sha-256=" + checksumOf(sha256Sum[:])},
+			code:   http.StatusOK,
+		},
+		{
+			name:   "Digest with one mismatch",
+			header: map[string]string{"Digest": "md5=" + checksumOf(md5Sum[:]) + ", sha-256=" + checksumOf(otherSum[:])},
+			code:   http.StatusBadRequest,
+			msg:    "Digest mismatch",
+		},
+		{
+			name:   "unknown algorithm is ignored",
+			header: map[string]string{"Digest": "SHA-512=" + checksumOf(sha512Sum[:])},
+			code:   http.StatusOK,
+		},
+		{
+			name:   "unknown algorithm with a wrong digest is ignored",
+			header: map[string]string{"Digest": "SHA-512=" + checksumOf(otherSum[:])},
+			code:   http.StatusOK,
+		},
+		{
+			name:   "unknown and known algorithm",
+			header: map[string]string{"Digest": "unixsum=42, sha-256=" + checksumOf(otherSum[:])},
+			code:   http.StatusBadRequest,
+			msg:    "Digest mismatch",
+		},
+		{
+			name:   "invalid base64",
+			header: map[string]string{"Content-MD5": "not base64!"},
+			code:   http.StatusBadRequest,
+			msg:    "Content-MD5 mismatch",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := generichttp.VerifyChecksum(generichttp.ChecksumOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				got = string(b)
+			}))
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(checksumBody))
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.code {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.code, w.Body)
+			}
+			if tt.code != http.StatusOK {
+				testutil.AssertJSON(t, w, map[string]any{"message": tt.msg})
+				return
+			}
+			if got != checksumBody {
+				t.Errorf("body passed on = %q, want %q", got, checksumBody)
+			}
+		})
+	}
+}
+
+func TestVerifyChecksumMaxBodySize(t *testing.T) {
+	sum := sha256.Sum256([]byte(checksumBody))
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	tests := []struct {
+		name string
+		opts generichttp.ChecksumOptions
+		body string
+		code int
+	}{
+		{"within limit", generichttp.ChecksumOptions{MaxBodySize: int64(len(checksumBody))}, checksumBody, http.StatusOK},
+		{"over limit", generichttp.ChecksumOptions{MaxBodySize: 8}, checksumBody, http.StatusRequestEntityTooLarge},
+		{"over default limit", generichttp.ChecksumOptions{}, strings.Repeat("x", 1<<20+1), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			r.Header.Set("Digest", "sha-256="+checksumOf(sum[:]))
+			w := httptest.NewRecorder()
+			generichttp.VerifyChecksum(tt.opts)(next).ServeHTTP(w, r)
+			if w.Code != tt.code {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.code, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package generichttp
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// CoalesceOptions configures the Coalesce middleware.
+type CoalesceOptions struct {
+	// Key returns the key of a request; concurrent requests with the same
+	// key share a response. It defaults to the method and URL. Requests
+	// with an Authorization header are not coalesced unless Key is set, as
+	// their responses are usually specific to the user; include the user
+	// in the key in that case.
+	Key func(r *http.Request) string
+	// MaxSize is the maximum size of a response body to share, in bytes.
+	// Waiting requests of a larger response are handled on their own. It
+	// defaults to 1 MiB.
+	MaxSize int64
+}
+
+// Coalesce returns a middleware that protects expensive GET and HEAD
+// endpoints from stampedes, e.g. when a cache entry expires: while a
+// request is handled, concurrent requests with the same key wait for it
+// and get a copy of its response instead of calling the next handler
+// again.
+//
+// Responses that are streamed, i.e. flushed by the handler, or larger than
+// MaxSize can't be shared; the waiting requests are then handled
+// independently and concurrently, as if there was no middleware. The same
+// goes if the handler panics or hijacks the connection. A waiting request
+// whose context is canceled stops waiting without a response.
+func Coalesce(opts CoalesceOptions) Middleware {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = defaultMaxBodySize
+	}
+	keyFunc := opts.Key
+	if keyFunc == nil {
+		keyFunc = func(r *http.Request) string { return r.Method + " " + r.URL.String() }
+	}
+	var (
+		mu    sync.Mutex
+		calls = make(map[string]*coalesceCall)
+	)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if (r.Method != http.MethodGet && r.Method != http.MethodHead) ||
+				(opts.Key == nil && r.Header.Get("Authorization") != "") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key := keyFunc(r)
+
+			mu.Lock()
+			if c, found := calls[key]; found {
+				mu.Unlock()
+				select {
+				case <-c.done:
+				case <-r.Context().Done():
+					return
+				}
+				if c.resp == nil {
+					next.ServeHTTP(w, r)
+					return
+				}
+				// Copy the values, as the shared response must not be
+				// changed through the header of one of the waiters.
+				for k, v := range c.resp.Header {
+					w.Header()[k] = append([]string(nil), v...)
+				}
+				w.WriteHeader(c.resp.StatusCode)
+				_, err := w.Write(c.resp.Body)
+				handleWriteError(err)
+				return
+			}
+			c := &coalesceCall{done: make(chan struct{})}
+			calls[key] = c
+			mu.Unlock()
+
+			defer func() {
+				mu.Lock()
+				delete(calls, key)
+				mu.Unlock()
+				close(c.done)
+			}()
+			var body bytes.Buffer
+			cw := &coalesceResponseWriter{
+				TeeResponseWriter: NewTeeResponseWriter(w, &limitedWriter{w: &body, n: opts.MaxSize}),
+			}
+			next.ServeHTTP(cw, r)
+			if cw.flushed || cw.Written() > opts.MaxSize {
+				return
+			}
+			status, header := cw.Status(), cw.WrittenHeader()
+			if status == 0 {
+				status, header = http.StatusOK, w.Header().Clone()
+			}
+			c.resp = &StoredResponse{
+				StatusCode: status,
+				Header:     header,
+				Body:       body.Bytes(),
+			}
+		})
+	}
+}
+
+// coalesceCall is a request in flight in the Coalesce middleware. resp is
+// set before done is closed if the response can be shared.
+type coalesceCall struct {
+	done chan struct{}
+	resp *StoredResponse
+}
+
+// coalesceResponseWriter records whether the response was streamed or the
+// connection hijacked, in which case it can't be shared.
+type coalesceResponseWriter struct {
+	*TeeResponseWriter
+	flushed bool
+}
+
+// Flush implements http.Flusher.
+func (w *coalesceResponseWriter) Flush() {
+	_ = w.FlushError()
+}
+
+// FlushError is used by http.ResponseController, see
+// TeeResponseWriter.FlushError.
+func (w *coalesceResponseWriter) FlushError() error {
+	w.flushed = true
+	return w.TeeResponseWriter.FlushError()
+}
+
+// Hijack implements http.Hijacker.
+func (w *coalesceResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.flushed = true
+	return w.TeeResponseWriter.Hijack()
+}
+
+// Unwrap returns the underlying http.ResponseWriter for
+// http.ResponseController.
+func (w *coalesceResponseWriter) Unwrap() http.ResponseWriter {
+	return w.TeeResponseWriter
+}
@@ -0,0 +1,168 @@
+package generichttp_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/olivere/generichttp"
+)
+
+// slowBackend is an expensive handler that blocks until release is closed.
+type slowBackend struct {
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+	flush   bool
+}
+
+func newSlowBackend() *slowBackend {
+	return &slowBackend{started: make(chan struct{}, 16), release: make(chan struct{})}
+}
+
+func (b *slowBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := b.calls.Add(1)
+	b.started <- struct{}{}
+	<-b.release
+	w.Header().Set("Content-Type", "text/plain")
+	w.Header().Set("X-Call", strconv.Itoa(int(n)))
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, "expensive result")
+	if b.flush {
+		w.(http.Flusher).Flush()
+	}
+}
+
+// serveConcurrently serves n requests to h concurrently, starting the
+// others once the first one is in the backend, and returns the responses
+// after releasing the backend.
+func serveConcurrently(h http.Handler, b *slowBackend, n int) []*httptest.ResponseRecorder {
+	recs := make([]*httptest.ResponseRecorder, n)
+	var wg sync.WaitGroup
+	serve := func(i int) {
+		defer wg.Done()
+		recs[i] = httptest.NewRecorder()
+		h.ServeHTTP(recs[i], httptest.NewRequest(http.MethodGet, "/report?year=2024", nil))
+	}
+	wg.Add(n)
+	go serve(0)
+	<-b.started
+	for i := 1; i < n; i++ {
+		go serve(i)
+	}
+	time.Sleep(50 * time.Millisecond) // let the others start waiting
+	close(b.release)
+	wg.Wait()
+	return recs
+}
+
+func TestCoalesce(t *testing.T) {
+	backend := newSlowBackend()
+	recs := serveConcurrently(generichttp.Coalesce(generichttp.CoalesceOptions{})(backend), backend, 5)
+	if n := backend.calls.Load(); n != 1 {
+		t.Errorf("backend called %d times, want once", n)
+	}
+	for i, w := range recs {
+		if w.Code != http.StatusOK || w.Body.String() != "expensive result" || w.Header().Get("X-Call") != "1" {
+			t.Errorf("response %d: status = %d, X-Call = %q, body = %q; want the shared response", i, w.Code, w.Header().Get("X-Call"), w.Body)
+		}
+	}
+
+	// The waiters got copies of the shared header.
+	recs[1].Header()["X-Call"][0] = "changed"
+	for _, i := range []int{0, 2, 3, 4} {
+		if got := recs[i].Header().Get("X-Call"); got != "1" {
+			t.Errorf("response %d: X-Call = %q after changing another response", i, got)
+		}
+	}
+}
+
+func TestCoalesceStreamed(t *testing.T) {
+	backend := newSlowBackend()
+	backend.flush = true
+	recs := serveConcurrently(generichttp.Coalesce(generichttp.CoalesceOptions{})(backend), backend, 3)
+	if n := backend.calls.Load(); n != 3 {
+		t.Errorf("backend called %d times, want once per request for a streamed response", n)
+	}
+	calls := make(map[string]bool)
+	for i, w := range recs {
+		if w.Code != http.StatusOK || w.Body.String() != "expensive result" {
+			t.Errorf("response %d: status = %d, body = %q", i, w.Code, w.Body)
+		}
+		calls[w.Header().Get("X-Call")] = true
+	}
+	if len(calls) != 3 {
+		t.Errorf("X-Call values = %v, want a response of its own for each request", calls)
+	}
+}
+
+func TestCoalesceWaiterCanceled(t *testing.T) {
+	backend := newSlowBackend()
+	h := generichttp.Coalesce(generichttp.CoalesceOptions{})(backend)
+
+	first := make(chan struct{})
+	go func() {
+		defer close(first)
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/report", nil))
+	}()
+	<-backend.started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	waiter := make(chan struct{})
+	w := httptest.NewRecorder()
+	go func() {
+		defer close(waiter)
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/report", nil).WithContext(ctx))
+	}()
+	cancel()
+	<-waiter // returns while the backend is still blocked
+	close(backend.release)
+	<-first
+
+	if n := backend.calls.Load(); n != 1 {
+		t.Errorf("backend called %d times, want once", n)
+	}
+	if w.Body.Len() != 0 || w.Header().Get("X-Call") != "" {
+		t.Errorf("canceled waiter got a response: %v %q", w.Header(), w.Body)
+	}
+}
+
+func TestCoalesceSkipped(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		auth   bool
+	}{
+		{"POST", http.MethodPost, false},
+		{"Authorization", http.MethodGet, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newSlowBackend()
+			h := generichttp.Coalesce(generichttp.CoalesceOptions{})(backend)
+			var wg sync.WaitGroup
+			wg.Add(2)
+			for i := 0; i < 2; i++ {
+				go func() {
+					defer wg.Done()
+					r := httptest.NewRequest(tt.method, "/report", nil)
+					if tt.auth {
+						r.Header.Set("Authorization", "Bearer token")
+					}
+					h.ServeHTTP(httptest.NewRecorder(), r)
+				}()
+			}
+			// Both requests reach the backend before it is released.
+			<-backend.started
+			<-backend.started
+			close(backend.release)
+			wg.Wait()
+		})
+	}
+}
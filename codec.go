@@ -0,0 +1,105 @@
+package generichttp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// Decoder decodes JSON values from a stream, like json.Decoder.
+type Decoder interface {
+	Decode(v any) error
+}
+
+// Encoder encodes JSON values to a stream, like json.Encoder.
+type Encoder interface {
+	Encode(v any) error
+}
+
+// The functions used to encode and decode JSON. They default to
+// encoding/json. Replace them on startup, before serving requests,
+// to use a different JSON library. Unmarshal must not retain the data
+// passed to it, which is reused for other requests. E.g.:
+//
+//	generichttp.NewDecoder = func(r io.Reader) generichttp.Decoder {
+//		return sonic.ConfigStd.NewDecoder(r)
+//	}
+var (
+	Marshal    = json.Marshal
+	Unmarshal  = json.Unmarshal
+	NewDecoder = func(r io.Reader) Decoder { return json.NewDecoder(r) }
+	NewEncoder = func(w io.Writer) Encoder { return json.NewEncoder(w) }
+)
+
+// EncoderSettings configures the JSON encoding of a response, see
+// WithEncoderSettings.
+type EncoderSettings struct {
+	// NewEncoder creates the encoder of the response body, e.g. one of a
+	// JSON library configured with a different time format. Nil uses
+	// NewEncoder.
+	NewEncoder func(w io.Writer) Encoder
+	// NamingPolicy renames the fields of the response data instead of the
+	// NamingPolicy of the options. Nil keeps the one of the options.
+	NamingPolicy NamingPolicy
+}
+
+// WithEncoderSettings selects the settings for encoding each response
+// of JSON, and of Auto if it renders JSON, e.g. per tenant:
+//
+//	settings := map[string]*generichttp.EncoderSettings{
+//		"legacy": {NamingPolicy: generichttp.SnakeCase},
+//	}
+//	h := generichttp.JSON(getItem, generichttp.WithEncoderSettings(func(ctx context.Context) *generichttp.EncoderSettings {
+//		tenant, _ := generichttp.TenantFromContext(ctx)
+//		return settings[tenant]
+//	}))
+//
+// A nil result uses the settings of the options and the global NewEncoder.
+// Error responses are always encoded with the global settings.
+//
+// The function is called once per request, before the handler, so that
+// the paths of WithFieldSelection are checked against the naming policy of
+// the response. It should return settings that are prepared once, e.g.
+// looked up in a map, instead of creating them per call. Encoders created
+// by EncoderSettings.NewEncoder are not pooled like the default ones, so
+// prefer a cheap constructor.
+func WithEncoderSettings(fn func(ctx context.Context) *EncoderSettings) Option {
+	return func(o *Options) {
+		o.EncoderSettings = fn
+	}
+}
+
+// encoderSettingsKey is the context key of the EncoderSettings of a
+// response.
+type encoderSettingsKey struct{}
+
+// encoderSettings returns the EncoderSettings selected by opts for r, and
+// r with them in its context for encodeJSONContext.
+func encoderSettings(r *http.Request, opts *Options) (*EncoderSettings, *http.Request) {
+	if opts.EncoderSettings == nil {
+		return nil, r
+	}
+	s := opts.EncoderSettings(r.Context())
+	if s == nil || s.NewEncoder == nil {
+		return s, r
+	}
+	return s, r.WithContext(context.WithValue(r.Context(), encoderSettingsKey{}, s))
+}
+
+// namingPolicy returns the NamingPolicy of s, or policy if s has none.
+func (s *EncoderSettings) namingPolicy(policy NamingPolicy) NamingPolicy {
+	if s == nil || s.NamingPolicy == nil {
+		return policy
+	}
+	return s.NamingPolicy
+}
+
+// encodeJSONContext encodes v to w with the encoder of the EncoderSettings
+// in ctx, if any, or with a pooled encoder, see encodeJSON.
+func encodeJSONContext(ctx context.Context, w io.Writer, v any) error {
+	if s, ok := ctx.Value(encoderSettingsKey{}).(*EncoderSettings); ok {
+		return s.NewEncoder(w).Encode(v)
+	}
+	return encodeJSON(w, v)
+}
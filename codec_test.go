@@ -0,0 +1,112 @@
+package generichttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type codecTenantKey struct{}
+
+type codecUser struct {
+	UserID      int
+	DisplayName string
+}
+
+func getCodecUser(w http.ResponseWriter, req Request[struct{}]) (*Response[codecUser], error) {
+	return OK(&codecUser{UserID: 1, DisplayName: "Alice"}), nil
+}
+
+// codecSettings selects SnakeCase for the tenant "legacy".
+func codecSettings(ctx context.Context) *EncoderSettings {
+	if ctx.Value(codecTenantKey{}) == "legacy" {
+		return &EncoderSettings{NamingPolicy: SnakeCase}
+	}
+	return nil
+}
+
+func TestEncoderSettingsNamingPolicy(t *testing.T) {
+	opts := []Option{WithNamingPolicy(CamelCase), WithEncoderSettings(codecSettings), WithFieldSelection()}
+	tests := []struct {
+		name   string
+		tenant string
+		query  string
+		code   int
+		want   map[string]any
+	}{
+		{"global", "", "", http.StatusOK, map[string]any{"userID": 1.0, "displayName": "Alice"}},
+		{"tenant", "legacy", "", http.StatusOK, map[string]any{"user_id": 1.0, "display_name": "Alice"}},
+		{"global fields", "", "?fields=userID", http.StatusOK, map[string]any{"userID": 1.0}},
+		{"tenant fields", "legacy", "?fields=user_id", http.StatusOK, map[string]any{"user_id": 1.0}},
+		{"tenant fields of global policy", "legacy", "?fields=userID", http.StatusBadRequest, nil},
+		{"global fields of tenant policy", "", "?fields=user_id", http.StatusBadRequest, nil},
+	}
+	for kind, h := range map[string]http.Handler{
+		"JSON": JSON(getCodecUser, opts...),
+		"Auto": Auto(getCodecUser, opts...),
+	} {
+		for _, tt := range tests {
+			t.Run(kind+"/"+tt.name, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodGet, "/"+tt.query, nil)
+				if tt.tenant != "" {
+					r = r.WithContext(context.WithValue(r.Context(), codecTenantKey{}, tt.tenant))
+				}
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, r)
+				if w.Code != tt.code {
+					t.Fatalf("status = %d, want %d: %s", w.Code, tt.code, w.Body)
+				}
+				if tt.want == nil {
+					return
+				}
+				var got map[string]any
+				if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("body = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+type prefixEncoder struct {
+	w io.Writer
+}
+
+func (e prefixEncoder) Encode(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(append([]byte("tenant:"), b...))
+	return err
+}
+
+func TestEncoderSettingsNewEncoder(t *testing.T) {
+	h := JSON(getCodecUser, WithEncoderSettings(func(ctx context.Context) *EncoderSettings {
+		if ctx.Value(codecTenantKey{}) == "legacy" {
+			return &EncoderSettings{NewEncoder: func(w io.Writer) Encoder { return prefixEncoder{w} }}
+		}
+		return nil
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(context.WithValue(r.Context(), codecTenantKey{}, "legacy"))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if !bytes.HasPrefix(w.Body.Bytes(), []byte("tenant:")) {
+		t.Errorf("body = %q, want it encoded by the encoder of the settings", w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if bytes.HasPrefix(w.Body.Bytes(), []byte("tenant:")) {
+		t.Errorf("body = %q, want it encoded by the global encoder", w.Body)
+	}
+}
@@ -0,0 +1,128 @@
+package generichttp
+
+import (
+	"reflect"
+	"sync"
+)
+
+// WithEmptyCollections makes JSON and Auto render nil slices as [] and nil
+// maps as {} instead of null in response data, so clients can rely on
+// lists being arrays. Fields with omitempty are omitted either way. Byte
+// slices are left alone, as they are encoded as base64 strings.
+//
+// The response data is changed in place before encoding, so don't use it
+// with data that is shared between requests, e.g. from a cache, unless it
+// is fine to change that as well. Data is only walked for types that can
+// contain slices or maps. It is off by default.
+//
+// Without this option, construct responses with empty collections, e.g.
+// make([]Item, 0) instead of a nil slice.
+func WithEmptyCollections() Option {
+	return func(o *Options) {
+		o.EmptyCollections = true
+	}
+}
+
+// collectionCache caches the results of typeHasCollections.
+var collectionCache sync.Map // map[reflect.Type]bool
+
+// typeHasCollections reports whether t or any type reachable from t is a
+// slice or map.
+func typeHasCollections(t reflect.Type) bool {
+	if found, ok := collectionCache.Load(t); ok {
+		return found.(bool)
+	}
+	found := hasCollections(t, make(map[reflect.Type]bool))
+	collectionCache.Store(t, found)
+	return found
+}
+
+func hasCollections(t reflect.Type, visited map[reflect.Type]bool) bool {
+	if visited[t] {
+		return false
+	}
+	visited[t] = true
+	switch t.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Interface:
+		return true
+	case reflect.Pointer, reflect.Array:
+		return hasCollections(t.Elem(), visited)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.IsExported() || f.Anonymous {
+				if hasCollections(f.Type, visited) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// fillEmptyCollections replaces nil slices and maps in data by empty ones.
+func fillEmptyCollections(data any) {
+	v := reflect.ValueOf(data)
+	if !v.IsValid() || !typeHasCollections(v.Type()) {
+		return
+	}
+	fillCollections(v, make(map[uintptr]bool))
+}
+
+// fillCollections replaces nil slices and maps in v, which must be
+// settable to replace v itself. Pointers already visited are skipped to
+// handle cycles.
+func fillCollections(v reflect.Value, visited map[uintptr]bool) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() || visited[v.Pointer()] {
+			return
+		}
+		visited[v.Pointer()] = true
+		fillCollections(v.Elem(), visited)
+	case reflect.Interface:
+		if !v.IsNil() && v.Elem().Kind() == reflect.Pointer {
+			fillCollections(v.Elem(), visited)
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return
+		}
+		if v.IsNil() {
+			if v.CanSet() {
+				v.Set(reflect.MakeSlice(v.Type(), 0, 0))
+			}
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			fillCollections(v.Index(i), visited)
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			fillCollections(v.Index(i), visited)
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			if v.CanSet() {
+				v.Set(reflect.MakeMap(v.Type()))
+			}
+			return
+		}
+		if !v.CanInterface() || !typeHasCollections(v.Type().Elem()) {
+			return
+		}
+		// Map values are not addressable, so fill a copy and store it.
+		iter := v.MapRange()
+		for iter.Next() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(iter.Value())
+			fillCollections(elem, visited)
+			v.SetMapIndex(iter.Key(), elem)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Type().Field(i); f.IsExported() || f.Anonymous {
+				fillCollections(v.Field(i), visited)
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package generichttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/olivere/generichttp"
+)
+
+type collectionNode struct {
+	Name     string            `json:"name"`
+	Children []*collectionNode `json:"children"`
+	Parent   *collectionNode   `json:"parent,omitempty"`
+}
+
+type collectionPage struct {
+	Items    []string                  `json:"items"`
+	Labels   map[string]string         `json:"labels"`
+	Optional []string                  `json:"optional,omitempty"`
+	Raw      []byte                    `json:"raw"`
+	Groups   map[string]collectionNode `json:"groups"`
+	Nested   [2]collectionNode         `json:"nested"`
+	Any      any                       `json:"any"`
+}
+
+// serveCollection serves data with JSON and Auto and returns the bodies.
+func serveCollection[T any](t *testing.T, data *T, opts ...generichttp.Option) map[string]string {
+	t.Helper()
+	h := func(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[T], error) {
+		return generichttp.OK(data), nil
+	}
+	bodies := make(map[string]string)
+	for kind, handler := range map[string]http.Handler{
+		"JSON": generichttp.JSON(h, opts...),
+		"Auto": generichttp.Auto(h, opts...),
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want %d", kind, w.Code, http.StatusOK)
+		}
+		bodies[kind] = w.Body.String()
+	}
+	return bodies
+}
+
+func TestEmptyCollections(t *testing.T) {
+	var list []string
+	for kind, body := range serveCollection(t, &list, generichttp.WithEmptyCollections()) {
+		if body != "[]\n" {
+			t.Errorf("%s: nil slice: body = %q, want %q", kind, body, "[]\n")
+		}
+	}
+
+	var m map[string]int
+	for kind, body := range serveCollection(t, &m, generichttp.WithEmptyCollections()) {
+		if body != "{}\n" {
+			t.Errorf("%s: nil map: body = %q, want %q", kind, body, "{}\n")
+		}
+	}
+
+	root := &collectionNode{Name: "root", Children: []*collectionNode{{Name: "child"}}}
+	page := &collectionPage{
+		Groups: map[string]collectionNode{"g": {Name: "g"}},
+		Any:    &collectionNode{Name: "any"},
+	}
+	want := `{"items":[],"labels":{},"raw":null,` +
+		`"groups":{"g":{"name":"g","children":[]}},` +
+		`"nested":[{"name":"","children":[]},{"name":"","children":[]}],` +
+		`"any":{"name":"any","children":[]}}` + "\n"
+	for kind, body := range serveCollection(t, page, generichttp.WithEmptyCollections()) {
+		if body != want {
+			t.Errorf("%s: body = %s, want %s", kind, body, want)
+		}
+	}
+
+	want = `{"name":"root","children":[{"name":"child","children":[]}]}` + "\n"
+	for kind, body := range serveCollection(t, root, generichttp.WithEmptyCollections()) {
+		if body != want {
+			t.Errorf("%s: body = %s, want %s", kind, body, want)
+		}
+	}
+}
+
+func TestEmptyCollectionsCycle(t *testing.T) {
+	type cyclic struct {
+		Self *cyclic  `json:"-"`
+		Tags []string `json:"tags"`
+	}
+	c := &cyclic{}
+	c.Self = c
+	for kind, body := range serveCollection(t, c, generichttp.WithEmptyCollections()) {
+		if body != `{"tags":[]}`+"\n" {
+			t.Errorf("%s: body = %q, want %q", kind, body, `{"tags":[]}`+"\n")
+		}
+	}
+}
+
+func TestEmptyCollectionsOff(t *testing.T) {
+	var list []string
+	for kind, body := range serveCollection(t, &list) {
+		if body != "null\n" {
+			t.Errorf("%s: body = %q, want %q without the option", kind, body, "null\n")
+		}
+	}
+}
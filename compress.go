@@ -0,0 +1,182 @@
+package generichttp
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// Compressor is a writer that compresses data for a content coding, e.g.
+// a *gzip.Writer. Close must flush all data, and Reset must discard the
+// state of the writer and make it write to w, so that it can be reused.
+type Compressor interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+// compressor is a registered content coding.
+type compressor struct {
+	encoding string
+	pool     *sync.Pool
+}
+
+// compressors are the registered content codings, the most preferred
+// first.
+var compressors = []compressor{{
+	encoding: "gzip",
+	pool:     &sync.Pool{New: func() any { return gzip.NewWriter(nil) }},
+}}
+
+// RegisterCompressor registers a content coding for Compress, e.g. "br"
+// for Brotli. newCompressor returns a new Compressor; Compressors are
+// reused across responses. An encoding registered later is preferred over
+// earlier ones if the client accepts both with the same q value, e.g. "br"
+// over the built-in "gzip". Registering an encoding again replaces it.
+//
+// Import the brotli package to register Brotli. It is a module of its
+// own, so that this module doesn't depend on a Brotli implementation:
+//
+//	go get github.com/olivere/generichttp/brotli
+//
+//	import _ "github.com/olivere/generichttp/brotli"
+//
+// Register compressors on startup, before serving requests.
+func RegisterCompressor(encoding string, newCompressor func() Compressor) {
+	c := compressor{
+		encoding: encoding,
+		pool:     &sync.Pool{New: func() any { return newCompressor() }},
+	}
+	list := []compressor{c}
+	for _, existing := range compressors {
+		if existing.encoding != encoding {
+			list = append(list, existing)
+		}
+	}
+	compressors = list
+}
+
+// Compress returns a middleware that compresses response bodies with the
+// content coding the client prefers according to the q values of the
+// Accept-Encoding header: gzip, or one registered with RegisterCompressor,
+// e.g. br. The response is sent uncompressed if the client accepts none
+// of them, or prefers the identity coding by a higher q value.
+//
+// Responses that already have a Content-Encoding, partial content, and
+// responses without a body are not compressed. Compressed responses have
+// their Content-Length removed and get a Vary: Accept-Encoding header.
+func Compress() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			if r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			c, found := negotiateCompressor(r.Header.Get("Accept-Encoding"))
+			if !found {
+				next.ServeHTTP(w, r)
+				return
+			}
+			cw := &compressResponseWriter{ResponseWriter: w, compressor: c}
+			defer cw.close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiateCompressor returns the registered compressor the client prefers
+// according to the Accept-Encoding header. Ties are resolved by the order
+// of preference of the compressors. It reports false if the client accepts
+// none of them or prefers the identity coding, e.g. "identity, gzip;q=0.5".
+func negotiateCompressor(header string) (compressor, bool) {
+	offers := make([]string, 0, len(compressors)+1)
+	for _, c := range compressors {
+		offers = append(offers, c.encoding)
+	}
+	// Offered last, so that a compressor wins a tie with identity.
+	offers = append(offers, "identity")
+	encoding := NegotiateEncoding(header, offers)
+	for _, c := range compressors {
+		if c.encoding == encoding {
+			return c, true
+		}
+	}
+	return compressor{}, false
+}
+
+// compressResponseWriter compresses the response body with compressor,
+// unless the response is not eligible for compression. The decision is
+// made when the header is written.
+type compressResponseWriter struct {
+	http.ResponseWriter
+	compressor  compressor
+	enc         Compressor
+	wroteHeader bool
+}
+
+// WriteHeader decides whether to compress and writes the header.
+func (w *compressResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	h := w.Header()
+	if code >= http.StatusOK && code != http.StatusNoContent && code != http.StatusNotModified &&
+		code != http.StatusPartialContent && h.Get("Content-Encoding") == "" && h.Get("Content-Range") == "" {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", w.compressor.encoding)
+		w.enc = w.compressor.pool.Get().(Compressor)
+		w.enc.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes p, compressed if eligible.
+func (w *compressResponseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(p))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.enc != nil {
+		return w.enc.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush flushes compressed data to the client.
+func (w *compressResponseWriter) Flush() {
+	if f, ok := w.enc.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker, e.g. for WebSockets.
+func (w *compressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the underlying http.ResponseWriter for
+// http.ResponseController.
+func (w *compressResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close finishes the compressed stream and returns the writer to the
+// pool.
+func (w *compressResponseWriter) close() {
+	if w.enc == nil {
+		return
+	}
+	_ = w.enc.Close()
+	w.enc.Reset(io.Discard)
+	w.compressor.pool.Put(w.enc)
+	w.enc = nil
+}
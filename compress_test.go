@@ -0,0 +1,137 @@
+package generichttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// prefixCompressor is a fake Compressor that marks its output with a
+// prefix instead of compressing it.
+type prefixCompressor struct {
+	w      io.Writer
+	prefix string
+	buf    bytes.Buffer
+}
+
+func (c *prefixCompressor) Write(p []byte) (int, error) { return c.buf.Write(p) }
+
+func (c *prefixCompressor) Close() error {
+	_, err := io.WriteString(c.w, c.prefix+c.buf.String())
+	c.buf.Reset()
+	return err
+}
+
+func (c *prefixCompressor) Reset(w io.Writer) {
+	c.w = w
+	c.buf.Reset()
+}
+
+// withFakeBrotli registers a fake "br" compressor for the duration of the
+// test, preferred over gzip like the one of the brotli package.
+func withFakeBrotli(t *testing.T) {
+	t.Helper()
+	saved := compressors
+	t.Cleanup(func() { compressors = saved })
+	RegisterCompressor("br", func() Compressor { return &prefixCompressor{prefix: "br:"} })
+}
+
+func TestNegotiateCompressor(t *testing.T) {
+	withFakeBrotli(t)
+	tests := []struct {
+		header string
+		want   string // "" for uncompressed
+	}{
+		{"", ""},
+		{"br", "br"},
+		{"gzip", "gzip"},
+		{"gzip, br", "br"},
+		{"br, gzip", "br"},
+		{"br;q=0.5, gzip", "gzip"},
+		{"gzip;q=0.8, br;q=0.9", "br"},
+		{"br;q=0, gzip", "gzip"},
+		{"br;q=0, gzip;q=0", ""},
+		{"deflate", ""},
+		{"identity", ""},
+		{"identity, gzip;q=0.5", ""},
+		{"identity;q=0.5, gzip", "gzip"},
+		{"identity, gzip", "gzip"},
+		{"*", "br"},
+		{"*;q=0.5, gzip", "gzip"},
+		{"*, br;q=0", "gzip"},
+		{"br;q=0.5, *;q=0.8", "gzip"},
+		{"*;q=0", ""},
+		{"*;q=0, identity", ""},
+		{"GZIP;Q=0.5, BR;Q=0.1", "gzip"},
+	}
+	for _, tt := range tests {
+		c, found := negotiateCompressor(tt.header)
+		if got := c.encoding; got != tt.want || found != (tt.want != "") {
+			t.Errorf("Accept-Encoding %q: got %q, %v; want %q", tt.header, got, found, tt.want)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	withFakeBrotli(t)
+	const body = `{"name":"Alice"}`
+	h := Compress()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", "16")
+		io.WriteString(w, body)
+	}))
+	tests := []struct {
+		header   string
+		encoding string
+	}{
+		{"br, gzip", "br"},
+		{"gzip, br;q=0.5", "gzip"},
+		{"identity, gzip;q=0.5", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Encoding", tt.header)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if got := w.Header().Get("Content-Encoding"); got != tt.encoding {
+			t.Errorf("Accept-Encoding %q: Content-Encoding = %q, want %q", tt.header, got, tt.encoding)
+		}
+		if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+			t.Errorf("Accept-Encoding %q: Vary = %q, want Accept-Encoding", tt.header, got)
+		}
+		var got string
+		switch tt.encoding {
+		case "gzip":
+			zr, err := gzip.NewReader(w.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := io.ReadAll(zr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = string(b)
+		case "br":
+			b, ok := bytes.CutPrefix(w.Body.Bytes(), []byte("br:"))
+			if !ok {
+				t.Errorf("Accept-Encoding %q: body = %q, want it written by the br compressor", tt.header, w.Body)
+			}
+			got = string(b)
+		default:
+			got = w.Body.String()
+			if cl := w.Header().Get("Content-Length"); cl != "16" {
+				t.Errorf("Accept-Encoding %q: Content-Length = %q, want it kept", tt.header, cl)
+			}
+		}
+		if got != body {
+			t.Errorf("Accept-Encoding %q: body = %q, want %q", tt.header, got, body)
+		}
+		if tt.encoding != "" && w.Header().Get("Content-Length") != "" {
+			t.Errorf("Accept-Encoding %q: Content-Length = %q, want it removed", tt.header, w.Header().Get("Content-Length"))
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package generichttp
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CheckPreconditions evaluates the If-Match and If-Unmodified-Since
+// headers of an update request against the current version of a resource,
+// as described in RFC 7232. Use it for optimistic concurrency control:
+// clients send the ETag or Last-Modified they got, and the update fails
+// with a PreconditionFailedError if the resource changed in the meantime.
+//
+// etag is the current entity tag of the resource, with or without quotes;
+// modTime is its last modification time. Pass an empty etag or zero time
+// if unknown. If-Unmodified-Since is only evaluated if If-Match is absent.
+func CheckPreconditions(r *http.Request, etag string, modTime time.Time) error {
+	if r.Header.Get("If-Match") != "" {
+		return CheckIfMatch(r, etag)
+	}
+	return CheckIfUnmodifiedSince(r, modTime)
+}
+
+// CheckIfMatch returns a PreconditionFailedError if the request has an
+// If-Match header that doesn't match etag. ETags are compared strongly,
+// i.e. weak ETags never match. "*" matches any existing resource, i.e.
+// any non-empty etag.
+func CheckIfMatch(r *http.Request, etag string) error {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return nil
+	}
+	if etag != "" {
+		etag = quoteETag(etag)
+		for _, candidate := range strings.Split(header, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || (candidate == etag && !strings.HasPrefix(etag, "W/")) {
+				return nil
+			}
+		}
+	}
+	return PreconditionFailedError{Message: "Resource has been modified"}
+}
+
+// CheckIfUnmodifiedSince returns a PreconditionFailedError if the request
+// has a valid If-Unmodified-Since header and the resource has been modified
+// after that time. Times are compared with a granularity of one second.
+func CheckIfUnmodifiedSince(r *http.Request, modTime time.Time) error {
+	header := r.Header.Get("If-Unmodified-Since")
+	if header == "" || modTime.IsZero() {
+		return nil
+	}
+	since, err := http.ParseTime(header)
+	if err != nil {
+		return nil // invalid dates are ignored as per RFC 7232
+	}
+	if modTime.Truncate(time.Second).After(since) {
+		return PreconditionFailedError{Message: "Resource has been modified"}
+	}
+	return nil
+}
+
+// quoteETag adds quotes to etag if it doesn't have them.
+func quoteETag(etag string) string {
+	if strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, `W/"`) {
+		return etag
+	}
+	return `"` + etag + `"`
+}
+
+// notModifiedSince reports whether r is a GET or HEAD request with a valid
+// If-Modified-Since header that is not before lastModified, a date in the
+// format of http.TimeFormat. As per RFC 7232, If-Modified-Since is ignored
+// if the request has an If-None-Match header.
+func notModifiedSince(r *http.Request, lastModified string) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	header := r.Header.Get("If-Modified-Since")
+	if header == "" || lastModified == "" || r.Header.Get("If-None-Match") != "" {
+		return false
+	}
+	since, err := http.ParseTime(header)
+	if err != nil {
+		return false
+	}
+	modTime, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+	return !modTime.After(since)
+}
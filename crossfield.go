@@ -0,0 +1,210 @@
+package generichttp
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// crossFieldTags are the struct tags of constraints between the fields of
+// a struct, see checkCrossFields.
+var crossFieldTags = []string{"exclusive", "required_together", "required_if"}
+
+// crossField is a field of a struct checked by checkCrossFields.
+type crossField struct {
+	f    reflect.StructField
+	v    reflect.Value
+	name string // JSON name of the field within its struct
+	path string // dotted path of the field, see walkFields
+}
+
+// set reports whether the field is set, i.e. doesn't have its zero value.
+// A pointer to a zero value is set, e.g. a *bool pointing to false.
+func (cf crossField) set() bool {
+	return !cf.v.IsZero()
+}
+
+// hasCrossFieldTags reports whether t or any type reachable from t has a
+// struct field with a constraint between fields.
+func hasCrossFieldTags(t reflect.Type) bool {
+	for _, tag := range crossFieldTags {
+		if typeHasTag(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCrossFields checks the constraints between the fields of all
+// structs reachable from v, given by the exclusive, required_together, and
+// required_if struct tags, see Validator. The path is that of v, see
+// walkFields.
+func checkCrossFields(v reflect.Value, path string) error {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		fields := structCrossFields(v, path, nil)
+		if err := checkStructCrossFields(fields); err != nil {
+			return err
+		}
+		for _, cf := range fields {
+			if err := checkCrossFields(cf.v, cf.path); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := checkCrossFields(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := checkCrossFields(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// structCrossFields appends the fields of struct v to fields, with the
+// fields of embedded structs without a JSON name in place of the struct,
+// as encoding/json does.
+func structCrossFields(v reflect.Value, path string, fields []crossField) []crossField {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Tag.Get("json") == "" && f.Type.Kind() == reflect.Struct {
+			fields = structCrossFields(v.Field(i), path, fields)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		name := fieldName(f, "json")
+		if name == "" {
+			continue
+		}
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+		fields = append(fields, crossField{f: f, v: v.Field(i), name: name, path: fieldPath})
+	}
+	return fields
+}
+
+// checkStructCrossFields checks the constraints between fields, which are
+// the fields of a single struct. Groups are checked in the order of their
+// first field.
+func checkStructCrossFields(fields []crossField) error {
+	var (
+		groups   []string
+		members  = make(map[string][]crossField)
+		required = make(map[string]bool)
+	)
+	for _, cf := range fields {
+		for _, tag := range []string{"exclusive", "required_together"} {
+			value, found := cf.f.Tag.Lookup(tag)
+			if !found {
+				continue
+			}
+			group, opt, _ := strings.Cut(value, ",")
+			if group == "" || (opt != "" && (tag != "exclusive" || opt != "required")) {
+				return fmt.Errorf("generichttp: invalid %s tag on field %s: %q", tag, cf.f.Name, value)
+			}
+			key := tag + ":" + group
+			if _, found := members[key]; !found {
+				groups = append(groups, key)
+			}
+			members[key] = append(members[key], cf)
+			required[key] = required[key] || opt == "required"
+		}
+	}
+	for _, key := range groups {
+		tag, _, _ := strings.Cut(key, ":")
+		var paths, setPaths []string
+		for _, cf := range members[key] {
+			paths = append(paths, cf.path)
+			if cf.set() {
+				setPaths = append(setPaths, cf.path)
+			}
+		}
+		switch {
+		case tag == "exclusive" && len(setPaths) > 1:
+			return ValidationError{
+				Message: fmt.Sprintf("Only one of %s may be set", strings.Join(paths, ", ")),
+				Fields:  setPaths,
+			}
+		case tag == "exclusive" && len(setPaths) == 0 && required[key]:
+			return ValidationError{
+				Message: fmt.Sprintf("One of %s is required", strings.Join(paths, ", ")),
+				Fields:  paths,
+			}
+		case tag == "required_together" && len(setPaths) > 0 && len(setPaths) < len(paths):
+			return ValidationError{
+				Message: fmt.Sprintf("%s must be set together", strings.Join(paths, ", ")),
+				Fields:  paths,
+			}
+		}
+	}
+	for _, cf := range fields {
+		value, found := cf.f.Tag.Lookup("required_if")
+		if !found || cf.set() {
+			continue
+		}
+		name, want, hasValue := strings.Cut(value, "=")
+		other, ok := findCrossField(fields, name)
+		if !ok {
+			return fmt.Errorf("generichttp: invalid required_if tag on field %s: no field %q", cf.f.Name, name)
+		}
+		if !other.set() {
+			continue
+		}
+		if !hasValue {
+			return ValidationError{
+				Message: fmt.Sprintf("%s is required if %s is set", cf.path, other.path),
+				Fields:  []string{cf.path, other.path},
+			}
+		}
+		if crossFieldValue(other.v) == want {
+			return ValidationError{
+				Message: fmt.Sprintf("%s is required if %s is %s", cf.path, other.path, want),
+				Fields:  []string{cf.path, other.path},
+			}
+		}
+	}
+	return nil
+}
+
+// findCrossField returns the field with the given JSON name.
+func findCrossField(fields []crossField, name string) (crossField, bool) {
+	for _, cf := range fields {
+		if cf.name == name {
+			return cf, true
+		}
+	}
+	return crossField{}, false
+}
+
+// crossFieldValue returns v as a string to compare with the value of a
+// required_if tag, with pointers dereferenced.
+func crossFieldValue(v reflect.Value) string {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if !v.CanInterface() {
+		return ""
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,153 @@
+package generichttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/olivere/generichttp"
+	"github.com/olivere/generichttp/testutil"
+)
+
+type contactItem struct {
+	Code string `json:"code,omitempty" exclusive:"id"`
+	SKU  string `json:"sku,omitempty" exclusive:"id"`
+}
+
+type contactRequest struct {
+	Email    string        `json:"email,omitempty" exclusive:"contact,required"`
+	Phone    string        `json:"phone,omitempty" exclusive:"contact,required"`
+	Street   string        `json:"street,omitempty" required_together:"address"`
+	City     string        `json:"city,omitempty" required_together:"address"`
+	Method   string        `json:"method,omitempty"`
+	Callback string        `json:"callback,omitempty" required_if:"method=callback"`
+	Company  string        `json:"company,omitempty"`
+	VATID    string        `json:"vat_id,omitempty" required_if:"company"`
+	Items    []contactItem `json:"items,omitempty"`
+}
+
+// Validate is called after the constraints between fields are met.
+func (c *contactRequest) Validate() error {
+	if c.Email == "blocked@example.com" {
+		return generichttp.ValidationError{Message: "Email is blocked", Fields: []string{"email"}}
+	}
+	return nil
+}
+
+func echoContact(w http.ResponseWriter, req generichttp.Request[contactRequest]) (*generichttp.Response[contactRequest], error) {
+	return generichttp.OK(req.Data), nil
+}
+
+func TestCrossFieldConstraints(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want map[string]any // error body, nil if valid
+	}{
+		{
+			name: "valid",
+			body: `{"email":"a@example.com","street":"Main St 1","city":"Springfield","method":"callback","callback":"+1 555","company":"ACME","vat_id":"DE1","items":[{"code":"a"},{"sku":"b"},{}]}`,
+		},
+		{
+			name: "exclusive",
+			body: `{"email":"a@example.com","phone":"+1 555"}`,
+			want: map[string]any{"message": "Only one of email, phone may be set", "fields": []any{"email", "phone"}},
+		},
+		{
+			name: "exclusive required",
+			body: `{"street":"Main St 1","city":"Springfield"}`,
+			want: map[string]any{"message": "One of email, phone is required", "fields": []any{"email", "phone"}},
+		},
+		{
+			name: "exclusive nested",
+			body: `{"phone":"+1 555","items":[{"code":"a"},{"code":"b","sku":"c"}]}`,
+			want: map[string]any{"message": "Only one of items[1].code, items[1].sku may be set", "fields": []any{"items[1].code", "items[1].sku"}},
+		},
+		{
+			name: "required together",
+			body: `{"phone":"+1 555","city":"Springfield"}`,
+			want: map[string]any{"message": "street, city must be set together", "fields": []any{"street", "city"}},
+		},
+		{
+			name: "required if value",
+			body: `{"phone":"+1 555","method":"callback"}`,
+			want: map[string]any{"message": "callback is required if method is callback", "fields": []any{"callback", "method"}},
+		},
+		{
+			name: "required if other value",
+			body: `{"phone":"+1 555","method":"email"}`,
+		},
+		{
+			name: "required if set",
+			body: `{"phone":"+1 555","company":"ACME"}`,
+			want: map[string]any{"message": "vat_id is required if company is set", "fields": []any{"vat_id", "company"}},
+		},
+		{
+			name: "validate hook",
+			body: `{"email":"blocked@example.com"}`,
+			want: map[string]any{"message": "Email is blocked", "fields": []any{"email"}},
+		},
+		{
+			name: "constraints before validate hook",
+			body: `{"email":"blocked@example.com","phone":"+1 555"}`,
+			want: map[string]any{"message": "Only one of email, phone may be set", "fields": []any{"email", "phone"}},
+		},
+	}
+	for _, tt := range tests {
+		for kind, h := range map[string]http.Handler{"JSON": generichttp.JSON(echoContact), "Auto": generichttp.Auto(echoContact)} {
+			t.Run(tt.name+"/"+kind, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodPost, "/contacts", strings.NewReader(tt.body))
+				r.Header.Set("Content-Type", "application/json")
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, r)
+				if tt.want == nil {
+					if w.Code != http.StatusOK {
+						t.Errorf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+					}
+					return
+				}
+				if w.Code != http.StatusBadRequest {
+					t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+				}
+				testutil.AssertJSON(t, w, tt.want)
+			})
+		}
+	}
+}
+
+func TestCrossFieldConstraintsForm(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/contacts", strings.NewReader("email=a%40example.com&phone=%2B1+555"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	generichttp.Auto(echoContact).ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	testutil.AssertJSON(t, w, map[string]any{"message": "Only one of email, phone may be set", "fields": []any{"email", "phone"}})
+}
+
+func TestCrossFieldInvalidTags(t *testing.T) {
+	type badOption struct {
+		A string `json:"a" required_together:"g,required"`
+	}
+	type badReference struct {
+		A string `json:"a" required_if:"missing"`
+	}
+	for name, h := range map[string]http.Handler{
+		"option": generichttp.JSON(func(w http.ResponseWriter, req generichttp.Request[badOption]) (*generichttp.Response[badOption], error) {
+			return generichttp.OK(req.Data), nil
+		}),
+		"reference": generichttp.JSON(func(w http.ResponseWriter, req generichttp.Request[badReference]) (*generichttp.Response[badReference], error) {
+			return generichttp.OK(req.Data), nil
+		}),
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("%s: status = %d, want %d for a programming error", name, w.Code, http.StatusInternalServerError)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package generichttp
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// startTime is used to report the uptime in DebugHandler.
+var startTime = time.Now()
+
+// DebugOptions configures DebugHandler.
+type DebugOptions struct {
+	// Enabled must be set to serve debug information. If it is false,
+	// DebugHandler responds with a NotFoundError.
+	Enabled bool
+	// OmitBuildSettings omits the build settings, e.g. VCS information
+	// and compiler flags.
+	OmitBuildSettings bool
+	// OmitDependencies omits the modules the binary depends on.
+	OmitDependencies bool
+}
+
+// debugInfo is the response of DebugHandler.
+type debugInfo struct {
+	GoVersion    string            `json:"goVersion"`
+	OS           string            `json:"os"`
+	Arch         string            `json:"arch"`
+	Path         string            `json:"path,omitempty"`
+	Version      string            `json:"version,omitempty"`
+	Settings     map[string]string `json:"settings,omitempty"`
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+	StartTime    time.Time         `json:"startTime"`
+	Uptime       string            `json:"uptime"`
+	Goroutines   int               `json:"goroutines"`
+	CPUs         int               `json:"cpus"`
+}
+
+// DebugHandler returns a http.Handler that reports build and runtime
+// information as JSON: the Go version, the module path and version of the
+// binary, build settings, dependencies, uptime, and number of goroutines.
+//
+// It is disabled unless opts.Enabled is set, as the information may help
+// attackers. Protect it e.g. by authentication when enabling it in
+// production.
+func DebugHandler(opts DebugOptions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !opts.Enabled {
+			writeJSONError(w, r, NotFoundError{})
+			return
+		}
+		info := debugInfo{
+			GoVersion:  runtime.Version(),
+			OS:         runtime.GOOS,
+			Arch:       runtime.GOARCH,
+			StartTime:  startTime,
+			Uptime:     time.Since(startTime).Round(time.Second).String(),
+			Goroutines: runtime.NumGoroutine(),
+			CPUs:       runtime.NumCPU(),
+		}
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			info.Path = bi.Main.Path
+			info.Version = bi.Main.Version
+			if !opts.OmitBuildSettings {
+				info.Settings = make(map[string]string)
+				for _, s := range bi.Settings {
+					info.Settings[s.Key] = s.Value
+				}
+			}
+			if !opts.OmitDependencies {
+				info.Dependencies = make(map[string]string)
+				for _, dep := range bi.Deps {
+					info.Dependencies[dep.Path] = dep.Version
+				}
+			}
+		}
+		WriteJSON(w, info)
+	})
+}
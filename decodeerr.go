@@ -0,0 +1,197 @@
+package generichttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+)
+
+// WithVerboseDecodeErrors includes the message of the decoder in the
+// BadRequestError for a request body that cannot be decoded, e.g.
+// "json: cannot unmarshal string into Go struct field User.age of type int".
+//
+// By default, JSON and Auto answer such bodies with a message that names
+// the field and the expected JSON type, e.g. `Invalid value for field
+// "age": expected integer`, but not the Go types of the request data. The
+// messages of the decoder expose implementation details, so use this in
+// development only.
+func WithVerboseDecodeErrors() Option {
+	return func(o *Options) {
+		o.VerboseDecodeErrors = true
+	}
+}
+
+// decodeJSONRequest decodes the JSON body of r into v. An empty body is
+// not an error. A body over the limit of limitBody results in a
+// RequestEntityTooLargeError, a BadRequestError from decoding, e.g. for a
+// malformed time, is returned as is, and all other errors are translated
+// by decodeError, with the line and column of the error in the body.
+func decodeJSONRequest(r *http.Request, v any, opts *Options) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	lines := &lineReader{r: r.Body}
+	r.Body = readCloser{Reader: lines, Closer: r.Body}
+	err := bodyError(decodeJSONBody(r, v))
+	var bre BadRequestError
+	var tle RequestEntityTooLargeError
+	switch {
+	case err == nil || errors.Is(err, io.EOF):
+		return nil
+	case errors.As(err, &bre) || errors.As(err, &tle):
+		return err
+	}
+	return decodeError(err, opts.VerboseDecodeErrors, lines)
+}
+
+// decodeError returns a BadRequestError for an error in decoding the
+// request body. Unless verbose is set, common JSON errors are translated
+// into messages without Go type names, and all others result in a generic
+// message. If lines is not nil, syntax and type errors name the line and
+// column of the error in the body.
+func decodeError(err error, verbose bool, lines *lineReader) error {
+	var ute *json.UnmarshalTypeError
+	var se *json.SyntaxError
+	var tde *trailingDataError
+	var offset int64
+	var at string
+	switch {
+	case errors.As(err, &se):
+		offset = se.Offset
+		at = lines.position(offset)
+	case errors.As(err, &tde):
+		offset = tde.Offset
+		at = lines.position(offset)
+	case errors.As(err, &ute) && !errors.As(err, new(rewrittenError)):
+		at = lines.position(ute.Offset)
+	}
+	if verbose {
+		if at != "" {
+			return BadRequestError{Message: "Invalid request body " + at + ": " + err.Error()}
+		}
+		return BadRequestError{Message: "Invalid request body: " + err.Error()}
+	}
+	switch {
+	case ute != nil:
+		msg := fmt.Sprintf("Invalid value for field %q", ute.Field)
+		if ute.Field == "" {
+			msg = "Invalid request body"
+		}
+		if at != "" {
+			msg += " " + at
+		}
+		return BadRequestError{Message: fmt.Sprintf("%s: expected %s", msg, jsonTypeName(ute.Type))}
+	case se != nil || tde != nil:
+		if at != "" {
+			return BadRequestError{Message: "Malformed JSON " + at}
+		}
+		if offset > 0 {
+			return BadRequestError{Message: fmt.Sprintf("Malformed JSON at offset %d", offset)}
+		}
+		return BadRequestError{Message: "Malformed JSON"}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return BadRequestError{Message: "Malformed JSON: unexpected end of input"}
+	}
+	return BadRequestError{Message: "Invalid request body"}
+}
+
+// lineReader records the offsets of the line breaks read from r, so that
+// the offset of a decode error can be turned into a line and column
+// without buffering the body.
+type lineReader struct {
+	r      io.Reader
+	n      int64
+	breaks []int64
+}
+
+// Read implements io.Reader.
+func (lr *lineReader) Read(p []byte) (int, error) {
+	n, err := lr.r.Read(p)
+	for i := 0; i < n; {
+		j := bytes.IndexByte(p[i:n], '\n')
+		if j < 0 {
+			break
+		}
+		lr.breaks = append(lr.breaks, lr.n+int64(i+j))
+		i += j + 1
+	}
+	lr.n += int64(n)
+	return n, err
+}
+
+// position returns "at line L, column C" for the byte before offset, as
+// reported by the errors of encoding/json, or "" if lr is nil or offset
+// is unknown. Lines and columns start at 1; columns count bytes.
+func (lr *lineReader) position(offset int64) string {
+	if lr == nil || offset <= 0 || offset > lr.n {
+		return ""
+	}
+	pos := offset - 1
+	line, start := 1, int64(0)
+	for _, b := range lr.breaks {
+		if b >= pos {
+			break
+		}
+		line, start = line+1, b+1
+	}
+	return fmt.Sprintf("at line %d, column %d", line, pos-start+1)
+}
+
+// trailingDataError is returned by decodeValue for data after the JSON
+// value, which Unmarshal reports as a *json.SyntaxError.
+type trailingDataError struct {
+	c      byte  // first byte of the data, the start of a JSON value
+	Offset int64 // as in json.SyntaxError, or 0 if unknown
+}
+
+// Error implements the error interface.
+func (e *trailingDataError) Error() string {
+	return fmt.Sprintf("invalid character '%c' after top-level value", e.c)
+}
+
+// rewrittenError marks an error of decoding data that was rewritten before,
+// whose offsets don't match the request body, see decodeJSONBodyWithTimes.
+type rewrittenError struct {
+	err error
+}
+
+// Error implements the error interface.
+func (e rewrittenError) Error() string { return e.err.Error() }
+
+// Unwrap returns the wrapped error.
+func (e rewrittenError) Unwrap() error { return e.err }
+
+// jsonTypeName returns the name of the JSON type that t is decoded from.
+func jsonTypeName(t reflect.Type) string {
+	if t == nil {
+		return "value"
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "string"
+		}
+		return "array"
+	case reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+	return "value"
+}
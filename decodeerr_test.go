@@ -0,0 +1,298 @@
+package generichttp
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+type decodeErrUser struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func echoDecodeErrUser(w http.ResponseWriter, req Request[decodeErrUser]) (*Response[decodeErrUser], error) {
+	return NewResponse(req.Data), nil
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "wrong type",
+			body: `{"name":"Alice","age":"forty"}`,
+			want: `Invalid value for field "age" at line 1, column 29: expected integer`,
+		},
+		{
+			name: "truncated",
+			body: `{"name":"Alice",`,
+			want: "Malformed JSON at line 1, column 16",
+		},
+		{
+			name: "malformed",
+			body: `{"name":"Alice",}`,
+			want: "Malformed JSON at line 1, column 17",
+		},
+	}
+	handlers := map[string]http.Handler{
+		"JSON": JSON(echoDecodeErrUser),
+		"Auto": Auto(echoDecodeErrUser),
+	}
+	for _, tt := range tests {
+		for kind, h := range handlers {
+			t.Run(kind+"/"+tt.name, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+				r.Header.Set("Content-Type", "application/json")
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, r)
+				if w.Code != http.StatusBadRequest {
+					t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+				}
+				var body errorBody
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+					t.Fatal(err)
+				}
+				if body.Message != tt.want {
+					t.Errorf("message = %q, want %q", body.Message, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestDecodeErrorsVerbose(t *testing.T) {
+	for kind, h := range map[string]http.Handler{
+		"JSON": JSON(echoDecodeErrUser, WithVerboseDecodeErrors()),
+		"Auto": Auto(echoDecodeErrUser, WithVerboseDecodeErrors()),
+	} {
+		t.Run(kind, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"age":"forty"}`))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+			if !strings.Contains(w.Body.String(), "json: cannot unmarshal string") {
+				t.Errorf("body = %s, want the message of the decoder", w.Body)
+			}
+		})
+	}
+}
+
+func TestDecodeErrorsEmptyBody(t *testing.T) {
+	for kind, h := range map[string]http.Handler{
+		"JSON": JSON(echoDecodeErrUser),
+		"Auto": Auto(echoDecodeErrUser),
+	} {
+		t.Run(kind, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != http.StatusOK {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+		})
+	}
+}
+
+type decodeErrList struct {
+	Items []int `json:"items"`
+}
+
+type decodeErrTimes struct {
+	Name string    `json:"name"`
+	At   time.Time `json:"at" time:"unix"`
+}
+
+func TestDecodeErrorsPosition(t *testing.T) {
+	var long strings.Builder
+	long.WriteString("{\"items\":[\n")
+	for i := 0; i < 5000; i++ {
+		long.WriteString("  1,\n")
+	}
+	long.WriteString("  x\n]}")
+
+	tests := []struct {
+		name    string
+		h       http.Handler
+		body    string
+		chunked bool
+		want    string
+	}{
+		{
+			name: "wrong type on line 3",
+			h:    JSON(echoDecodeErrUser),
+			body: "{\n  \"name\": \"Alice\",\n  \"age\": \"forty\"\n}",
+			want: `Invalid value for field "age" at line 3, column 16: expected integer`,
+		},
+		{
+			name: "missing comma",
+			h:    JSON(echoDecodeErrUser),
+			body: "{\n  \"name\": \"Alice\"\n  \"age\": 40\n}",
+			want: "Malformed JSON at line 3, column 3",
+		},
+		{
+			name: "invalid character at the start of a line",
+			h:    JSON(echoDecodeErrUser),
+			body: "{\n\"name\": \"Alice\",\n}",
+			want: "Malformed JSON at line 3, column 1",
+		},
+		{
+			name: "CRLF line breaks",
+			h:    JSON(echoDecodeErrUser),
+			body: "{\r\n  \"name\": \"Alice\",\r\n  \"age\": true\r\n}",
+			want: `Invalid value for field "age" at line 3, column 13: expected integer`,
+		},
+		{
+			name: "streamed body",
+			h: JSON(func(w http.ResponseWriter, req Request[decodeErrList]) (*Response[decodeErrList], error) {
+				return nil, nil
+			}),
+			body:    long.String(),
+			chunked: true,
+			want:    "Malformed JSON at line 5002, column 3",
+		},
+		{
+			name: "Auto",
+			h:    Auto(echoDecodeErrUser),
+			body: "{\n  \"name\": \"Alice\",\n  \"age\": \"forty\"\n}",
+			want: `Invalid value for field "age" at line 3, column 16: expected integer`,
+		},
+		{
+			name: "rewritten times keep the position of syntax errors",
+			h: JSON(func(w http.ResponseWriter, req Request[decodeErrTimes]) (*Response[decodeErrTimes], error) {
+				return nil, nil
+			}),
+			body: "{\n  \"name\": \"Alice\",\n  \"at\": 1700000000,\n}",
+			want: "Malformed JSON at line 4, column 1",
+		},
+		{
+			name: "rewritten times have no position for type errors",
+			h: JSON(func(w http.ResponseWriter, req Request[decodeErrTimes]) (*Response[decodeErrTimes], error) {
+				return nil, nil
+			}),
+			body: "{\n  \"name\": 42,\n  \"at\": 1700000000\n}",
+			want: `Invalid value for field "name": expected string`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			if tt.chunked {
+				r.Body = io.NopCloser(iotest.HalfReader(strings.NewReader(tt.body)))
+				r.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			tt.h.ServeHTTP(w, r)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body)
+			}
+			var body errorBody
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Message != tt.want {
+				t.Errorf("message = %q, want %q", body.Message, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeErrorsTrailingData(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string // empty if the body is valid
+	}{
+		{"white space", "{\"name\":\"Alice\"}\n\t \n", ""},
+		{"second value", `{"name":"Alice"} {"name":"Bob"}`, "Malformed JSON at line 1, column 18"},
+		{"second value on the next line", "{\"name\":\"Alice\"}\n[]", "Malformed JSON at line 2, column 1"},
+		{"garbage", "{\"name\":\"Alice\"}\n x", "Malformed JSON at line 2, column 2"},
+	}
+	handlers := map[string]http.Handler{
+		"JSON": JSON(echoDecodeErrUser),
+		"Auto": Auto(echoDecodeErrUser),
+		"times": JSON(func(w http.ResponseWriter, req Request[decodeErrTimes]) (*Response[decodeErrTimes], error) {
+			return nil, nil
+		}),
+	}
+	for _, tt := range tests {
+		for kind, h := range handlers {
+			// Small bodies with a known Content-Length are decoded with
+			// Unmarshal, all others with a Decoder.
+			for _, streamed := range []bool{false, true} {
+				name := kind + "/" + tt.name
+				if streamed {
+					name += "/streamed"
+				}
+				t.Run(name, func(t *testing.T) {
+					r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+					r.Header.Set("Content-Type", "application/json")
+					if streamed {
+						r.Body = io.NopCloser(iotest.HalfReader(strings.NewReader(tt.body)))
+						r.ContentLength = -1
+					}
+					w := httptest.NewRecorder()
+					h.ServeHTTP(w, r)
+					if tt.want == "" {
+						if w.Code != http.StatusOK {
+							t.Errorf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+						}
+						return
+					}
+					if w.Code != http.StatusBadRequest {
+						t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+					}
+					var body errorBody
+					if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+						t.Fatal(err)
+					}
+					if body.Message != tt.want {
+						t.Errorf("message = %q, want %q", body.Message, tt.want)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestLineReaderPosition(t *testing.T) {
+	const body = "ab\ncd\n\nef"
+	lr := &lineReader{r: iotest.OneByteReader(strings.NewReader(body))}
+	if _, err := io.ReadAll(lr); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		offset int64
+		want   string
+	}{
+		{0, ""},
+		{1, "at line 1, column 1"},
+		{2, "at line 1, column 2"},
+		{3, "at line 1, column 3"}, // the line break itself
+		{4, "at line 2, column 1"},
+		{7, "at line 3, column 1"},
+		{8, "at line 4, column 1"},
+		{9, "at line 4, column 2"},
+		{10, ""},
+	}
+	for _, tt := range tests {
+		if got := lr.position(tt.offset); got != tt.want {
+			t.Errorf("position(%d) = %q, want %q", tt.offset, got, tt.want)
+		}
+	}
+	if got := (*lineReader)(nil).position(1); got != "" {
+		t.Errorf("position of nil lineReader = %q, want empty", got)
+	}
+}
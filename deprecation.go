@@ -0,0 +1,38 @@
+package generichttp
+
+import (
+	"net/http"
+	"time"
+)
+
+// deprecation describes a deprecated route, see WithDeprecation.
+type deprecation struct {
+	sunset time.Time
+	note   string
+}
+
+// WithDeprecation marks a route as deprecated, e.g.:
+//
+//	router.Get("/old", generichttp.JSON(getOld), generichttp.WithDeprecation(sunset, "Use /new"))
+//
+// Responses of the route include a "Deprecation: true" header and, unless
+// sunset is zero, a Sunset header with the time the route will go away
+// (RFC 8594). A non-empty note, e.g. how to migrate, is sent in a Warning
+// header with code 299. The route is also marked as deprecated in the
+// OpenAPI document.
+func WithDeprecation(sunset time.Time, note string) RouteOption {
+	return func(e *endpoint) {
+		e.deprecation = &deprecation{sunset: sunset, note: note}
+	}
+}
+
+// setHeaders sets the deprecation headers of a response.
+func (d *deprecation) setHeaders(h http.Header) {
+	h.Set("Deprecation", "true")
+	if !d.sunset.IsZero() {
+		h.Set("Sunset", d.sunset.UTC().Format(http.TimeFormat))
+	}
+	if d.note != "" {
+		addWarningHeader(h, d.note)
+	}
+}
@@ -0,0 +1,63 @@
+package generichttp_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/olivere/generichttp"
+)
+
+func TestWithDeprecation(t *testing.T) {
+	sunset := time.Date(2027, time.March, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	router := generichttp.NewRouter()
+	router.Get("/v1/items/{id}", generichttp.JSON(getRouterItem), generichttp.WithDeprecation(sunset, "Use /v2/items/{id}"))
+	router.Get("/v1/users/{id}", generichttp.JSON(getRouterItem), generichttp.WithDeprecation(time.Time{}, ""))
+	router.Get("/v2/items/{id}", generichttp.JSON(getRouterItem))
+
+	tests := []struct {
+		target      string
+		code        int
+		deprecation string
+		sunset      string
+		warning     string
+	}{
+		{"/v1/items/7", http.StatusOK, "true", "Mon, 01 Mar 2027 11:00:00 GMT", `299 - "Use /v2/items/{id}"`},
+		{"/v1/items/missing", http.StatusNotFound, "true", "Mon, 01 Mar 2027 11:00:00 GMT", `299 - "Use /v2/items/{id}"`},
+		{"/v1/users/7", http.StatusOK, "true", "", ""},
+		{"/v2/items/7", http.StatusOK, "", "", ""},
+	}
+	for _, tt := range tests {
+		for _, method := range []string{http.MethodGet, http.MethodHead} {
+			w := serveRouter(router, method, tt.target)
+			if w.Code != tt.code {
+				t.Errorf("%s %s: status = %d, want %d", method, tt.target, w.Code, tt.code)
+			}
+			for name, want := range map[string]string{"Deprecation": tt.deprecation, "Sunset": tt.sunset, "Warning": tt.warning} {
+				if got := w.Header().Get(name); got != want {
+					t.Errorf("%s %s: %s = %q, want %q", method, tt.target, name, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestWithDeprecationOpenAPI(t *testing.T) {
+	router := generichttp.NewRouter()
+	router.Get("/v1/items/{id}", generichttp.JSON(getRouterItem), generichttp.WithDeprecation(time.Time{}, "Use /v2/items/{id}"))
+	router.Get("/v2/items/{id}", generichttp.JSON(getRouterItem))
+
+	doc, err := router.OpenAPI(generichttp.OpenAPIInfo{Title: "Items", Version: "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]bool{"/v1/items/{id}": true, "/v2/items/{id}": false} {
+		op := doc.Paths[path]["get"]
+		if op == nil {
+			t.Fatalf("no operation for GET %s", path)
+		}
+		if op.Deprecated != want {
+			t.Errorf("GET %s: deprecated = %v, want %v", path, op.Deprecated, want)
+		}
+	}
+}
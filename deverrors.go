@@ -0,0 +1,68 @@
+package generichttp
+
+import (
+	"errors"
+	"strings"
+)
+
+// WithDevErrors includes the details of internal errors in the response,
+// for local development. For errors without a HTTPCode, which are rendered
+// as "Internal server error" otherwise, the body has the error message in
+// an error field, e.g.:
+//
+//	{"message":"Internal server error","error":"query users: connection refused"}
+//
+// Go errors don't record where they were created, so a stack trace is only
+// included for panics recovered by Recover, in a stack field with one line
+// per element. Pass WithDevErrors to Recover to enable that.
+//
+// Never enable this in production: the details may leak internals like
+// queries, file paths, and credentials. It is off by default.
+func WithDevErrors() Option {
+	return func(o *Options) {
+		o.DevErrors = true
+	}
+}
+
+// devError is an internal error whose details are rendered in the
+// response, see WithDevErrors.
+type devError struct {
+	err   error
+	stack []byte
+}
+
+// Error implements the error interface.
+func (e devError) Error() string { return e.err.Error() }
+
+// Unwrap returns the wrapped error.
+func (e devError) Unwrap() error { return e.err }
+
+// devErrorFor returns err as a devError with the given stack trace, if
+// enabled in opts and err is an internal error, i.e. doesn't have a
+// HTTPCode and isn't a Halt or an aggregate of errors.
+func devErrorFor(err error, stack []byte, opts *Options) error {
+	if !opts.DevErrors {
+		return err
+	}
+	if _, ok := err.(interface{ HTTPCode() int }); ok {
+		return err
+	}
+	if _, ok := asHalt(err); ok || joinedErrors(err) != nil {
+		return err
+	}
+	return devError{err: err, stack: stack}
+}
+
+// addDevDetails adds the details of a devError in err to body.
+func addDevDetails(body *errorBody, err error) {
+	var de devError
+	if !errors.As(err, &de) {
+		return
+	}
+	body.Error = de.err.Error()
+	if len(de.stack) > 0 {
+		for _, line := range strings.Split(strings.TrimSpace(string(de.stack)), "\n") {
+			body.Stack = append(body.Stack, strings.ReplaceAll(line, "\t", "    "))
+		}
+	}
+}
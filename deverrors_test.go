@@ -0,0 +1,114 @@
+package generichttp_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/olivere/generichttp"
+	"github.com/olivere/generichttp/testutil"
+)
+
+// devErrorBody is the JSON body of an error response with dev details.
+type devErrorBody struct {
+	Message string   `json:"message"`
+	Error   *string  `json:"error"`
+	Stack   []string `json:"stack"`
+}
+
+func decodeDevErrorBody(t *testing.T, w *httptest.ResponseRecorder) devErrorBody {
+	t.Helper()
+	var body devErrorBody
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body = %q: %v", w.Body, err)
+	}
+	return body
+}
+
+func failWithQueryError(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[struct{}], error) {
+	return nil, errors.New("query users: connection refused")
+}
+
+func panicInHandler(w http.ResponseWriter, r *http.Request) {
+	panic("nil map write in panicInHandler")
+}
+
+func TestDevErrors(t *testing.T) {
+	for kind, h := range map[string]http.Handler{
+		"JSON": generichttp.JSON(failWithQueryError, generichttp.WithDevErrors()),
+		"Auto": generichttp.Auto(failWithQueryError, generichttp.WithDevErrors()),
+	} {
+		t.Run(kind, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != http.StatusInternalServerError {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+			}
+			testutil.AssertJSON(t, w, map[string]any{
+				"message": "Internal server error",
+				"error":   "query users: connection refused",
+			})
+		})
+	}
+}
+
+func TestDevErrorsDisabled(t *testing.T) {
+	for kind, h := range map[string]http.Handler{
+		"JSON":    generichttp.JSON(failWithQueryError),
+		"Auto":    generichttp.Auto(failWithQueryError),
+		"Recover": generichttp.Recover()(http.HandlerFunc(panicInHandler)),
+	} {
+		t.Run(kind, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != http.StatusInternalServerError {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+			}
+			testutil.AssertJSON(t, w, map[string]any{"message": "Internal server error"})
+			if strings.Contains(w.Body.String(), "connection refused") || strings.Contains(w.Body.String(), "panicInHandler") {
+				t.Errorf("body = %s, want no details", w.Body)
+			}
+		})
+	}
+}
+
+func TestDevErrorsRecover(t *testing.T) {
+	h := generichttp.Recover(generichttp.WithDevErrors())(http.HandlerFunc(panicInHandler))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	body := decodeDevErrorBody(t, w)
+	if body.Message != "Internal server error" {
+		t.Errorf("message = %q, want %q", body.Message, "Internal server error")
+	}
+	if body.Error == nil || *body.Error != "panic: nil map write in panicInHandler" {
+		t.Errorf("error = %v, want the panic value", body.Error)
+	}
+	var found bool
+	for _, line := range body.Stack {
+		if strings.Contains(line, "\t") {
+			t.Errorf("stack line %q contains a tab", line)
+		}
+		found = found || strings.Contains(line, "deverrors_test.go")
+	}
+	if !found {
+		t.Errorf("stack = %q, want the panicking test handler", body.Stack)
+	}
+}
+
+func TestDevErrorsHTTPError(t *testing.T) {
+	h := generichttp.JSON(func(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[struct{}], error) {
+		return nil, generichttp.NotFoundError{Message: "No such user"}
+	}, generichttp.WithDevErrors())
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	testutil.AssertJSON(t, w, map[string]any{"message": "No such user"})
+}
@@ -0,0 +1,95 @@
+package generichttp
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"path"
+	"time"
+)
+
+//go:embed assets/swagger-ui/swagger-ui-bundle.js assets/swagger-ui/swagger-ui.css
+var swaggerUIAssets embed.FS
+
+// docsTemplate renders the Swagger UI page.
+var docsTemplate = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="swagger-ui-bundle.js"></script>
+<script src="swagger-initializer.js"></script>
+</body>
+</html>
+`))
+
+// docsInitializer initializes Swagger UI. It is served as a separate file
+// so that the page works with a Content-Security-Policy without
+// 'unsafe-inline' scripts.
+const docsInitializer = `window.ui = SwaggerUIBundle({url: %s, dom_id: "#swagger-ui", deepLinking: true});
+`
+
+// docsContentSecurityPolicy allows Swagger UI to load its own assets only.
+const docsContentSecurityPolicy = "default-src 'self'; img-src 'self' data:; style-src 'self' 'unsafe-inline'"
+
+// DocsOption configures DocsHandler.
+type DocsOption func(*docsConfig)
+
+type docsConfig struct {
+	Title   string
+	SpecURL string
+}
+
+// WithDocsTitle sets the page title of DocsHandler. It defaults to "API".
+func WithDocsTitle(title string) DocsOption {
+	return func(c *docsConfig) {
+		c.Title = title
+	}
+}
+
+// DocsHandler returns a http.Handler that serves Swagger UI for the
+// OpenAPI document at specPath, e.g. "/openapi.json" as served by
+// Router.OpenAPIHandler. The assets are embedded into the binary.
+//
+// Register the handler for a subtree like "/docs/", as the page loads
+// its assets relative to its own URL.
+func DocsHandler(specPath string, opts ...DocsOption) http.Handler {
+	cfg := docsConfig{Title: "API", SpecURL: specPath}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	var page bytes.Buffer
+	if err := docsTemplate.Execute(&page, cfg); err != nil {
+		panic(err)
+	}
+	specURL, _ := json.Marshal(cfg.SpecURL)
+	init := fmt.Sprintf(docsInitializer, specURL)
+	modTime := time.Now()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", docsContentSecurityPolicy)
+		switch name := path.Base(r.URL.Path); name {
+		case "swagger-ui-bundle.js", "swagger-ui.css":
+			asset, err := swaggerUIAssets.ReadFile("assets/swagger-ui/" + name)
+			if err != nil {
+				WriteJSONError(w, err)
+				return
+			}
+			w.Header().Set("Cache-Control", "public, max-age=86400")
+			http.ServeContent(w, r, name, modTime, bytes.NewReader(asset))
+		case "swagger-initializer.js":
+			w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+			_, _ = io.WriteString(w, init)
+		default:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write(page.Bytes())
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package generichttp
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Drainer tracks whether a server is shutting down. While it drains, its
+// middleware rejects new requests and its health check fails, so that
+// load balancers stop routing to the instance while in-flight requests
+// finish. RunServer starts draining on shutdown; use the same Drainer for
+// its options and the health check, e.g.:
+//
+//	var drainer generichttp.Drainer
+//	router.Get("/readyz", drainer.HealthHandler())
+//	err := generichttp.RunServer(ctx, srv, lis, generichttp.DrainOptions{Drainer: &drainer})
+//
+// The zero value is not draining and ready to use.
+type Drainer struct {
+	// RetryAfter is sent in the Retry-After header of rejected requests. It
+	// defaults to five seconds.
+	RetryAfter time.Duration
+
+	draining atomic.Bool
+}
+
+// Start starts draining. It can't be undone.
+func (d *Drainer) Start() {
+	d.draining.Store(true)
+}
+
+// Draining reports whether the Drainer drains.
+func (d *Drainer) Draining() bool {
+	return d.draining.Load()
+}
+
+// Handler returns a middleware that rejects requests while the Drainer
+// drains with a ServiceUnavailableError, a Retry-After header, and
+// "Connection: close", so that clients retry on another instance instead
+// of reusing the connection. Requests that started before are not
+// affected.
+func (d *Drainer) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !d.Draining() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		retryAfter := d.RetryAfter
+		if retryAfter <= 0 {
+			retryAfter = 5 * time.Second
+		}
+		w.Header().Set("Connection", "close")
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		writeJSONError(w, r, ServiceUnavailableError{Message: "Server is shutting down"})
+	})
+}
+
+// HealthHandler returns a handler for a readiness check of load balancers,
+// e.g. "/readyz". It answers with 200 OK while serving and with
+// 503 Service Unavailable while draining.
+func (d *Drainer) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if d.Draining() {
+			writeJSONError(w, r, ServiceUnavailableError{Message: "Server is shutting down"})
+			return
+		}
+		WriteJSON(w, struct {
+			Status string `json:"status"`
+		}{"ok"})
+	})
+}
+
+// DrainOptions configures the shutdown of RunServer.
+type DrainOptions struct {
+	// Drainer is started on shutdown. Use it for the health check of the
+	// server, see Drainer. It defaults to a new Drainer.
+	Drainer *Drainer
+	// Timeout is the time in-flight requests get to finish on shutdown,
+	// after which their connections are closed. It defaults to 30 seconds.
+	Timeout time.Duration
+	// Delay is the time between the start of draining and closing the
+	// listener, during which new requests are rejected and the health
+	// check fails, so that load balancers take the instance out of
+	// rotation before connections are refused. It defaults to zero.
+	Delay time.Duration
+}
+
+// RunServer serves requests on lis with srv until ctx is done, e.g. by a
+// signal with signal.NotifyContext, and then shuts down gracefully: it
+// starts draining, i.e. new requests are rejected as described for
+// Drainer.Handler and keep-alives are disabled, closes the listener after
+// opts.Delay, and waits for in-flight requests to finish within
+// opts.Timeout. RunServer wraps the handler of srv, or
+// http.DefaultServeMux if it is nil, in the middleware of the Drainer.
+//
+// It returns nil after a graceful shutdown, the error of Serve if serving
+// fails, and the error of Shutdown, e.g. context.DeadlineExceeded, if
+// requests were still in flight after the timeout.
+func RunServer(ctx context.Context, srv *http.Server, lis net.Listener, opts DrainOptions) error {
+	d := opts.Drainer
+	if d == nil {
+		d = new(Drainer)
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	h := srv.Handler
+	if h == nil {
+		h = http.DefaultServeMux
+	}
+	srv.Handler = d.Handler(h)
+
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(lis)
+	}()
+	select {
+	case err := <-served:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	d.Start()
+	srv.SetKeepAlivesEnabled(false)
+	if opts.Delay > 0 {
+		time.Sleep(opts.Delay)
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), opts.Timeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return err
+	}
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package generichttp_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/olivere/generichttp"
+	"github.com/olivere/generichttp/testutil"
+)
+
+func TestDrainerHandler(t *testing.T) {
+	d := &generichttp.Drainer{RetryAfter: 1500 * time.Millisecond}
+	h := d.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	health := d.HealthHandler()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("status = %d before draining, want %d", w.Code, http.StatusNoContent)
+	}
+	w = httptest.NewRecorder()
+	health.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("health status = %d before draining, want %d", w.Code, http.StatusOK)
+	}
+	testutil.AssertJSON(t, w, map[string]any{"status": "ok"})
+
+	d.Start()
+	if !d.Draining() {
+		t.Fatal("not draining after Start")
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d while draining, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want 2", got)
+	}
+	if got := w.Header().Get("Connection"); got != "close" {
+		t.Errorf("Connection = %q, want close", got)
+	}
+	testutil.AssertJSON(t, w, map[string]any{"message": "Server is shutting down"})
+
+	w = httptest.NewRecorder()
+	health.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("health status = %d while draining, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+}
+
+// drainServer runs RunServer with a handler that blocks until release is
+// closed, and returns the URL of the server and the result of RunServer.
+func drainServer(t *testing.T, ctx context.Context, opts generichttp.DrainOptions, started, release chan struct{}) (string, <-chan error) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		io.WriteString(w, "done")
+	})}
+	done := make(chan error, 1)
+	go func() {
+		done <- generichttp.RunServer(ctx, srv, lis, opts)
+	}()
+	return "http://" + lis.Addr().String(), done
+}
+
+func TestRunServerDrain(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	var d generichttp.Drainer
+	url, done := drainServer(t, ctx, generichttp.DrainOptions{Drainer: &d, Delay: 200 * time.Millisecond}, started, release)
+
+	inFlight := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url + "/slow")
+		if err != nil {
+			inFlight <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		inFlight <- resp.Status + " " + string(body)
+	}()
+	<-started
+	cancel()
+	for !d.Draining() {
+		time.Sleep(time.Millisecond)
+	}
+
+	// New requests are rejected while the listener is still open.
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	resp, err := client.Get(url + "/new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") != "5" {
+		t.Errorf("new request: status = %d, Retry-After = %q; want %d, 5", resp.StatusCode, resp.Header.Get("Retry-After"), http.StatusServiceUnavailable)
+	}
+
+	// The in-flight request finishes before RunServer returns.
+	select {
+	case err := <-done:
+		t.Fatalf("RunServer returned %v with a request in flight", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+	close(release)
+	if got := <-inFlight; got != "200 OK done" {
+		t.Errorf("in-flight request got %q, want 200 OK done", got)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("RunServer returned %v, want nil", err)
+	}
+}
+
+func TestRunServerDrainTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	defer close(release)
+	url, done := drainServer(t, ctx, generichttp.DrainOptions{Timeout: 50 * time.Millisecond}, started, release)
+
+	go func() {
+		resp, err := http.Get(url + "/stuck")
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("RunServer returned %v, want context.DeadlineExceeded", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunServer didn't return after the timeout")
+	}
+}
+
+func TestRunServerServeError(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis.Close()
+	err = generichttp.RunServer(context.Background(), &http.Server{}, lis, generichttp.DrainOptions{})
+	if err == nil || errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("RunServer returned %v, want the error of Serve", err)
+	}
+}
@@ -0,0 +1,79 @@
+package generichttp
+
+import (
+	"net/http"
+	"reflect"
+)
+
+// EmptyResponse is how JSON and Auto render a response without data, e.g.
+// NewResponse[T](nil) or a nil *Response, see WithEmptyResponse.
+type EmptyResponse int
+
+const (
+	// EmptyNoBody sends the status code of the response, or 200 if it is
+	// zero, without a body. It is the default.
+	EmptyNoBody EmptyResponse = iota
+	// EmptyNoContent sends 204 No Content instead of 200. Other status
+	// codes of the response, e.g. 202 Accepted, are kept, without a body.
+	EmptyNoContent
+	// EmptyNull sends the status code of the response with the JSON body
+	// null.
+	EmptyNull
+	// EmptyValue sends the status code of the response with an empty JSON
+	// value of the response type as the body: [] for slices and arrays,
+	// and {} for all other types.
+	EmptyValue
+)
+
+// WithEmptyResponse sets how JSON and Auto render responses without data,
+// e.g. from a handler that returns NewResponse[[]Item](nil); a handler
+// that returns a nil *Response and no error is treated the same. Clients
+// that always decode the body may fail on an empty one, so an API that
+// returns lists might use EmptyValue, and one that returns resources
+// EmptyNoContent. The default is EmptyNoBody.
+//
+// Auto renders the JSON bodies of EmptyNull and EmptyValue only if the
+// client accepts JSON, and no body otherwise. A response with status 204
+// never has a body.
+func WithEmptyResponse(mode EmptyResponse) Option {
+	return func(o *Options) {
+		o.EmptyResponse = mode
+	}
+}
+
+// writeEmpty renders resp, which has no data, according to mode. Bodies
+// are only rendered for the JSON media type.
+func writeEmpty[W any](w http.ResponseWriter, resp *Response[W], mode EmptyResponse, mediaType string) {
+	resp.writeHeader(w)
+	code := resp.StatusCode
+	if code == 0 {
+		code = http.StatusOK
+	}
+	if mode == EmptyNoContent && code == http.StatusOK {
+		code = http.StatusNoContent
+	}
+	if code == http.StatusNoContent || mediaType != mediaTypeJSON {
+		w.WriteHeader(code)
+		return
+	}
+	switch mode {
+	case EmptyNull:
+		writeBody(w, code, "application/json", []byte("null\n"))
+	case EmptyValue:
+		writeBody(w, code, "application/json", []byte(emptyValue[W]()+"\n"))
+	default:
+		w.WriteHeader(code)
+	}
+}
+
+// emptyValue returns the empty JSON value of type T, see EmptyValue.
+func emptyValue[T any]() string {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		return "[]"
+	}
+	return "{}"
+}
@@ -0,0 +1,97 @@
+package generichttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/olivere/generichttp"
+)
+
+type emptyItem struct {
+	ID int `json:"id"`
+}
+
+func nilResponse(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[[]emptyItem], error) {
+	return nil, nil
+}
+
+func nilData(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[[]emptyItem], error) {
+	return generichttp.NewResponse[[]emptyItem](nil), nil
+}
+
+func nilItem(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[emptyItem], error) {
+	return nil, nil
+}
+
+func TestEmptyResponse(t *testing.T) {
+	tests := []struct {
+		name string
+		mode generichttp.EmptyResponse
+		item bool // response type emptyItem instead of []emptyItem
+		code int
+		body string
+	}{
+		{"no body", generichttp.EmptyNoBody, false, http.StatusOK, ""},
+		{"no content", generichttp.EmptyNoContent, false, http.StatusNoContent, ""},
+		{"null", generichttp.EmptyNull, false, http.StatusOK, "null\n"},
+		{"empty slice", generichttp.EmptyValue, false, http.StatusOK, "[]\n"},
+		{"empty object", generichttp.EmptyValue, true, http.StatusOK, "{}\n"},
+	}
+	for _, tt := range tests {
+		opt := generichttp.WithEmptyResponse(tt.mode)
+		handlers := map[string]http.Handler{
+			"JSON nil response": generichttp.JSON(nilResponse, opt),
+			"JSON nil data":     generichttp.JSON(nilData, opt),
+			"Auto nil response": generichttp.Auto(nilResponse, opt),
+			"Auto nil data":     generichttp.Auto(nilData, opt),
+		}
+		if tt.item {
+			handlers = map[string]http.Handler{
+				"JSON nil response": generichttp.JSON(nilItem, opt),
+				"Auto nil response": generichttp.Auto(nilItem, opt),
+			}
+		}
+		for kind, h := range handlers {
+			t.Run(tt.name+"/"+kind, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+				if w.Code != tt.code {
+					t.Errorf("status = %d, want %d", w.Code, tt.code)
+				}
+				if w.Body.String() != tt.body {
+					t.Errorf("body = %q, want %q", w.Body, tt.body)
+				}
+			})
+		}
+	}
+}
+
+func TestEmptyResponseConditional(t *testing.T) {
+	for kind, h := range map[string]http.Handler{
+		"JSON": generichttp.JSON(nilResponse),
+		"Auto": generichttp.Auto(nilResponse),
+	} {
+		t.Run(kind, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("If-Modified-Since", time.Now().UTC().Format(http.TimeFormat))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != http.StatusOK {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+		})
+	}
+}
+
+func TestRespondNil(t *testing.T) {
+	w := httptest.NewRecorder()
+	generichttp.Respond[emptyItem](w, nil)
+	if w.Code != http.StatusOK || w.Body.Len() != 0 {
+		t.Errorf("status = %d, body = %q; want %d without a body", w.Code, w.Body, http.StatusOK)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "" {
+		t.Errorf("Content-Type = %q, want none", ct)
+	}
+}
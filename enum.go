@@ -0,0 +1,92 @@
+package generichttp
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// WithEnumValidation rejects requests with values not allowed by the enum
+// struct tag of their field with a BadRequestError naming the field and
+// the allowed values. The tag lists the allowed values separated by
+// commas, e.g. `enum:"draft,published,archived"` or `enum:"1,2,3"`. It
+// applies to strings, integers, and pointers and slices thereof. Nil
+// pointers are not checked, so use a pointer for an optional field.
+//
+// Enum validation walks the request data via reflection, but only for
+// types that have enum struct tags at all. Parsed tags are cached. It is
+// off by default.
+func WithEnumValidation() Option {
+	return func(o *Options) {
+		o.ValidateEnum = true
+	}
+}
+
+// enumCache caches the parsed values of enum struct tags.
+var enumCache sync.Map // map[string][]string
+
+// enumValues returns the values of an enum struct tag.
+func enumValues(tag string) []string {
+	if values, ok := enumCache.Load(tag); ok {
+		return values.([]string)
+	}
+	values := strings.Split(tag, ",")
+	for i := range values {
+		values[i] = strings.TrimSpace(values[i])
+	}
+	enumCache.Store(tag, values)
+	return values
+}
+
+// checkEnum checks the enum struct tag of field f with value v.
+func checkEnum(f reflect.StructField, v reflect.Value, path string) error {
+	tag, found := f.Tag.Lookup("enum")
+	if !found {
+		return nil
+	}
+	values := enumValues(tag)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		for i := 0; i < v.Len(); i++ {
+			if err := checkEnumValue(v.Index(i), values, f.Name, path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return checkEnumValue(v, values, f.Name, path)
+}
+
+// checkEnumValue checks that v is one of values.
+func checkEnumValue(v reflect.Value, values []string, name, path string) error {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	var s string
+	switch v.Kind() {
+	case reflect.String:
+		s = v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s = strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s = strconv.FormatUint(v.Uint(), 10)
+	default:
+		return fmt.Errorf("generichttp: enum tag on field %s of unsupported type %s", name, v.Type())
+	}
+	for _, value := range values {
+		if s == value {
+			return nil
+		}
+	}
+	return BadRequestError{Message: fmt.Sprintf("%s must be one of %s", path, strings.Join(values, ", "))}
+}
@@ -0,0 +1,63 @@
+package generichttp
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+// ErrorLogOptions configures the logging of errors rendered by JSON, Auto,
+// the middleware of this package, and WriteJSONError and WriteXMLError.
+type ErrorLogOptions struct {
+	// Logger is the logger to use. It defaults to slog.Default().
+	Logger *slog.Logger
+	// Level returns the level for an error with the given HTTP status code.
+	// It defaults to slog.LevelWarn for 4xx and slog.LevelError for 5xx.
+	// Return a level below the one of the logger to skip logging.
+	Level func(status int) slog.Level
+}
+
+// ErrorLog, if set, enables logging of errors. Each error is logged with
+// the method and path of the request, the HTTP status code, and the full
+// chain of wrapped errors. The message sent to the client is not affected,
+// so errors may contain details that must not leak. Set it on startup,
+// before serving requests.
+var ErrorLog *ErrorLogOptions
+
+// logError logs err, rendered with the given status code, if enabled in
+// ErrorLog. The request may be nil.
+func logError(r *http.Request, status int, err error) {
+	opts := ErrorLog
+	if opts == nil {
+		return
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	level := slog.LevelError
+	if opts.Level != nil {
+		level = opts.Level(status)
+	} else if status < http.StatusInternalServerError {
+		level = slog.LevelWarn
+	}
+	ctx := requestContext(r)
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	attrs := []slog.Attr{slog.Int("status", status)}
+	if r != nil {
+		attrs = append(attrs, slog.String("method", r.Method), slog.String("path", r.URL.Path))
+	}
+	attrs = append(attrs, slog.String("error", err.Error()), slog.Any("chain", errorChain(err)))
+	logger.LogAttrs(ctx, level, "generichttp: request failed", attrs...)
+}
+
+// errorChain returns the messages of err and the errors it wraps.
+func errorChain(err error) []string {
+	var chain []string
+	for ; err != nil; err = errors.Unwrap(err) {
+		chain = append(chain, err.Error())
+	}
+	return chain
+}
@@ -0,0 +1,424 @@
+package generichttp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// NotFoundError represents a HTTP Not Found error (status code 404).
+type NotFoundError struct {
+	Message string
+}
+
+// Error implements the error interface.
+func (e NotFoundError) Error() string { return e.HTTPError() }
+
+// HTTPCode returns the HTTP code.
+func (NotFoundError) HTTPCode() int { return http.StatusNotFound }
+
+// HTTPError returns the error message or "Not found".
+func (e NotFoundError) HTTPError() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return "Not found"
+}
+
+// MethodNotAllowedError represents a HTTP Method Not Allowed error
+// (status code 405).
+type MethodNotAllowedError struct {
+	Message string
+}
+
+// Error implements the error interface.
+func (e MethodNotAllowedError) Error() string { return e.HTTPError() }
+
+// HTTPCode returns the HTTP code.
+func (MethodNotAllowedError) HTTPCode() int { return http.StatusMethodNotAllowed }
+
+// HTTPError returns the error message or "Method not allowed".
+func (e MethodNotAllowedError) HTTPError() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return "Method not allowed"
+}
+
+// ForbiddenError represents a HTTP Forbidden error (status code 403).
+type ForbiddenError struct {
+	Message string
+}
+
+// Error implements the error interface.
+func (e ForbiddenError) Error() string { return e.HTTPError() }
+
+// HTTPCode returns the HTTP code.
+func (ForbiddenError) HTTPCode() int { return http.StatusForbidden }
+
+// HTTPError returns the error message or "Forbidden".
+func (e ForbiddenError) HTTPError() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return "Forbidden"
+}
+
+// PreconditionFailedError represents a HTTP Precondition Failed error
+// (status code 412), e.g. if the version given in If-Match doesn't match
+// the current version of a resource.
+type PreconditionFailedError struct {
+	Message string
+}
+
+// Error implements the error interface.
+func (e PreconditionFailedError) Error() string { return e.HTTPError() }
+
+// HTTPCode returns the HTTP code.
+func (PreconditionFailedError) HTTPCode() int { return http.StatusPreconditionFailed }
+
+// HTTPError returns the error message or "Precondition failed".
+func (e PreconditionFailedError) HTTPError() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return "Precondition failed"
+}
+
+// GatewayTimeoutError represents a HTTP Gateway Timeout error (status code
+// 504), e.g. if an item of a batch request or a call to an upstream service
+// doesn't complete in time. See UpstreamError.
+type GatewayTimeoutError struct {
+	Message string
+	// Err is the cause, if any. It is logged, but not sent to the client.
+	Err error
+}
+
+// Error implements the error interface. It includes the cause.
+func (e GatewayTimeoutError) Error() string { return withCause(e.HTTPError(), e.Err) }
+
+// Unwrap returns the cause.
+func (e GatewayTimeoutError) Unwrap() error { return e.Err }
+
+// HTTPCode returns the HTTP code.
+func (GatewayTimeoutError) HTTPCode() int { return http.StatusGatewayTimeout }
+
+// HTTPError returns the error message or "Gateway timeout".
+func (e GatewayTimeoutError) HTTPError() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return "Gateway timeout"
+}
+
+// UnauthorizedError represents a HTTP Unauthorized error (status code 401),
+// e.g. if a request is missing credentials.
+type UnauthorizedError struct {
+	Message string
+}
+
+// Error implements the error interface.
+func (e UnauthorizedError) Error() string { return e.HTTPError() }
+
+// HTTPCode returns the HTTP code.
+func (UnauthorizedError) HTTPCode() int { return http.StatusUnauthorized }
+
+// HTTPError returns the error message or "Unauthorized".
+func (e UnauthorizedError) HTTPError() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return "Unauthorized"
+}
+
+// ConflictError represents a HTTP Conflict error (status code 409).
+type ConflictError struct {
+	Message string
+}
+
+// Error implements the error interface.
+func (e ConflictError) Error() string { return e.HTTPError() }
+
+// HTTPCode returns the HTTP code.
+func (ConflictError) HTTPCode() int { return http.StatusConflict }
+
+// HTTPError returns the error message or "Conflict".
+func (e ConflictError) HTTPError() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return "Conflict"
+}
+
+// RequestHeaderFieldsTooLargeError represents a HTTP Request Header Fields
+// Too Large error (status code 431), e.g. if a request has too many headers.
+type RequestHeaderFieldsTooLargeError struct {
+	Message string
+}
+
+// Error implements the error interface.
+func (e RequestHeaderFieldsTooLargeError) Error() string { return e.HTTPError() }
+
+// HTTPCode returns the HTTP code.
+func (RequestHeaderFieldsTooLargeError) HTTPCode() int {
+	return http.StatusRequestHeaderFieldsTooLarge
+}
+
+// HTTPError returns the error message or "Request header fields too large".
+func (e RequestHeaderFieldsTooLargeError) HTTPError() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return "Request header fields too large"
+}
+
+// NotImplementedError represents a HTTP Not Implemented error (status code
+// 501).
+type NotImplementedError struct {
+	Message string
+}
+
+// Error implements the error interface.
+func (e NotImplementedError) Error() string { return e.HTTPError() }
+
+// HTTPCode returns the HTTP code.
+func (NotImplementedError) HTTPCode() int { return http.StatusNotImplemented }
+
+// HTTPError returns the error message or "Not implemented".
+func (e NotImplementedError) HTTPError() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return "Not implemented"
+}
+
+// RequestEntityTooLargeError represents a HTTP Request Entity Too Large
+// error (status code 413), e.g. if a request body exceeds the size limit.
+type RequestEntityTooLargeError struct {
+	Message string
+}
+
+// Error implements the error interface.
+func (e RequestEntityTooLargeError) Error() string { return e.HTTPError() }
+
+// HTTPCode returns the HTTP code.
+func (RequestEntityTooLargeError) HTTPCode() int { return http.StatusRequestEntityTooLarge }
+
+// HTTPError returns the error message or "Request entity too large".
+func (e RequestEntityTooLargeError) HTTPError() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return "Request entity too large"
+}
+
+// NotAcceptableError represents a HTTP Not Acceptable error (status code
+// 406), e.g. if a client accepts none of the media types of a handler, see
+// WithStrictAccept, or asks for a version of an API that doesn't exist,
+// see Versioned.
+type NotAcceptableError struct {
+	Message string
+}
+
+// Error implements the error interface.
+func (e NotAcceptableError) Error() string { return e.HTTPError() }
+
+// HTTPCode returns the HTTP code.
+func (NotAcceptableError) HTTPCode() int { return http.StatusNotAcceptable }
+
+// HTTPError returns the error message or "Not acceptable".
+func (e NotAcceptableError) HTTPError() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return "Not acceptable"
+}
+
+// UnsupportedMediaTypeError represents a HTTP Unsupported Media Type error
+// (status code 415), e.g. if a request body has a Content-Type or
+// Content-Encoding that the server doesn't support.
+type UnsupportedMediaTypeError struct {
+	Message string
+}
+
+// Error implements the error interface.
+func (e UnsupportedMediaTypeError) Error() string { return e.HTTPError() }
+
+// HTTPCode returns the HTTP code.
+func (UnsupportedMediaTypeError) HTTPCode() int { return http.StatusUnsupportedMediaType }
+
+// HTTPError returns the error message or "Unsupported media type".
+func (e UnsupportedMediaTypeError) HTTPError() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return "Unsupported media type"
+}
+
+// URITooLongError represents a HTTP URI Too Long error (status code 414).
+type URITooLongError struct {
+	Message string
+}
+
+// Error implements the error interface.
+func (e URITooLongError) Error() string { return e.HTTPError() }
+
+// HTTPCode returns the HTTP code.
+func (URITooLongError) HTTPCode() int { return http.StatusRequestURITooLong }
+
+// HTTPError returns the error message or "URI too long".
+func (e URITooLongError) HTTPError() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return "URI too long"
+}
+
+// ServiceUnavailableError represents a HTTP Service Unavailable error
+// (status code 503), e.g. if a service is overloaded or a dependency is
+// down.
+type ServiceUnavailableError struct {
+	Message string
+}
+
+// Error implements the error interface.
+func (e ServiceUnavailableError) Error() string { return e.HTTPError() }
+
+// HTTPCode returns the HTTP code.
+func (ServiceUnavailableError) HTTPCode() int { return http.StatusServiceUnavailable }
+
+// HTTPError returns the error message or "Service unavailable".
+func (e ServiceUnavailableError) HTTPError() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return "Service unavailable"
+}
+
+// TooManyRequestsError represents a HTTP Too Many Requests error (status
+// code 429), e.g. if a client exceeded its quota. Set a Retry-After header
+// with the time the client should wait.
+type TooManyRequestsError struct {
+	Message string
+}
+
+// Error implements the error interface.
+func (e TooManyRequestsError) Error() string { return e.HTTPError() }
+
+// HTTPCode returns the HTTP code.
+func (TooManyRequestsError) HTTPCode() int { return http.StatusTooManyRequests }
+
+// HTTPError returns the error message or "Too many requests".
+func (e TooManyRequestsError) HTTPError() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return "Too many requests"
+}
+
+// RangeNotSatisfiableError represents a HTTP Range Not Satisfiable error
+// (status code 416), e.g. if a Range header starts beyond the end of a
+// file. Set a Content-Range header with the size of the content, e.g.
+// "bytes */1024", before rendering it.
+type RangeNotSatisfiableError struct {
+	Message string
+}
+
+// Error implements the error interface.
+func (e RangeNotSatisfiableError) Error() string { return e.HTTPError() }
+
+// HTTPCode returns the HTTP code.
+func (RangeNotSatisfiableError) HTTPCode() int { return http.StatusRequestedRangeNotSatisfiable }
+
+// HTTPError returns the error message or "Range not satisfiable".
+func (e RangeNotSatisfiableError) HTTPError() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return "Range not satisfiable"
+}
+
+// BadGatewayError represents a HTTP Bad Gateway error (status code 502),
+// e.g. if an upstream service fails or returns an invalid response. See
+// UpstreamError.
+type BadGatewayError struct {
+	Message string
+	// Err is the cause, if any. It is logged, but not sent to the client.
+	Err error
+}
+
+// Error implements the error interface. It includes the cause.
+func (e BadGatewayError) Error() string { return withCause(e.HTTPError(), e.Err) }
+
+// Unwrap returns the cause.
+func (e BadGatewayError) Unwrap() error { return e.Err }
+
+// HTTPCode returns the HTTP code.
+func (BadGatewayError) HTTPCode() int { return http.StatusBadGateway }
+
+// HTTPError returns the error message or "Bad gateway".
+func (e BadGatewayError) HTTPError() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return "Bad gateway"
+}
+
+// UpstreamError wraps err, returned from calling an upstream service, in a
+// GatewayTimeoutError if it is a timeout, i.e. context.DeadlineExceeded or
+// a net.Error whose Timeout method returns true, or in a BadGatewayError
+// otherwise. The cause is kept for logging, see ErrorLog, but clients only
+// get the generic message. It returns nil if err is nil.
+//
+//	resp, err := client.Do(req)
+//	if err != nil {
+//		return nil, generichttp.UpstreamError(err)
+//	}
+func UpstreamError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return GatewayTimeoutError{Err: err}
+	}
+	return BadGatewayError{Err: err}
+}
+
+// withCause returns msg, followed by the message of cause if it is not nil.
+func withCause(msg string, cause error) string {
+	if cause == nil {
+		return msg
+	}
+	return msg + ": " + cause.Error()
+}
+
+// ValidationError represents a HTTP Bad Request error (status code 400)
+// for request data that violates a constraint on several fields, e.g.
+// fields that must not be set together, see Validator. Fields are the
+// JSON names of the fields involved; they are rendered in the fields
+// array of the error body.
+type ValidationError struct {
+	Message string
+	Fields  []string
+}
+
+// Error implements the error interface.
+func (e ValidationError) Error() string { return e.HTTPError() }
+
+// HTTPCode returns the HTTP code.
+func (ValidationError) HTTPCode() int { return http.StatusBadRequest }
+
+// HTTPError returns the error message or "Invalid fields" followed by the
+// names of the fields.
+func (e ValidationError) HTTPError() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	if len(e.Fields) > 0 {
+		return "Invalid fields: " + strings.Join(e.Fields, ", ")
+	}
+	return "Invalid fields"
+}
@@ -0,0 +1,133 @@
+package generichttp_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/olivere/generichttp"
+	"github.com/olivere/generichttp/testutil"
+)
+
+func TestWriteJSONErrorContentLength(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code int
+		want map[string]any
+	}{
+		{"bad request", generichttp.BadRequestError{Message: "Missing name"}, http.StatusBadRequest, map[string]any{"message": "Missing name"}},
+		{"generic error", errors.New("database is down"), http.StatusInternalServerError, map[string]any{"message": "Internal server error"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			generichttp.WriteJSONError(w, tt.err)
+			if w.Code != tt.code {
+				t.Fatalf("status = %d, want %d", w.Code, tt.code)
+			}
+			if got, want := w.Header().Get("Content-Length"), strconv.Itoa(w.Body.Len()); got != want {
+				t.Errorf("Content-Length = %q, want %q", got, want)
+			}
+			testutil.AssertJSON(t, w, tt.want)
+		})
+	}
+}
+
+func TestErrorResponseNotChunked(t *testing.T) {
+	h := generichttp.JSON(func(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[struct{}], error) {
+		return nil, generichttp.BadRequestError{Message: "Missing name"}
+	})
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+	if resp.ContentLength != int64(len(body)) {
+		t.Errorf("Content-Length = %d, want %d", resp.ContentLength, len(body))
+	}
+	if len(resp.TransferEncoding) != 0 {
+		t.Errorf("Transfer-Encoding = %v, want none", resp.TransferEncoding)
+	}
+}
+
+// timeoutError is a net.Error that timed out.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout reading from 10.0.0.7:443" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestUpstreamError(t *testing.T) {
+	var buf bytes.Buffer
+	defer func(prev *generichttp.ErrorLogOptions) { generichttp.ErrorLog = prev }(generichttp.ErrorLog)
+	generichttp.ErrorLog = &generichttp.ErrorLogOptions{Logger: slog.New(slog.NewJSONHandler(&buf, nil))}
+
+	refused := errors.New("dial tcp 10.0.0.7:443: connection refused")
+	tests := []struct {
+		name  string
+		cause error
+		code  int
+		msg   string
+	}{
+		{"failure", fmt.Errorf("call inventory: %w", refused), http.StatusBadGateway, "Bad gateway"},
+		{"deadline", fmt.Errorf("call inventory at 10.0.0.7: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, "Gateway timeout"},
+		{"net timeout", &url.Error{Op: "Get", URL: "https://10.0.0.7/items", Err: timeoutError{}}, http.StatusGatewayTimeout, "Gateway timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			err := generichttp.UpstreamError(tt.cause)
+			if !errors.Is(err, tt.cause) {
+				t.Errorf("errors.Is(%v, cause) = false, want the cause to be kept", err)
+			}
+			h := generichttp.JSON(func(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[struct{}], error) {
+				return nil, err
+			})
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+			if w.Code != tt.code {
+				t.Fatalf("status = %d, want %d", w.Code, tt.code)
+			}
+			testutil.AssertJSON(t, w, map[string]any{"message": tt.msg})
+			if strings.Contains(w.Body.String(), "10.0.0.7") {
+				t.Errorf("body = %s, want the cause not to be exposed", w.Body)
+			}
+			var line struct {
+				Level  string `json:"level"`
+				Status int    `json:"status"`
+				Error  string `json:"error"`
+			}
+			if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+				t.Fatalf("log = %q: %v", buf.String(), err)
+			}
+			if line.Level != "ERROR" || line.Status != tt.code || line.Error != tt.msg+": "+tt.cause.Error() {
+				t.Errorf("log = %+v, want the cause at error level", line)
+			}
+		})
+	}
+
+	if err := generichttp.UpstreamError(nil); err != nil {
+		t.Errorf("UpstreamError(nil) = %v, want nil", err)
+	}
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -18,7 +17,8 @@ import (
 
 func main() {
 	var (
-		port = flag.String("port", os.Getenv("PORT"), "HTTP port to bind to")
+		port  = flag.String("port", os.Getenv("PORT"), "HTTP port to bind to")
+		debug = flag.Bool("debug", false, "Enable the /debug endpoint")
 	)
 	flag.Parse()
 
@@ -34,9 +34,10 @@ func main() {
 
 	log.Printf("Listening on %s", lis.Addr().String())
 
-	// Create and start HTTP server
+	// Create HTTP server
+	app := newApp(*debug)
 	srv := &http.Server{
-		Handler: newApp(),
+		Handler: generichttp.SecurityHeaders(generichttp.StrictAPISecurityHeaders())(app),
 
 		// See e.g. https://ieftimov.com/posts/make-resilient-golang-net-http-servers-using-timeouts-deadlines-context-cancellation/
 		ReadTimeout:       5 * time.Second,
@@ -45,52 +46,51 @@ func main() {
 		ReadHeaderTimeout: 2 * time.Second,
 		MaxHeaderBytes:    8 * 1024, // 8 KiB
 	}
-	go func() {
-		if err := srv.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Fatal(err)
-		}
-	}()
 
 	// Triggers ctx.Done() if one of the signals are raised
 	ctx, stop := signal.NotifyContext(globalCtx, syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	<-ctx.Done()
-	stop()
-
-	log.Print("Shutting down")
-
-	// Cancel dependent processes
-	globalCancel()
-
-	// Shutdown HTTP server with a 5 second leeway
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := srv.Shutdown(ctx); err != nil {
+	// Serve until a signal is raised, then reject new requests and give
+	// in-flight requests 5 seconds to finish
+	if err := generichttp.RunServer(ctx, srv, lis, generichttp.DrainOptions{
+		Drainer: app.drainer,
+		Timeout: 5 * time.Second,
+	}); err != nil {
 		log.Fatal(err)
 	}
+	log.Print("Shut down")
 }
 
 // App that handles our requests.
 type App struct {
-	mux *http.ServeMux
+	router  *generichttp.Router
+	drainer *generichttp.Drainer
 }
 
 // newApp initializes an App.
-func newApp() *App {
+func newApp(debug bool) *App {
 	app := &App{
-		mux: http.NewServeMux(),
+		router:  generichttp.NewRouter(),
+		drainer: new(generichttp.Drainer),
 	}
 
-	app.mux.Handle("/", app.rootHandler())
-	app.mux.Handle("/add", app.addHandler())
+	app.router.Get("/", app.rootHandler())
+	app.router.Get("/readyz", app.drainer.HealthHandler())
+	app.router.Post("/add", app.addHandler(), generichttp.WithSummary("Add two numbers"))
+	app.router.Get("/openapi.json", app.router.OpenAPIHandler(generichttp.OpenAPIInfo{
+		Title:   "Example",
+		Version: "1.0.0",
+	}))
+	app.router.Get("/debug", generichttp.DebugHandler(generichttp.DebugOptions{Enabled: debug}))
+	app.router.Get("/docs/", generichttp.DocsHandler("/openapi.json", generichttp.WithDocsTitle("Example")))
 
 	return app
 }
 
-// Route all requests to the mux.
+// Route all requests to the router.
 func (app *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	app.mux.ServeHTTP(w, r)
+	app.router.ServeHTTP(w, r)
 }
 
 // rootHandler handles the "/" endpoint.
@@ -0,0 +1,38 @@
+package generichttp
+
+import "net/http"
+
+// WithPrecondition runs check before JSON and Auto read the request body,
+// e.g. to check permissions or quotas before accepting a large upload. If
+// check returns an error, it is rendered like an error of the handler and
+// the body is never read. Several preconditions run in order.
+//
+// This is what makes "Expect: 100-continue" useful: clients that send it
+// wait for the server before sending the body, and the server sends
+// "100 Continue" only when the handler starts to read the body. A request
+// that is rejected by a precondition, or by the Content-Length check of
+// WithMaxBodySize, is answered with the error right away, and the client
+// doesn't send the body at all. Middleware, e.g. for authentication, runs
+// before the body is read as well, unless it reads it itself.
+func WithPrecondition(check func(r *http.Request) error) Option {
+	return func(o *Options) {
+		if prev := o.Precondition; prev != nil {
+			o.Precondition = func(r *http.Request) error {
+				if err := prev(r); err != nil {
+					return err
+				}
+				return check(r)
+			}
+			return
+		}
+		o.Precondition = check
+	}
+}
+
+// checkPrecondition runs the precondition of opts, if any.
+func checkPrecondition(r *http.Request, opts *Options) error {
+	if opts.Precondition == nil {
+		return nil
+	}
+	return opts.Precondition(r)
+}
@@ -0,0 +1,177 @@
+package generichttp_test
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/olivere/generichttp"
+	"github.com/olivere/generichttp/testutil"
+)
+
+type upload struct {
+	Name string `json:"name"`
+}
+
+func requireQuota(r *http.Request) error {
+	if r.Header.Get("X-Quota") == "exceeded" {
+		return generichttp.ForbiddenError{Message: "Quota exceeded"}
+	}
+	return nil
+}
+
+// postExpectContinue sends a POST with "Expect: 100-continue" to url and
+// sends the body only if the server answers with 100 Continue. It returns
+// the interim status, if any, and the final response.
+func postExpectContinue(t *testing.T, url string, header http.Header, body string) (int, *http.Response) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := net.Dial("tcp", req.URL.Host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	var sb strings.Builder
+	sb.WriteString("POST " + req.URL.RequestURI() + " HTTP/1.1\r\nHost: " + req.URL.Host + "\r\n")
+	sb.WriteString("Content-Type: application/json\r\nExpect: 100-continue\r\n")
+	sb.WriteString("Content-Length: " + strconv.Itoa(len(body)) + "\r\n")
+	for k, v := range header {
+		sb.WriteString(k + ": " + v[0] + "\r\n")
+	}
+	sb.WriteString("\r\n")
+	if _, err := io.WriteString(conn, sb.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusContinue {
+		return 0, resp
+	}
+	if _, err := io.WriteString(conn, body); err != nil {
+		t.Fatal(err)
+	}
+	final, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, final
+}
+
+func TestExpectContinue(t *testing.T) {
+	var called atomic.Bool
+	srv := httptest.NewServer(generichttp.JSON(func(w http.ResponseWriter, req generichttp.Request[upload]) (*generichttp.Response[upload], error) {
+		called.Store(true)
+		return generichttp.Created(req.Data), nil
+	}, generichttp.WithPrecondition(requireQuota), generichttp.WithMaxBodySize(64)))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		header  http.Header
+		body    string
+		interim int
+		code    int
+		called  bool
+	}{
+		{"accepted", nil, `{"name":"report.pdf"}`, http.StatusContinue, http.StatusCreated, true},
+		{"precondition failed", http.Header{"X-Quota": {"exceeded"}}, `{"name":"report.pdf"}`, 0, http.StatusForbidden, false},
+		{"too large", nil, `{"name":"` + strings.Repeat("x", 100) + `"}`, 0, http.StatusRequestEntityTooLarge, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called.Store(false)
+			interim, resp := postExpectContinue(t, srv.URL+"/uploads", tt.header, tt.body)
+			defer resp.Body.Close()
+			if interim != tt.interim {
+				t.Errorf("interim status = %d, want %d", interim, tt.interim)
+			}
+			if resp.StatusCode != tt.code {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.code)
+			}
+			if called.Load() != tt.called {
+				t.Errorf("handler called = %v, want %v", called.Load(), tt.called)
+			}
+		})
+	}
+}
+
+// countingReader counts the reads of a request body.
+type countingReader struct {
+	io.Reader
+	reads int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	r.reads++
+	return r.Reader.Read(p)
+}
+
+func TestWithPrecondition(t *testing.T) {
+	var order []string
+	check := func(name string, err error) func(*http.Request) error {
+		return func(r *http.Request) error {
+			order = append(order, name)
+			return err
+		}
+	}
+	h := func(w http.ResponseWriter, req generichttp.Request[upload]) (*generichttp.Response[upload], error) {
+		order = append(order, "handler")
+		return generichttp.Created(req.Data), nil
+	}
+	tests := []struct {
+		name    string
+		checks  []func(*http.Request) error
+		code    int
+		message string
+		order   string
+	}{
+		{"pass", []func(*http.Request) error{check("first", nil), check("second", nil)}, http.StatusCreated, "", "first,second,handler"},
+		{"first fails", []func(*http.Request) error{check("first", generichttp.ForbiddenError{Message: "No"}), check("second", nil)}, http.StatusForbidden, "No", "first"},
+		{"second fails", []func(*http.Request) error{check("first", nil), check("second", errors.New("quota service down"))}, http.StatusInternalServerError, "Internal server error", "first,second"},
+	}
+	for _, tt := range tests {
+		var opts []generichttp.Option
+		for _, c := range tt.checks {
+			opts = append(opts, generichttp.WithPrecondition(c))
+		}
+		for kind, handler := range map[string]http.Handler{"JSON": generichttp.JSON(h, opts...), "Auto": generichttp.Auto(h, opts...)} {
+			t.Run(tt.name+"/"+kind, func(t *testing.T) {
+				order = nil
+				body := &countingReader{Reader: strings.NewReader(`{"name":"report.pdf"}`)}
+				r := httptest.NewRequest(http.MethodPost, "/uploads", body)
+				r.Header.Set("Content-Type", "application/json")
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+				if w.Code != tt.code {
+					t.Fatalf("status = %d, want %d: %s", w.Code, tt.code, w.Body)
+				}
+				if got := strings.Join(order, ","); got != tt.order {
+					t.Errorf("calls = %s, want %s", got, tt.order)
+				}
+				if tt.message != "" {
+					if body.reads != 0 {
+						t.Error("body read after a failed precondition")
+					}
+					testutil.AssertJSON(t, w, map[string]any{"message": tt.message})
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package generichttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// WithFieldSelection lets clients limit the fields of the response with
+// the fields query parameter, like partial responses in Google APIs, e.g.
+// "?fields=id,name,address.city". Paths are comma-separated lists of JSON
+// field names, with nested fields separated by dots. Paths into arrays
+// select the fields of their elements. A path that doesn't exist in the
+// response type results in a BadRequestError before the handler is called.
+//
+// Fields are selected on the JSON output, i.e. the response is encoded,
+// pruned, and encoded again, so this costs an additional round of
+// encoding. It applies to JSON responses only, and to the response data
+// before any post-processors, so e.g. an envelope added by a PostProcessor
+// is kept. It is off by default.
+func WithFieldSelection() Option {
+	return func(o *Options) {
+		o.FieldSelection = true
+	}
+}
+
+// fieldSet is a set of selected fields. A nil fieldSet for a field selects
+// the field with all of its subfields.
+type fieldSet map[string]fieldSet
+
+// responseFields returns the fields of W selected by the request, if
+// enabled in opts. Paths are checked against W with its fields renamed by
+// policy, the naming policy of the response, see WithEncoderSettings.
+func responseFields[W any](r *http.Request, opts *Options, policy NamingPolicy) (fieldSet, error) {
+	if !opts.FieldSelection {
+		return nil, nil
+	}
+	return parseFieldSelection(r, reflect.TypeOf((*W)(nil)).Elem(), policy)
+}
+
+// parseFieldSelection parses the fields query parameter of r and checks
+// the paths against type t with its fields renamed by policy. It returns
+// nil if no fields are selected.
+func parseFieldSelection(r *http.Request, t reflect.Type, policy NamingPolicy) (fieldSet, error) {
+	param := strings.TrimSpace(r.URL.Query().Get("fields"))
+	if param == "" {
+		return nil, nil
+	}
+	set := make(fieldSet)
+	for _, path := range strings.Split(param, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if !hasJSONPath(t, strings.Split(path, "."), policy) {
+			return nil, BadRequestError{Message: "Invalid field " + path}
+		}
+		set.add(strings.Split(path, "."))
+	}
+	return set, nil
+}
+
+// add adds a path to the set. Selecting a field selects all subfields,
+// so a field that is already selected as a whole is not narrowed.
+func (set fieldSet) add(path []string) {
+	sub, found := set[path[0]]
+	if len(path) == 1 {
+		set[path[0]] = nil
+		return
+	}
+	if found && sub == nil {
+		return
+	}
+	if sub == nil {
+		sub = make(fieldSet)
+		set[path[0]] = sub
+	}
+	sub.add(path[1:])
+}
+
+// hasJSONPath reports whether the JSON encoding of type t, with its fields
+// renamed by policy, has the path of field names. Paths below maps and
+// types with custom encodings cannot be checked and are accepted.
+func hasJSONPath(t reflect.Type, path []string, policy NamingPolicy) bool {
+	for len(path) > 0 {
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			if t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) {
+				return true
+			}
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Map || t.Kind() == reflect.Interface ||
+			t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) {
+			return true
+		}
+		if t.Kind() != reflect.Struct {
+			return false
+		}
+		ft, found := jsonFieldType(t, path[0], policy)
+		if !found {
+			return false
+		}
+		t, path = ft, path[1:]
+	}
+	return true
+}
+
+// jsonFieldType returns the type of the field of struct type t with the
+// given JSON name, including fields of embedded structs, see
+// dominantFields.
+func jsonFieldType(t reflect.Type, name string, policy NamingPolicy) (reflect.Type, bool) {
+	for _, f := range dominantFields(t, policy) {
+		if f.name == name {
+			return f.field.Type, true
+		}
+	}
+	return nil, false
+}
+
+// selectResponseFields returns data reduced to the fields in set, unless
+// set is nil or data renders itself.
+func selectResponseFields(data any, set fieldSet) (any, error) {
+	if set == nil {
+		return data, nil
+	}
+	if _, ok := data.(renderer); ok {
+		return data, nil
+	}
+	return selectFields(data, set)
+}
+
+// selectFields returns the JSON encoding of data reduced to the fields in
+// set, as a value to be encoded again.
+func selectFields(data any, set fieldSet) (any, error) {
+	b, err := Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return set.prune(v), nil
+}
+
+// prune removes the fields of v that are not in set.
+func (set fieldSet) prune(v any) any {
+	if set == nil {
+		return v
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		for k, sub := range v {
+			subset, found := set[k]
+			if !found {
+				delete(v, k)
+				continue
+			}
+			v[k] = subset.prune(sub)
+		}
+	case []any:
+		for i := range v {
+			v[i] = set.prune(v[i])
+		}
+	}
+	return v
+}
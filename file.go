@@ -0,0 +1,201 @@
+package generichttp
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"net/textproto"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileResponse is response data that is rendered as a file download
+// instead of JSON, e.g. for reports and exports. Use File to create a
+// response with it.
+type FileResponse struct {
+	// Reader is the content of the file. It is closed after rendering if
+	// it implements io.Closer.
+	Reader io.Reader
+	// Filename is the name the client saves the file as. It is sent in the
+	// Content-Disposition header, and may contain non-ASCII characters.
+	Filename string
+	// ContentType is the media type of the file. It defaults to the type
+	// of the extension of Filename, or application/octet-stream.
+	ContentType string
+	// Size is the size of the file in bytes, sent as Content-Length if it
+	// is greater than zero. Otherwise the response is chunked. It is not
+	// needed if Reader is an io.ReadSeeker.
+	Size int64
+	// ModTime is the modification time of the file, sent as Last-Modified
+	// and used for conditional and range requests if Reader is an
+	// io.ReadSeeker. It is optional.
+	ModTime time.Time
+}
+
+// File returns a response that JSON and Auto render as a download of the
+// content of rd, named filename, e.g.:
+//
+//	func export(w http.ResponseWriter, r generichttp.Request[ExportRequest]) (*generichttp.Response[generichttp.FileResponse], error) {
+//		f, err := os.Open(reportPath)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return generichttp.File(f, "report.csv"), nil
+//	}
+//
+// Set ContentType and Size on the Data of the response if they are known.
+// Copying stops when the request is canceled. Errors returned from the
+// handler are still rendered as JSON or XML.
+//
+// If rd is an io.ReadSeeker, e.g. an *os.File, and the status code is
+// 200, the response supports range requests for resumable downloads and
+// media players: it is served with http.ServeContent, which answers Range
+// headers with 206 Partial Content, including multiple ranges, and sends
+// Accept-Ranges: bytes. Ranges that can't be satisfied are answered with
+// a RangeNotSatisfiableError.
+func File(rd io.Reader, filename string) *Response[FileResponse] {
+	return NewResponse(&FileResponse{Reader: rd, Filename: filename})
+}
+
+// render implements renderer.
+func (f *FileResponse) render(w http.ResponseWriter, r *http.Request, code int) {
+	if c, ok := f.Reader.(io.Closer); ok {
+		defer c.Close()
+	}
+	contentType := f.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(f.Filename))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	if f.Filename != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": f.Filename}))
+	}
+	if code == 0 {
+		code = http.StatusOK
+	}
+	if rs, ok := f.Reader.(io.ReadSeeker); ok && r != nil && code == http.StatusOK {
+		f.serveContent(w, r, rs)
+		return
+	}
+	if f.Size > 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(f.Size, 10))
+	}
+	w.WriteHeader(code)
+	if f.Reader == nil {
+		return
+	}
+	_, err := io.Copy(w, &contextReader{ctx: requestContext(r), r: f.Reader})
+	handleWriteError(err)
+}
+
+// serveContent serves rs with support for range requests.
+func (f *FileResponse) serveContent(w http.ResponseWriter, r *http.Request, rs io.ReadSeeker) {
+	// http.ServeContent answers invalid ranges with a plain text error, so
+	// check them first. With If-Range, ranges may be ignored; leave it to
+	// http.ServeContent then.
+	if rangeHeader := r.Header.Get("Range"); rangeHeader != "" && r.Header.Get("If-Range") == "" {
+		size, err := rs.Seek(0, io.SeekEnd)
+		if err == nil {
+			_, err = rs.Seek(0, io.SeekStart)
+		}
+		if err != nil {
+			writeJSONError(w, r, err)
+			return
+		}
+		if !rangeSatisfiable(rangeHeader, size) {
+			w.Header().Del("Content-Disposition")
+			w.Header().Del("Content-Type")
+			w.Header().Set("Content-Range", "bytes */"+strconv.FormatInt(size, 10))
+			writeJSONError(w, r, RangeNotSatisfiableError{})
+			return
+		}
+	}
+	http.ServeContent(w, r, f.Filename, f.ModTime, &contextReadSeeker{
+		contextReader: contextReader{ctx: r.Context(), r: rs},
+		s:             rs,
+	})
+}
+
+// rangeSatisfiable reports whether the Range header s can be satisfied for
+// content of the given size, following the rules of http.ServeContent:
+// the header must be well-formed, and at least one range must start
+// before the end of the content, unless the content is empty.
+func rangeSatisfiable(s string, size int64) bool {
+	spec, ok := strings.CutPrefix(s, "bytes=")
+	if !ok {
+		return false
+	}
+	overlap, noOverlap := false, false
+	for _, ra := range strings.Split(spec, ",") {
+		ra = textproto.TrimString(ra)
+		if ra == "" {
+			continue
+		}
+		start, end, ok := strings.Cut(ra, "-")
+		if !ok {
+			return false
+		}
+		start, end = textproto.TrimString(start), textproto.TrimString(end)
+		if start == "" {
+			// Suffix range, e.g. "-500" for the last 500 bytes.
+			if i, err := strconv.ParseInt(end, 10, 64); end == "" || end[0] == '-' || err != nil || i < 0 {
+				return false
+			}
+			overlap = true
+			continue
+		}
+		i, err := strconv.ParseInt(start, 10, 64)
+		if err != nil || i < 0 {
+			return false
+		}
+		if i >= size {
+			noOverlap = true
+			continue
+		}
+		if end != "" {
+			j, err := strconv.ParseInt(end, 10, 64)
+			if err != nil || i > j {
+				return false
+			}
+		}
+		overlap = true
+	}
+	return overlap || !noOverlap || size == 0
+}
+
+// content implements renderer.
+func (*FileResponse) content() (string, *Schema) {
+	return "application/octet-stream", &Schema{Type: "string", Format: "binary"}
+}
+
+// contextReader is an io.Reader that fails once its context is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// Read implements io.Reader.
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
+
+// contextReadSeeker is an io.ReadSeeker that fails reading once its
+// context is done.
+type contextReadSeeker struct {
+	contextReader
+	s io.Seeker
+}
+
+// Seek implements io.Seeker.
+func (crs *contextReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	return crs.s.Seek(offset, whence)
+}
@@ -0,0 +1,142 @@
+package generichttp_test
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/olivere/generichttp"
+	"github.com/olivere/generichttp/testutil"
+)
+
+const fileContent = "0123456789abcdefghij"
+
+func serveFile(seekable bool) generichttp.Handler[struct{}, generichttp.FileResponse] {
+	return func(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[generichttp.FileResponse], error) {
+		var rd io.Reader = strings.NewReader(fileContent)
+		if !seekable {
+			rd = io.MultiReader(rd)
+		}
+		return generichttp.File(rd, "data.txt"), nil
+	}
+}
+
+func getRange(h http.Handler, rangeHeader string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/data.txt", nil)
+	if rangeHeader != "" {
+		r.Header.Set("Range", rangeHeader)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestFileRange(t *testing.T) {
+	tests := []struct {
+		rangeHeader  string
+		code         int
+		contentRange string
+		body         string
+	}{
+		{"", http.StatusOK, "", fileContent},
+		{"bytes=0-4", http.StatusPartialContent, "bytes 0-4/20", "01234"},
+		{"bytes=15-", http.StatusPartialContent, "bytes 15-19/20", "fghij"},
+		{"bytes=-3", http.StatusPartialContent, "bytes 17-19/20", "hij"},
+		{"bytes=18-100", http.StatusPartialContent, "bytes 18-19/20", "ij"},
+		{"bytes=25-, 0-1", http.StatusPartialContent, "bytes 0-1/20", "01"},
+		{"bytes=", http.StatusOK, "", fileContent},
+	}
+	for _, tt := range tests {
+		for kind, h := range map[string]http.Handler{
+			"JSON": generichttp.JSON(serveFile(true)),
+			"Auto": generichttp.Auto(serveFile(true)),
+		} {
+			t.Run(tt.rangeHeader+"/"+kind, func(t *testing.T) {
+				w := getRange(h, tt.rangeHeader)
+				if w.Code != tt.code {
+					t.Fatalf("status = %d, want %d: %s", w.Code, tt.code, w.Body)
+				}
+				if got := w.Header().Get("Accept-Ranges"); got != "bytes" {
+					t.Errorf("Accept-Ranges = %q, want bytes", got)
+				}
+				if got := w.Header().Get("Content-Range"); got != tt.contentRange {
+					t.Errorf("Content-Range = %q, want %q", got, tt.contentRange)
+				}
+				if got := w.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+					t.Errorf("Content-Type = %q, want text/plain; charset=utf-8", got)
+				}
+				if w.Body.String() != tt.body {
+					t.Errorf("body = %q, want %q", w.Body, tt.body)
+				}
+			})
+		}
+	}
+}
+
+func TestFileMultipleRanges(t *testing.T) {
+	w := getRange(generichttp.JSON(serveFile(true)), "bytes=0-1,5-6")
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusPartialContent)
+	}
+	mediaType, params, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if err != nil || mediaType != "multipart/byteranges" {
+		t.Fatalf("Content-Type = %q, want multipart/byteranges", w.Header().Get("Content-Type"))
+	}
+	mr := multipart.NewReader(w.Body, params["boundary"])
+	for _, want := range []struct{ contentRange, body string }{{"bytes 0-1/20", "01"}, {"bytes 5-6/20", "56"}} {
+		part, err := mr.NextPart()
+		if err != nil {
+			t.Fatalf("part %s: %v", want.contentRange, err)
+		}
+		body, _ := io.ReadAll(part)
+		if got := part.Header.Get("Content-Range"); got != want.contentRange || string(body) != want.body {
+			t.Errorf("part: Content-Range = %q, body = %q; want %q, %q", got, body, want.contentRange, want.body)
+		}
+	}
+	if _, err := mr.NextPart(); err != io.EOF {
+		t.Errorf("more parts than ranges: %v", err)
+	}
+}
+
+func TestFileInvalidRange(t *testing.T) {
+	for _, rangeHeader := range []string{
+		"bytes=20-",
+		"bytes=100-200",
+		"bytes=5-2",
+		"bytes=abc",
+		"bytes=-x",
+		"bytes=0-1,x",
+		"items=0-1",
+	} {
+		t.Run(rangeHeader, func(t *testing.T) {
+			w := getRange(generichttp.JSON(serveFile(true)), rangeHeader)
+			if w.Code != http.StatusRequestedRangeNotSatisfiable {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusRequestedRangeNotSatisfiable, w.Body)
+			}
+			if got := w.Header().Get("Content-Range"); got != "bytes */20" {
+				t.Errorf("Content-Range = %q, want %q", got, "bytes */20")
+			}
+			if got := w.Header().Get("Content-Disposition"); got != "" {
+				t.Errorf("Content-Disposition = %q, want none on errors", got)
+			}
+			testutil.AssertJSON(t, w, map[string]any{"message": "Range not satisfiable"})
+		})
+	}
+}
+
+func TestFileRangeNotSeekable(t *testing.T) {
+	w := getRange(generichttp.JSON(serveFile(false)), "bytes=0-4")
+	if w.Code != http.StatusOK || w.Body.String() != fileContent {
+		t.Errorf("status = %d, body = %q; want the whole file", w.Code, w.Body)
+	}
+	if got := w.Header().Get("Accept-Ranges"); got != "" {
+		t.Errorf("Accept-Ranges = %q, want none", got)
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename=data.txt` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+}
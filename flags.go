@@ -0,0 +1,58 @@
+package generichttp
+
+import (
+	"context"
+	"net/http"
+)
+
+// FlagEvaluator reports whether the feature flag with the given name is
+// enabled for a request, e.g. by looking up the user or tenant in the
+// request context (see TenantFromContext) in a feature flag service.
+type FlagEvaluator func(r *http.Request, name string) bool
+
+// flagsKey is the context key of the FlagEvaluator.
+type flagsKey struct{}
+
+// Flags returns a middleware that makes eval available to Flagged and
+// FlagEnabled in the handlers behind it. Install it after the middleware
+// that stores what eval keys on, e.g. Tenant.
+func Flags(eval FlagEvaluator) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), flagsKey{}, eval)))
+		})
+	}
+}
+
+// FlagEnabled reports whether the feature flag with the given name is
+// enabled for r, as evaluated by the FlagEvaluator installed with Flags.
+// Flags are disabled if there is none.
+func FlagEnabled(r *http.Request, name string) bool {
+	eval, ok := r.Context().Value(flagsKey{}).(FlagEvaluator)
+	return ok && eval != nil && eval(r, name)
+}
+
+// Flagged returns a handler that serves requests with on if the feature
+// flag with the given name is enabled for the request, and with off
+// otherwise, e.g. to roll out a new implementation of an endpoint
+// gradually:
+//
+//	router.Get("/search", generichttp.Flagged("new-search",
+//		generichttp.JSON(searchV2),
+//		generichttp.JSON(search),
+//	))
+//
+// See Flags for how flags are evaluated. A nil off handler responds with a
+// NotFoundError, which hides endpoints that aren't released yet.
+func Flagged(name string, on, off http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case FlagEnabled(r, name):
+			on.ServeHTTP(w, r)
+		case off != nil:
+			off.ServeHTTP(w, r)
+		default:
+			writeJSONError(w, r, NotFoundError{})
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package generichttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/olivere/generichttp"
+	"github.com/olivere/generichttp/testutil"
+)
+
+// betaUsers enables the "new-search" flag for the principals "alice" and
+// "bob".
+func betaUsers(r *http.Request, name string) bool {
+	p, _ := generichttp.PrincipalFromContext(r.Context())
+	return name == "new-search" && (p == "alice" || p == "bob")
+}
+
+func searchVersion(version string) http.Handler {
+	return generichttp.JSON(func(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[map[string]string], error) {
+		return generichttp.OK(&map[string]string{"version": version}), nil
+	})
+}
+
+func TestFlagged(t *testing.T) {
+	tests := []struct {
+		name      string
+		h         http.Handler
+		principal string
+		code      int
+		want      any
+	}{
+		{"on", generichttp.Flagged("new-search", searchVersion("v2"), searchVersion("v1")), "alice", http.StatusOK, map[string]any{"version": "v2"}},
+		{"off", generichttp.Flagged("new-search", searchVersion("v2"), searchVersion("v1")), "carol", http.StatusOK, map[string]any{"version": "v1"}},
+		{"other flag", generichttp.Flagged("new-checkout", searchVersion("v2"), searchVersion("v1")), "alice", http.StatusOK, map[string]any{"version": "v1"}},
+		{"off without handler", generichttp.Flagged("new-search", searchVersion("v2"), nil), "carol", http.StatusNotFound, map[string]any{"message": "Not found"}},
+		{"on without off handler", generichttp.Flagged("new-search", searchVersion("v2"), nil), "bob", http.StatusOK, map[string]any{"version": "v2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := testutil.NewRequest(struct{}{}, testutil.WithPrincipal(tt.principal))
+			w := httptest.NewRecorder()
+			generichttp.Flags(betaUsers)(tt.h).ServeHTTP(w, req.Request)
+			if w.Code != tt.code {
+				t.Fatalf("status = %d, want %d", w.Code, tt.code)
+			}
+			testutil.AssertJSON(t, w, tt.want)
+		})
+	}
+}
+
+func TestFlaggedWithoutEvaluator(t *testing.T) {
+	h := generichttp.Flagged("new-search", searchVersion("v2"), searchVersion("v1"))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, testutil.NewRequest(struct{}{}, testutil.WithPrincipal("alice")).Request)
+	testutil.AssertJSON(t, w, map[string]any{"version": "v1"})
+
+	if generichttp.FlagEnabled(httptest.NewRequest(http.MethodGet, "/", nil), "new-search") {
+		t.Error("FlagEnabled = true without Flags, want false")
+	}
+}
@@ -0,0 +1,118 @@
+package generichttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fuzzPlain struct {
+	Name   string   `json:"name" normalize:"trim,lower" maxlen:"64"`
+	Count  int      `json:"count"`
+	Tags   []string `json:"tags"`
+	Nested *struct {
+		Value float64 `json:"value"`
+	} `json:"nested"`
+}
+
+type fuzzTimes struct {
+	Name string      `json:"name"`
+	At   time.Time   `json:"at" time:"unix"`
+	Days []time.Time `json:"days" time:"2006-01-02"`
+}
+
+// fuzzBodyLimit is the body limit of FuzzNewRequest, small enough for the
+// fuzzer to exceed it.
+const fuzzBodyLimit = 1024
+
+func FuzzNewRequest(f *testing.F) {
+	oversize := `{"name":"` + strings.Repeat("x", 2*fuzzBodyLimit) + `"}`
+	seeds := []struct {
+		body            string
+		contentType     string
+		contentEncoding string
+		compress        bool
+	}{
+		{`{"name":"Alice","count":1,"tags":["a"],"nested":{"value":1.5}}`, "application/json", "", false},
+		{`{"name":"Alice","at":1700000000,"days":["2024-01-02"]}`, "application/json", "", false},
+		{`{"name":"Alice",`, "application/json", "", false},
+		{`{"at":"yesterday"}`, "application/json", "", false},
+		{`{"name":"Alice","count":1}`, "application/json", "gzip", true},
+		{`{"name":"Alice","count":1}`, "application/json", "deflate", true},
+		{`not gzip`, "application/json", "gzip", false},
+		{`{"name":"Alice"}`, "application/json", "br", false},
+		{`<user><name>Alice</name></user>`, "application/xml", "", false},
+		{`name=Alice&count=1`, "application/x-www-form-urlencoded", "", false},
+		{oversize, "application/json", "", false},
+		{oversize, "application/json", "gzip", true},
+		{"", "", "", false},
+		{"null", "application/json", "", false},
+	}
+	for _, s := range seeds {
+		f.Add([]byte(s.body), s.contentType, s.contentEncoding, s.compress)
+	}
+	f.Fuzz(func(t *testing.T, body []byte, contentType, contentEncoding string, compress bool) {
+		raw := body
+		if compress {
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			zw.Write(body)
+			zw.Close()
+			raw = buf.Bytes()
+		}
+		// plain is whether the fuzzer's body is what the decoder sees.
+		enc := strings.ToLower(strings.TrimSpace(contentEncoding))
+		plain := (!compress && (enc == "" || enc == "identity")) ||
+			(compress && (enc == "gzip" || enc == "x-gzip"))
+
+		opts := newOptions(DefaultOptions(), []Option{
+			WithMaxBodySize(fuzzBodyLimit),
+			WithDecompressBody(),
+		})
+		newReq := func() *http.Request {
+			r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(raw))
+			r.Header.Set("Content-Type", contentType)
+			if contentEncoding != "" {
+				r.Header.Set("Content-Encoding", contentEncoding)
+			}
+			return r
+		}
+
+		req, err := newRequest[fuzzPlain](httptest.NewRecorder(), newReq(), opts)
+		checkFuzzResult(t, req.Data == nil, err, body, raw, plain)
+		treq, err := newRequest[fuzzTimes](httptest.NewRecorder(), newReq(), opts)
+		checkFuzzResult(t, treq.Data == nil, err, body, raw, plain)
+
+		// The public API must not panic either.
+		NewRequest[fuzzPlain](newReq())
+	})
+}
+
+// checkFuzzResult checks the invariants of newRequest: an error always has
+// a HTTP status code, a Content-Length over the limit results in 413, and
+// without an error there is request data unless the body was empty or null.
+func checkFuzzResult(t *testing.T, noData bool, err error, body, raw []byte, plain bool) {
+	t.Helper()
+	if len(raw) > fuzzBodyLimit {
+		if err == nil || errorCode(err) != http.StatusRequestEntityTooLarge {
+			t.Fatalf("body of %d bytes over the limit of %d: err = %v, want 413", len(raw), fuzzBodyLimit, err)
+		}
+		return
+	}
+	if err != nil {
+		if _, ok := err.(interface{ HTTPCode() int }); !ok {
+			t.Fatalf("error without HTTP status code: %T %v", err, err)
+		}
+		return
+	}
+	if noData && plain {
+		trimmed := bytes.TrimSpace(body)
+		if len(trimmed) > 0 && !bytes.HasPrefix(trimmed, []byte("null")) {
+			t.Fatalf("no data and no error for body %q", body)
+		}
+	}
+}
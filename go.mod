@@ -1,3 +1,5 @@
 module github.com/olivere/generichttp
 
-go 1.18
+go 1.22
+
+require golang.org/x/text v0.22.0
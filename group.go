@@ -0,0 +1,167 @@
+package generichttp
+
+import (
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// Group is a named group of routes of a Router that share a pool of
+// concurrent requests, so that e.g. a slow endpoint can't exhaust the
+// capacity of the others (bulkhead isolation). Create it with
+// Router.Group and register routes with its methods.
+type Group struct {
+	rt       *Router
+	name     string
+	limit    int
+	timeout  time.Duration
+	sem      chan struct{}
+	inFlight atomic.Int64
+	rejected atomic.Int64
+}
+
+// GroupOption configures a Group.
+type GroupOption func(*Group)
+
+// WithConcurrency limits the number of requests a Group handles
+// concurrently to n. Requests over the limit are rejected with a
+// ServiceUnavailableError right away, instead of waiting. Zero means no
+// limit.
+func WithConcurrency(n int) GroupOption {
+	return func(g *Group) {
+		g.limit = n
+	}
+}
+
+// WithGroupTimeout sets the timeout of the routes in a Group, unless they
+// have their own, see WithTimeout. It overrides the default of the router.
+func WithGroupTimeout(d time.Duration) GroupOption {
+	return func(g *Group) {
+		g.timeout = d
+	}
+}
+
+// GroupStats are the metrics of a Group.
+type GroupStats struct {
+	// Name is the name of the group.
+	Name string
+	// Limit is the maximum number of concurrent requests, or 0 if the
+	// number is not limited.
+	Limit int
+	// InFlight is the number of requests being handled.
+	InFlight int64
+	// Rejected is the number of requests rejected because the group was
+	// at its limit, since the group was created.
+	Rejected int64
+}
+
+// Group returns the group of routes with the given name, creating it with
+// opts if it doesn't exist yet, e.g.:
+//
+//	heavy := router.Group("heavy", generichttp.WithConcurrency(10))
+//	heavy.Post("/reports", generichttp.JSON(createReport))
+//
+// The options are ignored if the group exists. Routes registered with the
+// Router itself don't belong to any group and are not limited.
+func (rt *Router) Group(name string, opts ...GroupOption) *Group {
+	if g, found := rt.groups[name]; found {
+		return g
+	}
+	g := &Group{rt: rt, name: name}
+	for _, opt := range opts {
+		opt(g)
+	}
+	if g.limit > 0 {
+		g.sem = make(chan struct{}, g.limit)
+	}
+	if rt.groups == nil {
+		rt.groups = make(map[string]*Group)
+	}
+	rt.groups[name] = g
+	return g
+}
+
+// GroupStats returns the metrics of all groups of the router, sorted by
+// name, e.g. to export them as gauges.
+func (rt *Router) GroupStats() []GroupStats {
+	stats := make([]GroupStats, 0, len(rt.groups))
+	for _, g := range rt.groups {
+		stats = append(stats, g.Stats())
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
+
+// Name returns the name of the group.
+func (g *Group) Name() string {
+	return g.name
+}
+
+// Stats returns the metrics of the group.
+func (g *Group) Stats() GroupStats {
+	return GroupStats{
+		Name:     g.name,
+		Limit:    g.limit,
+		InFlight: g.inFlight.Load(),
+		Rejected: g.rejected.Load(),
+	}
+}
+
+// Handle registers the handler for the given method and pattern in the
+// group, see Router.Handle.
+func (g *Group) Handle(method, pattern string, h http.Handler, opts ...RouteOption) {
+	opts = append(opts, func(e *endpoint) { e.group = g })
+	g.rt.Handle(method, pattern, h, opts...)
+}
+
+// HandleMethods registers the handler for each of the given methods and
+// the pattern in the group, see Router.HandleMethods.
+func (g *Group) HandleMethods(methods []string, pattern string, h http.Handler, opts ...RouteOption) {
+	for _, method := range methods {
+		g.Handle(method, pattern, h, opts...)
+	}
+}
+
+// Get registers the handler for GET requests on the given pattern.
+func (g *Group) Get(pattern string, h http.Handler, opts ...RouteOption) {
+	g.Handle(http.MethodGet, pattern, h, opts...)
+}
+
+// Post registers the handler for POST requests on the given pattern.
+func (g *Group) Post(pattern string, h http.Handler, opts ...RouteOption) {
+	g.Handle(http.MethodPost, pattern, h, opts...)
+}
+
+// Put registers the handler for PUT requests on the given pattern.
+func (g *Group) Put(pattern string, h http.Handler, opts ...RouteOption) {
+	g.Handle(http.MethodPut, pattern, h, opts...)
+}
+
+// Patch registers the handler for PATCH requests on the given pattern.
+func (g *Group) Patch(pattern string, h http.Handler, opts ...RouteOption) {
+	g.Handle(http.MethodPatch, pattern, h, opts...)
+}
+
+// Delete registers the handler for DELETE requests on the given pattern.
+func (g *Group) Delete(pattern string, h http.Handler, opts ...RouteOption) {
+	g.Handle(http.MethodDelete, pattern, h, opts...)
+}
+
+// serve serves the request with h if the group has capacity left, and
+// rejects it otherwise.
+func (g *Group) serve(h http.Handler, w http.ResponseWriter, r *http.Request) {
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+			defer func() { <-g.sem }()
+		default:
+			g.rejected.Add(1)
+			writeJSONError(w, r, ServiceUnavailableError{Message: "Too many concurrent requests"})
+			return
+		}
+	}
+	g.inFlight.Add(1)
+	defer g.inFlight.Add(-1)
+	h.ServeHTTP(w, r)
+}
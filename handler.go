@@ -1,34 +1,210 @@
 package generichttp
 
 import (
-	"encoding/json"
+	"context"
+	"encoding/xml"
+	"fmt"
 	"io"
 	"net/http"
+	"reflect"
+	"strconv"
+	"time"
 )
 
+// defaultMaxBodySize is the maximum number of bytes read from a request body.
+const defaultMaxBodySize = 1 << 20
+
 // Handler for a generic endpoint.
 type Handler[R, W any] func(http.ResponseWriter, Request[R]) (*Response[W], error)
 
-// Request wraps data on the request side.
+// Request wraps data on the request side. The embedded http.Request is the
+// one passed to JSON or Auto, with attributes added to its context, so
+// values that middleware stored in the context are available through
+// Context.
 type Request[T any] struct {
 	*http.Request
 	Data *T
 }
 
+// WithContext returns a shallow copy of r with its context changed to ctx,
+// keeping Data, e.g. to add a value to the context in a HandlerMiddleware
+// before calling the next handler. It shadows http.Request.WithContext,
+// which returns an *http.Request; use r.Request.WithContext for that.
+func (r Request[T]) WithContext(ctx context.Context) Request[T] {
+	r.Request = r.Request.WithContext(ctx)
+	return r
+}
+
 // NewRequest creates a new Request from a HTTP request. It parses the HTTP
-// body up to 1 MiB.
+// body up to 1 MiB; larger bodies are not truncated but leave Data empty.
+// JSON and Auto answer them with a RequestEntityTooLargeError and close the
+// connection, see WithMaxBodySize. Bodies that cannot be decoded leave Data
+// empty as well; JSON and Auto answer them with a BadRequestError naming
+// the line and column of the error, see WithVerboseDecodeErrors. Fields of
+// T with a query struct tag are set from the query string, see BindQuery.
+//
+// String fields of T can be normalized after parsing with the normalize
+// struct tag. It lists operations that are applied to strings, pointers
+// to strings, and slices of strings in the order given, e.g.
+// `normalize:"trim,lower"` first trims white space, then converts to
+// lower case. Supported operations are:
+//
+//   - trim: remove leading and trailing white space
+//   - lower: convert to lower case
+//   - upper: convert to upper case
+//   - nfc: convert to Unicode Normalization Form C
+//
+// Fields of nested structs are normalized as well. Normalization happens
+// before validation, so e.g. a maxlen struct tag applies to the
+// normalized string.
+//
+// The time struct tag sets the format of time.Time fields in the body,
+// which otherwise must be in RFC 3339. It is either a layout as accepted by
+// time.Parse, e.g. `time:"2006-01-02"`, or "unix" for seconds since the
+// Unix epoch, or "unixmilli" for milliseconds. Unix timestamps may be
+// numbers or strings. The tag applies to pointers and slices of time.Time
+// as well. Bodies of types with time struct tags are decoded in two passes.
+// In JSON and Auto, a time that doesn't match results in a BadRequestError
+// naming the field.
+//
+// NewRequest reads the body right away. For a client that sent "Expect:
+// 100-continue", this makes the server send "100 Continue" and the client
+// send the body, so check preconditions before, e.g. with WithPrecondition
+// in JSON and Auto.
 func NewRequest[T any](r *http.Request) Request[T] {
+	req, _ := newRequest[T](nil, r, defaultOptions)
+	return req
+}
+
+// newRequest creates a new Request from a HTTP request, like NewRequest.
+// It returns errors of the checks of the raw body enabled in opts, of
+// decoding the body, see decodeJSONRequest, of query binding, and of
+// normalization.
+func newRequest[T any](w http.ResponseWriter, r *http.Request, opts *Options) (Request[T], error) {
 	req := Request[T]{
 		Request: r,
 	}
-	_ = json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req.Data)
-	return req
+	if err := limitBody(w, r, opts); err != nil {
+		return req, err
+	}
+	if opts.StreamBody {
+		return req, nil
+	}
+	if opts.ValidateUTF8 {
+		if err := checkBodyUTF8(r); err != nil {
+			return req, err
+		}
+	}
+	if err := decodeJSONRequest(r, &req.Data, opts); err != nil {
+		return req, err
+	}
+	if err := bindQuery(r, &req); err != nil {
+		return req, err
+	}
+	return req, normalizeRequest(req.Data)
 }
 
-// Response wraps data on the response side.
+// Response wraps data on the response side. A zero StatusCode is sent as
+// 200 OK; see OK, Created, and Accepted for the common success codes.
+// Header holds additional response headers that JSON and Auto set before
+// writing the body, see e.g. CacheFor and SetCookie. They replace headers
+// of the same name set on the http.ResponseWriter, except for Set-Cookie
+// headers, which are added.
 type Response[T any] struct {
-	StatusCode int `json:"-"`
-	Data       *T  `json:"data,omitempty"`
+	StatusCode int         `json:"-"`
+	Header     http.Header `json:"-"`
+	Data       *T          `json:"data,omitempty"`
+}
+
+// CacheFor sets the Cache-Control header of the response to allow caching
+// for d, and the Expires header for HTTP/1.0 caches. It returns resp.
+func (resp *Response[T]) CacheFor(d time.Duration) *Response[T] {
+	resp.setHeader("Cache-Control", fmt.Sprintf("max-age=%d", int64(d/time.Second)))
+	resp.setHeader("Expires", time.Now().Add(d).UTC().Format(http.TimeFormat))
+	return resp
+}
+
+// ModifiedAt sets the Last-Modified header of the response to t, e.g. the
+// update time of a resource. JSON and Auto then answer GET and HEAD
+// requests whose If-Modified-Since header is not before t with 304 Not
+// Modified and no body, which saves bandwidth for polling clients. Times
+// are compared with a granularity of one second. It returns resp.
+func (resp *Response[T]) ModifiedAt(t time.Time) *Response[T] {
+	resp.setHeader("Last-Modified", t.UTC().Format(http.TimeFormat))
+	return resp
+}
+
+// writeNotModified writes a 304 Not Modified response with the header of
+// resp and reports true if r is a conditional GET or HEAD request that
+// resp is not modified for, see ModifiedAt.
+func (resp *Response[T]) writeNotModified(w http.ResponseWriter, r *http.Request) bool {
+	if resp.StatusCode != 0 && resp.StatusCode != http.StatusOK {
+		return false
+	}
+	if !notModifiedSince(r, resp.Header.Get("Last-Modified")) {
+		return false
+	}
+	if f, ok := any(resp.Data).(*FileResponse); ok && f != nil {
+		if c, ok := f.Reader.(io.Closer); ok {
+			c.Close()
+		}
+	}
+	resp.writeHeader(w)
+	h := w.Header()
+	h.Del("Content-Type")
+	h.Del("Content-Length")
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// NoStore sets the Cache-Control header of the response to forbid caching,
+// e.g. for sensitive data. It returns resp.
+func (resp *Response[T]) NoStore() *Response[T] {
+	resp.setHeader("Cache-Control", "no-store")
+	if resp.Header != nil {
+		resp.Header.Del("Expires")
+	}
+	return resp
+}
+
+// setHeader sets a header of the response.
+func (resp *Response[T]) setHeader(key, value string) {
+	if resp.Header == nil {
+		resp.Header = make(http.Header)
+	}
+	resp.Header.Set(key, value)
+}
+
+// writeHeader copies the header of the response to w, see copyHeader.
+func (resp *Response[T]) writeHeader(w http.ResponseWriter) {
+	copyHeader(w.Header(), resp.Header)
+}
+
+// SetCookie adds a Set-Cookie header for c to the response. Each call adds
+// a header, so a response can set several cookies, and cookies set on the
+// http.ResponseWriter, e.g. by middleware, are kept. Invalid cookies are
+// dropped, like with http.SetCookie. It returns resp.
+func (resp *Response[T]) SetCookie(c *http.Cookie) *Response[T] {
+	if v := c.String(); v != "" {
+		if resp.Header == nil {
+			resp.Header = make(http.Header)
+		}
+		resp.Header.Add("Set-Cookie", v)
+	}
+	return resp
+}
+
+// copyHeader copies the values of src to dst, replacing those of dst,
+// except for Set-Cookie headers, which are added, as every cookie has its
+// own header.
+func copyHeader(dst, src http.Header) {
+	for k, v := range src {
+		if k == "Set-Cookie" {
+			dst[k] = append(dst[k], v...)
+			continue
+		}
+		dst[k] = v
+	}
 }
 
 // NewResponse creates a new Response with the given data and HTTP status code
@@ -47,25 +223,132 @@ func NewResponseWithCode[T any](code int, data *T) *Response[T] {
 	return resp
 }
 
+// OK creates a new Response with the given data and HTTP status code 200.
+// It is the same as NewResponse.
+func OK[T any](data *T) *Response[T] {
+	return NewResponseWithCode(http.StatusOK, data)
+}
+
+// Created creates a new Response with the given data and HTTP status code
+// 201, e.g. for a resource created by a POST request. Set the Location
+// header of the new resource via the Header of the response.
+func Created[T any](data *T) *Response[T] {
+	return NewResponseWithCode(http.StatusCreated, data)
+}
+
+// Accepted creates a new Response with the given data and HTTP status code
+// 202, e.g. for a request that is processed asynchronously. See AcceptedAt
+// to point clients to a status endpoint.
+func Accepted[T any](data *T) *Response[T] {
+	return NewResponseWithCode(http.StatusAccepted, data)
+}
+
 // JSON handles a request and returns a http.Handler. The request body is
 // parsed and passed into the handler. The response returned from the handler
 // is being encoded to JSON as well.
 //
+// The request data is validated before the handler is called, see Validator.
+//
 // If the handler returns an error, its is mapped as a JSON struct and
 // a HTTP status code as well. Use e.g. BadRequestError to return specialized
-// errors.
-func JSON[R, W any](h Handler[R, W]) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		req := NewRequest[R](r)
-		resp, err := h(w, req)
-		if err != nil {
-			WriteJSONError(w, err)
-			return
-		}
-		if resp.Data != nil {
-			WriteJSONCode(w, resp.StatusCode, resp.Data)
-		}
-	})
+// errors. The error message is translated to the language preferred by the
+// Accept-Language header, see RegisterTranslation.
+//
+// The options apply on top of DefaultOptions, or of the options of the
+// Router the handler is registered with, see NewRouter.
+func JSON[R, W any](h Handler[R, W], opts ...Option) http.Handler {
+	return jsonHandler[R, W]{h: h, own: opts, opts: newOptions(*defaultOptions, opts)}
+}
+
+// jsonHandler is the http.Handler returned by JSON.
+type jsonHandler[R, W any] struct {
+	h    Handler[R, W]
+	own  []Option
+	opts *Options
+}
+
+// withOptions returns the handler with its own options applied on top of
+// base.
+func (jh jsonHandler[R, W]) withOptions(base Options) http.Handler {
+	jh.opts = newOptions(base, jh.own)
+	return jh
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (jh jsonHandler[R, W]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r = withAttributes(r)
+	if err := checkRequestLimits(r, jh.opts); err != nil {
+		writeJSONError(w, r, err)
+		return
+	}
+	if err := checkAccept[W](r, jsonOffers, jh.opts); err != nil {
+		writeJSONError(w, r, err)
+		return
+	}
+	if err := checkPrecondition(r, jh.opts); err != nil {
+		writeJSONError(w, r, err)
+		return
+	}
+	settings, sr := encoderSettings(r, jh.opts)
+	policy := settings.namingPolicy(jh.opts.NamingPolicy)
+	fields, err := responseFields[W](r, jh.opts, policy)
+	if err != nil {
+		writeJSONError(w, r, err)
+		return
+	}
+	req, err := newRequest[R](w, r, jh.opts)
+	if err == nil {
+		err = validateRequest(req.Data, jh.opts)
+	}
+	if err != nil {
+		writeJSONError(w, r, err)
+		return
+	}
+	resp, err := jh.h(w, req)
+	if err != nil {
+		writeJSONError(w, r, devErrorFor(bodyError(err), nil, jh.opts))
+		return
+	}
+	if resp == nil {
+		// No response at all is rendered like one without data.
+		resp = &Response[W]{}
+	}
+	writeWarnings(w, r)
+	if resp.writeNotModified(w, r) {
+		return
+	}
+	if resp.Data == nil {
+		writeEmpty(w, resp, jh.opts.EmptyResponse, mediaTypeJSON)
+		return
+	}
+	if jh.opts.EmptyCollections {
+		fillEmptyCollections(resp.Data)
+	}
+	data, err := selectResponseFields(applyNaming(resp.Data, policy), fields)
+	if err != nil {
+		writeJSONError(w, r, err)
+		return
+	}
+	resp.writeHeader(w)
+	writeJSONResponse(w, sr, resp.StatusCode, data)
+}
+
+// dataTypes returns the types of the request and response data.
+func (jsonHandler[R, W]) dataTypes() (reflect.Type, reflect.Type) {
+	return reflect.TypeOf((*R)(nil)).Elem(), reflect.TypeOf((*W)(nil)).Elem()
+}
+
+// typedHandler is implemented by handlers that know the types of their
+// request and response data, e.g. the handlers returned by JSON.
+type typedHandler interface {
+	dataTypes() (req, resp reflect.Type)
+}
+
+// configurableHandler is implemented by handlers that take Options, i.e.
+// the handlers returned by JSON and Auto, so that a Router can apply its
+// options.
+type configurableHandler interface {
+	withOptions(base Options) http.Handler
 }
 
 // WriteJSON renders JSON to the HTTP response body with HTTP status code 200.
@@ -74,14 +357,30 @@ func WriteJSON(w http.ResponseWriter, data any) {
 }
 
 // WriteJSONCode renders JSON to the HTTP response body with the given
-// HTTP status code.
+// HTTP status code. If the client disconnects while writing, the rest of
+// the body is discarded, see OnClientAbort.
+//
+// The response has Content-Type application/json and the status code, or
+// 200 if code is 0. The body is the encoding of data by Encoder, followed
+// by a newline, and decodes back into a value of the same type. It is
+// streamed without a Content-Length. If data cannot be encoded, e.g. for
+// a float that is NaN, the status code is sent with an empty body and the
+// error is logged. Registered post-processors apply before encoding, see
+// RegisterPostProcessor.
 func WriteJSONCode(w http.ResponseWriter, code int, data any) {
+	writeJSONCode(context.Background(), w, code, data)
+}
+
+// writeJSONCode renders JSON like WriteJSONCode, with the registered
+// post-processors applied in the given context.
+func writeJSONCode(ctx context.Context, w http.ResponseWriter, code int, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	if code == 0 {
 		code = http.StatusOK
 	}
+	code, data = postProcess(ctx, code, data)
 	w.WriteHeader(code)
-	_ = json.NewEncoder(w).Encode(data)
+	handleWriteError(encodeJSONContext(ctx, w, data))
 }
 
 // WriteJSONError renders the error as JSON. If the err has a HTTPCode() int
@@ -89,22 +388,98 @@ func WriteJSONCode(w http.ResponseWriter, code int, data any) {
 // HTTPError() string function, is it being used for the error message.
 // Use specialized errors like BadRequestError to automatically do the right
 // thing.
+//
+// An error that aggregates others with an Unwrap() []error function, e.g.
+// from errors.Join, is rendered with a list of the errors, each with its
+// status code and message, e.g.:
+//
+//	{
+//	  "message": "Invalid name",
+//	  "errors": [
+//	    {"status": 400, "message": "Invalid name"},
+//	    {"status": 400, "message": "Invalid email"}
+//	  ]
+//	}
+//
+// The status code and message of the response are those of the first
+// error with the highest status code, so a server error outranks a client
+// error. Nested aggregates are flattened.
+//
+// As error bodies are small, they are buffered and sent with a
+// Content-Length header instead of being streamed.
 func WriteJSONError(w http.ResponseWriter, err error) {
-	msg := struct {
-		Message string `json:"message"`
-	}{
-		Message: "Internal server error",
+	writeJSONError(w, nil, err)
+}
+
+// writeJSONError renders the error as JSON like WriteJSONError. If r is
+// not nil, the message is translated to the language preferred by r,
+// see RegisterTranslation.
+func writeJSONError(w http.ResponseWriter, r *http.Request, err error) {
+	if h, ok := asHalt(err); ok {
+		copyHeader(w.Header(), h.header)
+		if h.data != nil {
+			writeJSONResponse(w, r, h.code, h.data)
+		} else if h.code != 0 {
+			w.WriteHeader(h.code)
+		}
+		return
 	}
-	w.Header().Set("Content-Type", "application/json")
+	logError(r, errorCode(err), err)
+	msg := newErrorBody(r, err)
+	body, merr := Marshal(msg)
+	if merr != nil {
+		WriteJSONCode(w, errorCode(err), msg)
+		return
+	}
+	writeBody(w, errorCode(err), "application/json", append(body, '\n'))
+}
+
+// writeBody writes a buffered body with the given HTTP status code,
+// Content-Type, and Content-Length.
+func writeBody(w http.ResponseWriter, code int, contentType string, body []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(code)
+	_, err := w.Write(body)
+	handleWriteError(err)
+}
+
+// errorBody is the body rendered for errors.
+type errorBody struct {
+	XMLName xml.Name   `json:"-" xml:"error"`
+	Message string     `json:"message" xml:"message"`
+	Errors  errorItems `json:"errors,omitempty" xml:"errors"`
+	Fields  []string   `json:"fields,omitempty" xml:"field,omitempty"`
+	Error   string     `json:"error,omitempty" xml:"detail,omitempty"`
+	Stack   []string   `json:"stack,omitempty" xml:"stack,omitempty"`
+}
+
+// errorCode returns the HTTP status code for err. If the err has a
+// HTTPCode() int function, it is being used. If it aggregates errors, see
+// WriteJSONError, it is the highest status code of those. Otherwise it
+// is 500.
+func errorCode(err error) int {
 	if intf, ok := err.(interface{ HTTPCode() int }); ok {
-		w.WriteHeader(intf.HTTPCode())
-	} else {
-		w.WriteHeader(http.StatusInternalServerError)
+		return intf.HTTPCode()
+	}
+	if errs := joinedErrors(err); len(errs) > 0 {
+		return errorCode(primaryError(errs))
 	}
+	return http.StatusInternalServerError
+}
+
+// errorMessage returns the client-facing message for err. If the err has
+// a HTTPError() string function, it is being used. If it aggregates
+// errors, it is the message of the first error with the highest status
+// code. Otherwise it is a generic message that doesn't leak any details.
+func errorMessage(err error) string {
 	if intf, ok := err.(interface{ HTTPError() string }); ok {
-		msg.Message = intf.HTTPError()
+		return intf.HTTPError()
+	}
+	if errs := joinedErrors(err); len(errs) > 0 {
+		return errorMessage(primaryError(errs))
 	}
-	_ = json.NewEncoder(w).Encode(msg)
+	return "Internal server error"
 }
 
 // BadRequestError represents a HTTP Bad Request error (status code 400).
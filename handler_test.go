@@ -0,0 +1,279 @@
+package generichttp
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+type roundTripItem struct {
+	ID    int64   `json:"id"`
+	Label string  `json:"label"`
+	Score float64 `json:"score"`
+}
+
+type roundTripValue struct {
+	Name    string            `json:"name"`
+	Count   int               `json:"count"`
+	Small   int8              `json:"small"`
+	Big     uint64            `json:"big"`
+	Ratio   float32           `json:"ratio"`
+	Active  bool              `json:"active"`
+	Tags    []string          `json:"tags"`
+	Attrs   map[string]string `json:"attrs"`
+	Items   []roundTripItem   `json:"items"`
+	Parent  *roundTripItem    `json:"parent"`
+	Skipped string            `json:"-"`
+	Omitted string            `json:"omitted,omitempty"`
+}
+
+// roundTripCodes are the status codes used by TestWriteJSONCodeRoundTrip.
+var roundTripCodes = []int{0, http.StatusOK, http.StatusCreated, http.StatusAccepted,
+	http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError}
+
+func TestWriteJSONCodeRoundTrip(t *testing.T) {
+	check := func(v roundTripValue, c uint8) bool {
+		v.Skipped = ""
+		code := roundTripCodes[int(c)%len(roundTripCodes)]
+		w := httptest.NewRecorder()
+		WriteJSONCode(w, code, v)
+
+		wantCode := code
+		if wantCode == 0 {
+			wantCode = http.StatusOK
+		}
+		if w.Code != wantCode {
+			t.Logf("status = %d, want %d", w.Code, wantCode)
+			return false
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Logf("Content-Type = %q, want application/json", ct)
+			return false
+		}
+		if cl := w.Header().Get("Content-Length"); cl != "" {
+			t.Logf("Content-Length = %q, want none", cl)
+			return false
+		}
+		if !strings.HasSuffix(w.Body.String(), "}\n") {
+			t.Logf("body = %q, want a trailing newline", w.Body)
+			return false
+		}
+		var got roundTripValue
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Logf("decode: %v", err)
+			return false
+		}
+		if !reflect.DeepEqual(got, v) {
+			t.Logf("got  %+v\nwant %+v", got, v)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(check, &quick.Config{MaxCount: 500}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteJSONCodeUnencodable(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteJSONCode(w, http.StatusCreated, map[string]float64{"x": math.NaN()})
+	if w.Code != http.StatusCreated {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusCreated)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("body = %q, want empty", w.Body)
+	}
+}
+
+func TestResponseCacheControl(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+	}
+	tests := []struct {
+		name         string
+		resp         func() *Response[item]
+		cacheControl string
+		expires      bool
+	}{
+		{"cache for", func() *Response[item] { return OK(&item{"a"}).CacheFor(90 * time.Minute) }, "max-age=5400", true},
+		{"no store", func() *Response[item] { return OK(&item{"a"}).NoStore() }, "no-store", false},
+		{"no store overrides cache for", func() *Response[item] { return OK(&item{"a"}).CacheFor(time.Hour).NoStore() }, "no-store", false},
+		{"cache for overrides no store", func() *Response[item] { return OK(&item{"a"}).NoStore().CacheFor(time.Minute) }, "max-age=60", true},
+	}
+	for _, tt := range tests {
+		h := func(w http.ResponseWriter, req Request[struct{}]) (*Response[item], error) {
+			w.Header().Set("Cache-Control", "private")
+			return tt.resp(), nil
+		}
+		for kind, handler := range map[string]http.Handler{"JSON": JSON(h), "Auto": Auto(h)} {
+			t.Run(tt.name+"/"+kind, func(t *testing.T) {
+				start := time.Now().UTC().Truncate(time.Second)
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+				if w.Code != http.StatusOK {
+					t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+				}
+				if got := w.Header().Values("Cache-Control"); len(got) != 1 || got[0] != tt.cacheControl {
+					t.Errorf("Cache-Control = %q, want %q", got, tt.cacheControl)
+				}
+				expires := w.Header().Get("Expires")
+				if !tt.expires {
+					if expires != "" {
+						t.Errorf("Expires = %q, want none", expires)
+					}
+					return
+				}
+				at, err := http.ParseTime(expires)
+				if err != nil {
+					t.Fatalf("Expires = %q: %v", expires, err)
+				}
+				maxAge, _ := strconv.Atoi(strings.TrimPrefix(tt.cacheControl, "max-age="))
+				if want := start.Add(time.Duration(maxAge) * time.Second); at.Before(want) || at.After(want.Add(2*time.Second)) {
+					t.Errorf("Expires = %v, want about %v", at, want)
+				}
+			})
+		}
+	}
+}
+
+func TestResponseStatusHelpers(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+	}
+	data := &item{Name: "Widget"}
+	tests := []struct {
+		name string
+		resp *Response[item]
+		code int
+	}{
+		{"OK", OK(data), http.StatusOK},
+		{"Created", Created(data), http.StatusCreated},
+		{"Accepted", Accepted(data), http.StatusAccepted},
+		{"NewResponse", NewResponse(data), http.StatusOK},
+		{"NewResponseWithCode", NewResponseWithCode(http.StatusNonAuthoritativeInfo, data), http.StatusNonAuthoritativeInfo},
+		{"zero StatusCode", &Response[item]{Data: data}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if tt.resp.Data != data {
+			t.Errorf("%s: Data = %v, want %v", tt.name, tt.resp.Data, data)
+		}
+		h := func(w http.ResponseWriter, req Request[struct{}]) (*Response[item], error) {
+			return tt.resp, nil
+		}
+		for kind, handler := range map[string]http.Handler{"JSON": JSON(h), "Auto": Auto(h)} {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+			if w.Code != tt.code {
+				t.Errorf("%s/%s: status = %d, want %d", tt.name, kind, w.Code, tt.code)
+			}
+			if got, want := w.Body.String(), `{"name":"Widget"}`+"\n"; got != want {
+				t.Errorf("%s/%s: body = %q, want %q", tt.name, kind, got, want)
+			}
+		}
+	}
+}
+
+// Context keys of TestRequestContext.
+type (
+	contextTestKey struct{}
+	traceTestKey   struct{}
+)
+
+func TestRequestContext(t *testing.T) {
+	type greeting struct {
+		Name string `json:"name"`
+	}
+	type reply struct {
+		Name   string `json:"name"`
+		Tenant string `json:"tenant"`
+		Trace  string `json:"trace"`
+	}
+	handler := func(w http.ResponseWriter, req Request[greeting]) (*Response[reply], error) {
+		tenant, _ := req.Context().Value(contextTestKey{}).(string)
+		trace, _ := req.Context().Value(traceTestKey{}).(string)
+		return OK(&reply{Name: req.Data.Name, Tenant: tenant, Trace: trace}), nil
+	}
+	// addTrace adds a value to the context of the typed request.
+	addTrace := func(next Handler[greeting, reply]) Handler[greeting, reply] {
+		return func(w http.ResponseWriter, req Request[greeting]) (*Response[reply], error) {
+			return next(w, req.WithContext(context.WithValue(req.Context(), traceTestKey{}, "t-1")))
+		}
+	}
+	// setTenant is a http middleware preceding JSON and Auto.
+	setTenant := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextTestKey{}, "acme")))
+		})
+	}
+
+	h := Wrap(handler, addTrace)
+	for kind, hh := range map[string]http.Handler{"JSON": JSON(h), "Auto": Auto(h)} {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"Ada"}`))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		setTenant(hh).ServeHTTP(w, r)
+		if got, want := w.Body.String(), `{"name":"Ada","tenant":"acme","trace":"t-1"}`+"\n"; got != want {
+			t.Errorf("%s: body = %q, want %q", kind, got, want)
+		}
+	}
+
+	req := Request[greeting]{Request: httptest.NewRequest(http.MethodGet, "/", nil), Data: &greeting{Name: "Ada"}}
+	ctx := context.WithValue(context.Background(), contextTestKey{}, "acme")
+	req2 := req.WithContext(ctx)
+	if req2.Data != req.Data || req2.Context() != ctx || req.Context() == ctx {
+		t.Errorf("WithContext must keep Data and change only the context of the copy")
+	}
+}
+
+func TestResponseSetCookie(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+	}
+	resp := func() *Response[item] {
+		return OK(&item{"a"}).
+			SetCookie(&http.Cookie{Name: "session", Value: "s1", Path: "/", HttpOnly: true}).
+			SetCookie(&http.Cookie{Name: "theme", Value: "dark"}).
+			SetCookie(&http.Cookie{Name: "in valid", Value: "x"})
+	}
+	// setCSRF is a middleware that sets a cookie before the handler.
+	setCSRF := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.SetCookie(w, &http.Cookie{Name: "csrf", Value: "c1"})
+			next.ServeHTTP(w, r)
+		})
+	}
+	want := []string{"csrf=c1", "lang=en", "session=s1; Path=/; HttpOnly", "theme=dark"}
+
+	h := func(w http.ResponseWriter, req Request[struct{}]) (*Response[item], error) {
+		http.SetCookie(w, &http.Cookie{Name: "lang", Value: "en"})
+		return resp(), nil
+	}
+	halt := func(w http.ResponseWriter, req Request[struct{}]) (*Response[struct{}], error) {
+		http.SetCookie(w, &http.Cookie{Name: "lang", Value: "en"})
+		return nil, Halt(resp())
+	}
+	for kind, handler := range map[string]http.Handler{
+		"JSON":         JSON(h),
+		"Auto":         Auto(h),
+		"Halt":         JSON(halt),
+		"JSON/Timeout": Timeout(time.Minute)(JSON(h)),
+		"Auto/Timeout": Timeout(time.Minute)(Auto(h)),
+	} {
+		w := httptest.NewRecorder()
+		setCSRF(handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: status = %d, want %d", kind, w.Code, http.StatusOK)
+		}
+		if got := w.Header().Values("Set-Cookie"); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: Set-Cookie = %q, want %q", kind, got, want)
+		}
+	}
+}
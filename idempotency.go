@@ -0,0 +1,131 @@
+package generichttp
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// IdempotencyOptions configures the Idempotency middleware.
+type IdempotencyOptions struct {
+	// Store stores the responses. It defaults to a store in memory, see
+	// NewMemoryResponseStore, which is not shared between instances of a
+	// service. It may be shared with the Cache middleware, as the keys
+	// don't collide.
+	Store ResponseStore
+	// Header is the name of the request header with the key. It defaults
+	// to "Idempotency-Key".
+	Header string
+	// TTL is how long a response is stored. It defaults to 24 hours.
+	TTL time.Duration
+	// Methods are the methods the middleware applies to. They default to
+	// POST and PATCH.
+	Methods []string
+	// Required rejects requests without a key with a BadRequestError.
+	Required bool
+}
+
+// Idempotency returns a middleware that makes unsafe requests retriable.
+// A request with an Idempotency-Key header is handled once; repeating it
+// with the same key within the TTL returns the stored response of the first
+// request, with an Idempotent-Replayed: true header. While the first
+// request is in flight, repeated requests get a ConflictError.
+//
+// Keys are scoped by method and path. Responses with a 5xx status code are
+// not stored, so that the request can be retried.
+func Idempotency(opts IdempotencyOptions) Middleware {
+	if opts.Store == nil {
+		opts.Store = NewMemoryResponseStore()
+	}
+	if opts.Header == "" {
+		opts.Header = "Idempotency-Key"
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = 24 * time.Hour
+	}
+	if len(opts.Methods) == 0 {
+		opts.Methods = []string{http.MethodPost, http.MethodPatch}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !containsMethod(opts.Methods, r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key := strings.TrimSpace(r.Header.Get(opts.Header))
+			if key == "" {
+				if opts.Required {
+					writeJSONError(w, r, BadRequestError{Message: "Missing " + opts.Header + " header"})
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			key = r.Method + " " + r.URL.Path + " " + key
+
+			// An entry without a status code reserves the key while the
+			// first request is in flight.
+			reserved, err := opts.Store.Add(r.Context(), key, &StoredResponse{}, opts.TTL)
+			if err != nil {
+				writeJSONError(w, r, err)
+				return
+			}
+			if !reserved {
+				stored, err := opts.Store.Get(r.Context(), key)
+				if err != nil {
+					writeJSONError(w, r, err)
+					return
+				}
+				if stored == nil || stored.StatusCode == 0 {
+					writeJSONError(w, r, ConflictError{Message: "A request with this idempotency key is in progress"})
+					return
+				}
+				for k, v := range stored.Header {
+					w.Header()[k] = v
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(stored.StatusCode)
+				_, _ = w.Write(stored.Body)
+				return
+			}
+
+			var body bytes.Buffer
+			tw := NewTeeResponseWriter(w, &body)
+			completed := false
+			defer func() {
+				// Release the key if the handler panics or fails.
+				if !completed {
+					_ = opts.Store.Delete(context.WithoutCancel(r.Context()), key)
+				}
+			}()
+			next.ServeHTTP(tw, r)
+			status, header := tw.Status(), tw.WrittenHeader()
+			if status == 0 {
+				status, header = http.StatusOK, w.Header().Clone()
+			}
+			if status >= http.StatusInternalServerError {
+				return
+			}
+			resp := &StoredResponse{
+				StatusCode: status,
+				Header:     header,
+				Body:       body.Bytes(),
+			}
+			if err := opts.Store.Set(context.WithoutCancel(r.Context()), key, resp, opts.TTL); err == nil {
+				completed = true
+			}
+		})
+	}
+}
+
+// containsMethod reports whether methods contains method.
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package generichttp
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// JobState is the state of an asynchronous job, see JobStatus.
+type JobState string
+
+// States of asynchronous jobs.
+const (
+	JobPending   JobState = "pending"
+	JobRunning   JobState = "running"
+	JobSucceeded JobState = "succeeded"
+	JobFailed    JobState = "failed"
+)
+
+// JobStatus describes an asynchronous job, e.g. as the data of the
+// response that accepted it, see AcceptedAt, and of its status endpoint,
+// see JobStatusResponse.
+type JobStatus struct {
+	ID    string   `json:"id"`
+	State JobState `json:"state" enum:"pending,running,succeeded,failed"`
+	// Progress is the progress of a running job from 0 to 1, if known.
+	Progress *float64 `json:"progress,omitempty"`
+	// Error describes why a failed job failed.
+	Error string `json:"error,omitempty"`
+	// ResultURL is the URL of the result of a succeeded job, if any.
+	ResultURL string `json:"resultUrl,omitempty"`
+	// RetryAfter is the time clients should wait before polling the
+	// status of a pending or running job again. It is sent in the
+	// Retry-After header.
+	RetryAfter time.Duration `json:"-"`
+}
+
+// AcceptedAt creates a new Response with the given data and HTTP status
+// code 202 for a request that is processed asynchronously. The Location
+// and Content-Location headers are set to location, the URL of an
+// endpoint with the status of the job, e.g.:
+//
+//	job := &generichttp.JobStatus{ID: id, State: generichttp.JobPending}
+//	return generichttp.AcceptedAt("/jobs/"+id, job), nil
+//
+// data may describe the job, e.g. as a JobStatus, or be nil.
+func AcceptedAt[T any](location string, data *T) *Response[T] {
+	resp := Accepted(data)
+	resp.setHeader("Location", location)
+	resp.setHeader("Content-Location", location)
+	return resp
+}
+
+// JobStatusResponse creates the response of the status endpoint of an
+// asynchronous job, see AcceptedAt. A succeeded job with a ResultURL is
+// answered with 303 See Other and a Location header pointing to the
+// result, which most HTTP clients follow. Other jobs are answered with
+// 200 OK and, if pending or running, a Retry-After header if RetryAfter
+// is set.
+func JobStatusResponse(status *JobStatus) *Response[JobStatus] {
+	switch {
+	case status.State == JobSucceeded && status.ResultURL != "":
+		resp := NewResponseWithCode(http.StatusSeeOther, status)
+		resp.setHeader("Location", status.ResultURL)
+		return resp
+	case (status.State == JobPending || status.State == JobRunning) && status.RetryAfter > 0:
+		resp := OK(status)
+		resp.setHeader("Retry-After", strconv.Itoa(int(math.Ceil(status.RetryAfter.Seconds()))))
+		return resp
+	}
+	return OK(status)
+}
@@ -0,0 +1,96 @@
+package generichttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/olivere/generichttp"
+	"github.com/olivere/generichttp/testutil"
+)
+
+type jobRequest struct {
+	Report string `json:"report"`
+}
+
+func TestAcceptedAt(t *testing.T) {
+	h := generichttp.JSON(func(w http.ResponseWriter, req generichttp.Request[jobRequest]) (*generichttp.Response[generichttp.JobStatus], error) {
+		return generichttp.AcceptedAt("/jobs/42", &generichttp.JobStatus{ID: "42", State: generichttp.JobPending}), nil
+	})
+	r := httptest.NewRequest(http.MethodPost, "/reports", strings.NewReader(`{"report":"sales"}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusAccepted, w.Body)
+	}
+	for _, name := range []string{"Location", "Content-Location"} {
+		if got := w.Header().Get(name); got != "/jobs/42" {
+			t.Errorf("%s = %q, want /jobs/42", name, got)
+		}
+	}
+	if got := w.Header().Get("Retry-After"); got != "" {
+		t.Errorf("Retry-After = %q, want none", got)
+	}
+	testutil.AssertJSON(t, w, map[string]any{"id": "42", "state": "pending"})
+}
+
+func TestAcceptedAtWithoutData(t *testing.T) {
+	resp := generichttp.AcceptedAt[struct{}]("/jobs/42", nil)
+	if resp.StatusCode != http.StatusAccepted || resp.Data != nil {
+		t.Errorf("status = %d, data = %v; want %d without data", resp.StatusCode, resp.Data, http.StatusAccepted)
+	}
+	if got := resp.Header.Get("Location"); got != "/jobs/42" {
+		t.Errorf("Location = %q, want /jobs/42", got)
+	}
+}
+
+func TestJobStatusResponse(t *testing.T) {
+	progress := 0.5
+	tests := []struct {
+		name       string
+		status     generichttp.JobStatus
+		code       int
+		location   string
+		retryAfter string
+	}{
+		{"pending", generichttp.JobStatus{ID: "1", State: generichttp.JobPending, RetryAfter: 1500 * time.Millisecond}, http.StatusOK, "", "2"},
+		{"running", generichttp.JobStatus{ID: "1", State: generichttp.JobRunning, Progress: &progress, RetryAfter: 5 * time.Second}, http.StatusOK, "", "5"},
+		{"running without retry", generichttp.JobStatus{ID: "1", State: generichttp.JobRunning}, http.StatusOK, "", ""},
+		{"succeeded", generichttp.JobStatus{ID: "1", State: generichttp.JobSucceeded, ResultURL: "/reports/1", RetryAfter: time.Second}, http.StatusSeeOther, "/reports/1", ""},
+		{"succeeded without result", generichttp.JobStatus{ID: "1", State: generichttp.JobSucceeded}, http.StatusOK, "", ""},
+		{"failed", generichttp.JobStatus{ID: "1", State: generichttp.JobFailed, Error: "Out of paper", RetryAfter: time.Second}, http.StatusOK, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := generichttp.JSON(func(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[generichttp.JobStatus], error) {
+				return generichttp.JobStatusResponse(&tt.status), nil
+			})
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs/1", nil))
+			if w.Code != tt.code {
+				t.Errorf("status = %d, want %d", w.Code, tt.code)
+			}
+			if got := w.Header().Get("Location"); got != tt.location {
+				t.Errorf("Location = %q, want %q", got, tt.location)
+			}
+			if got := w.Header().Get("Retry-After"); got != tt.retryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.retryAfter)
+			}
+			want := map[string]any{"id": "1", "state": string(tt.status.State)}
+			if tt.status.Progress != nil {
+				want["progress"] = *tt.status.Progress
+			}
+			if tt.status.Error != "" {
+				want["error"] = tt.status.Error
+			}
+			if tt.status.ResultURL != "" {
+				want["resultUrl"] = tt.status.ResultURL
+			}
+			testutil.AssertJSON(t, w, want)
+		})
+	}
+}
@@ -0,0 +1,238 @@
+// Package jsonrpc implements JSON-RPC 2.0 over HTTP on top of generichttp.
+//
+// Methods are typed generichttp.Handler functions registered by name:
+//
+//	s := jsonrpc.NewServer()
+//	jsonrpc.Register(s, "add", func(w http.ResponseWriter, r generichttp.Request[AddParams]) (*generichttp.Response[AddResult], error) {
+//		return generichttp.NewResponse(&AddResult{Sum: r.Data.A + r.Data.B}), nil
+//	})
+//	router.Post("/rpc", s)
+//
+// The params of a call are decoded into the request data of the handler,
+// and the response data becomes the result. Batches and notifications are
+// supported as described in the specification at
+// https://www.jsonrpc.org/specification.
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/olivere/generichttp"
+)
+
+// Standard JSON-RPC 2.0 error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeServerError    = -32000
+)
+
+// maxBodySize is the maximum size of a request body.
+const maxBodySize = 1 << 20
+
+// Error is a JSON-RPC error object. Return it from a method to control the
+// error code and data sent to the client.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string { return e.Message }
+
+// request is a single JSON-RPC request.
+type request struct {
+	Version string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+// response is a single JSON-RPC response.
+type response struct {
+	Version string          `json:"jsonrpc"`
+	Result  any             `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// method calls a registered method with the raw params. It returns the
+// result data.
+type method func(w http.ResponseWriter, r *http.Request, params json.RawMessage) (any, error)
+
+// Server is a http.Handler that serves JSON-RPC 2.0 requests.
+type Server struct {
+	mu      sync.RWMutex
+	methods map[string]method
+}
+
+// NewServer returns a new Server without methods.
+func NewServer() *Server {
+	return &Server{methods: make(map[string]method)}
+}
+
+// Register registers h as the method with the given name. The params of a
+// call are decoded into R and validated if R implements
+// generichttp.Validator. The response data of h is the result of the call.
+//
+// Errors returned by h are mapped to JSON-RPC errors: an *Error is sent
+// as is, a generichttp.BadRequestError results in CodeInvalidParams, and
+// other errors with a HTTPCode and HTTPError method, e.g. a
+// generichttp.NotFoundError, result in CodeServerError with their message
+// and the HTTP status code as data. Any other error results in
+// CodeInternalError without leaking details.
+func Register[R, W any](s *Server, name string, h generichttp.Handler[R, W]) {
+	m := func(w http.ResponseWriter, r *http.Request, params json.RawMessage) (any, error) {
+		req := generichttp.Request[R]{Request: r}
+		if len(params) > 0 && !bytes.Equal(params, []byte("null")) {
+			data := new(R)
+			if err := generichttp.Unmarshal(params, data); err != nil {
+				return nil, &Error{Code: CodeInvalidParams, Message: "Invalid params"}
+			}
+			req.Data = data
+		}
+		if v, ok := any(req.Data).(generichttp.Validator); ok && req.Data != nil {
+			if err := v.Validate(); err != nil {
+				return nil, err
+			}
+		}
+		resp, err := h(w, req)
+		if err != nil {
+			return nil, err
+		}
+		if resp == nil || resp.Data == nil {
+			return nil, nil
+		}
+		return resp.Data, nil
+	}
+	s.mu.Lock()
+	s.methods[name] = m
+	s.mu.Unlock()
+}
+
+// ServeHTTP implements the http.Handler interface. Only POST requests are
+// accepted. Requests that consist of notifications only are answered with
+// 204 No Content.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		generichttp.WriteJSONError(w, generichttp.MethodNotAllowedError{})
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		generichttp.WriteJSONError(w, generichttp.BadRequestError{Message: "Unable to read request body"})
+		return
+	}
+	body = bytes.TrimSpace(body)
+
+	if len(body) > 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			generichttp.WriteJSON(w, errorResponse(nil, CodeParseError, "Parse error"))
+			return
+		}
+		if len(batch) == 0 {
+			generichttp.WriteJSON(w, errorResponse(nil, CodeInvalidRequest, "Invalid Request"))
+			return
+		}
+		var responses []*response
+		for _, raw := range batch {
+			if resp := s.call(w, r, raw); resp != nil {
+				responses = append(responses, resp)
+			}
+		}
+		if len(responses) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		generichttp.WriteJSON(w, responses)
+		return
+	}
+
+	if !json.Valid(body) {
+		generichttp.WriteJSON(w, errorResponse(nil, CodeParseError, "Parse error"))
+		return
+	}
+	resp := s.call(w, r, body)
+	if resp == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	generichttp.WriteJSON(w, resp)
+}
+
+// call handles a single request. It returns nil for notifications.
+func (s *Server) call(w http.ResponseWriter, r *http.Request, raw json.RawMessage) *response {
+	var req request
+	if err := json.Unmarshal(raw, &req); err != nil || req.Version != "2.0" || req.Method == "" || !validID(req.ID) {
+		return errorResponse(req.ID, CodeInvalidRequest, "Invalid Request")
+	}
+	s.mu.RLock()
+	m, found := s.methods[req.Method]
+	s.mu.RUnlock()
+
+	var resp *response
+	if !found {
+		resp = errorResponse(req.ID, CodeMethodNotFound, "Method not found")
+	} else if result, err := m(w, r, req.Params); err != nil {
+		resp = &response{Version: "2.0", Error: toError(err), ID: req.ID}
+	} else {
+		if result == nil {
+			result = json.RawMessage("null")
+		}
+		resp = &response{Version: "2.0", Result: result, ID: req.ID}
+	}
+	if req.ID == nil {
+		return nil // notification
+	}
+	return resp
+}
+
+// errorResponse returns a response with the given error.
+func errorResponse(id json.RawMessage, code int, message string) *response {
+	if id == nil || !validID(id) {
+		id = json.RawMessage("null")
+	}
+	return &response{Version: "2.0", Error: &Error{Code: code, Message: message}, ID: id}
+}
+
+// validID reports whether id is absent, a string, a number, or null.
+func validID(id json.RawMessage) bool {
+	if id == nil {
+		return true
+	}
+	switch id[0] {
+	case '"', 'n', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return true
+	}
+	return false
+}
+
+// toError maps an error returned by a method to a JSON-RPC error.
+func toError(err error) *Error {
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
+	var badRequest generichttp.BadRequestError
+	if errors.As(err, &badRequest) {
+		return &Error{Code: CodeInvalidParams, Message: badRequest.HTTPError()}
+	}
+	if intf, ok := err.(interface {
+		HTTPCode() int
+		HTTPError() string
+	}); ok {
+		return &Error{Code: CodeServerError, Message: intf.HTTPError(), Data: map[string]int{"status": intf.HTTPCode()}}
+	}
+	return &Error{Code: CodeInternalError, Message: "Internal error"}
+}
@@ -0,0 +1,128 @@
+package jsonrpc_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/olivere/generichttp"
+	"github.com/olivere/generichttp/jsonrpc"
+	"github.com/olivere/generichttp/testutil"
+)
+
+type addParams struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+type addResult struct {
+	Sum int `json:"sum"`
+}
+
+// newServer returns a server with an "add" method and a "log" method
+// that records the messages it is called with.
+func newServer(logged *[]string) *jsonrpc.Server {
+	s := jsonrpc.NewServer()
+	jsonrpc.Register(s, "add", func(w http.ResponseWriter, r generichttp.Request[addParams]) (*generichttp.Response[addResult], error) {
+		return generichttp.NewResponse(&addResult{Sum: r.Data.A + r.Data.B}), nil
+	})
+	jsonrpc.Register(s, "log", func(w http.ResponseWriter, r generichttp.Request[string]) (*generichttp.Response[struct{}], error) {
+		*logged = append(*logged, *r.Data)
+		return nil, nil
+	})
+	return s
+}
+
+func post(h http.Handler, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestCall(t *testing.T) {
+	var logged []string
+	w := post(newServer(&logged), `{"jsonrpc":"2.0","method":"add","params":{"a":1,"b":2},"id":1}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	testutil.AssertJSON(t, w, map[string]any{"jsonrpc": "2.0", "result": map[string]any{"sum": 3}, "id": 1})
+}
+
+func TestNotification(t *testing.T) {
+	var logged []string
+	w := post(newServer(&logged), `{"jsonrpc":"2.0","method":"log","params":"hello"}`)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("body = %q, want none", w.Body)
+	}
+	if len(logged) != 1 || logged[0] != "hello" {
+		t.Errorf("logged = %q, want [hello]", logged)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	var logged []string
+	w := post(newServer(&logged), `[
+		{"jsonrpc":"2.0","method":"add","params":{"a":1,"b":2},"id":"a"},
+		{"jsonrpc":"2.0","method":"log","params":"in between"},
+		{"jsonrpc":"2.0","method":"sub","params":{"a":1,"b":2},"id":"b"},
+		{"jsonrpc":"2.0","method":"add","params":[1,2],"id":"c"},
+		{"foo":"bar"},
+		1
+	]`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	testutil.AssertJSON(t, w, []any{
+		map[string]any{"jsonrpc": "2.0", "result": map[string]any{"sum": 3}, "id": "a"},
+		map[string]any{"jsonrpc": "2.0", "error": map[string]any{"code": jsonrpc.CodeMethodNotFound, "message": "Method not found"}, "id": "b"},
+		map[string]any{"jsonrpc": "2.0", "error": map[string]any{"code": jsonrpc.CodeInvalidParams, "message": "Invalid params"}, "id": "c"},
+		map[string]any{"jsonrpc": "2.0", "error": map[string]any{"code": jsonrpc.CodeInvalidRequest, "message": "Invalid Request"}, "id": nil},
+		map[string]any{"jsonrpc": "2.0", "error": map[string]any{"code": jsonrpc.CodeInvalidRequest, "message": "Invalid Request"}, "id": nil},
+	})
+	if len(logged) != 1 || logged[0] != "in between" {
+		t.Errorf("logged = %q, want [in between]", logged)
+	}
+}
+
+func TestBatchOfNotifications(t *testing.T) {
+	var logged []string
+	w := post(newServer(&logged), `[
+		{"jsonrpc":"2.0","method":"log","params":"one"},
+		{"jsonrpc":"2.0","method":"log","params":"two"}
+	]`)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("body = %q, want none", w.Body)
+	}
+	if len(logged) != 2 {
+		t.Errorf("logged = %q, want both notifications", logged)
+	}
+}
+
+func TestInvalidBatch(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		code int
+		msg  string
+	}{
+		{"empty batch", `[]`, jsonrpc.CodeInvalidRequest, "Invalid Request"},
+		{"malformed batch", `[{"jsonrpc":"2.0","method":"add"`, jsonrpc.CodeParseError, "Parse error"},
+		{"malformed request", `{"jsonrpc":"2.0",`, jsonrpc.CodeParseError, "Parse error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logged []string
+			w := post(newServer(&logged), tt.body)
+			testutil.AssertJSON(t, w, map[string]any{"jsonrpc": "2.0", "error": map[string]any{"code": tt.code, "message": tt.msg}, "id": nil})
+		})
+	}
+}
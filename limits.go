@@ -0,0 +1,133 @@
+package generichttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// WithMaxBodySize limits the size of request bodies to n bytes instead of
+// the default of 1 MiB. Larger bodies are rejected with a
+// RequestEntityTooLargeError, before reading if the Content-Length is
+// known, and as soon as the limit is exceeded otherwise.
+func WithMaxBodySize(n int64) Option {
+	return func(o *Options) {
+		o.MaxBodySize = n
+	}
+}
+
+// bodyLimit returns the maximum size of request bodies.
+func (o *Options) bodyLimit() int64 {
+	if o.MaxBodySize > 0 {
+		return o.MaxBodySize
+	}
+	return defaultMaxBodySize
+}
+
+// limitBody limits the body of r to the maximum size in opts. It returns
+// a RequestEntityTooLargeError if the Content-Length exceeds the limit.
+// Reading beyond the limit results in an error that bodyError maps to a
+// RequestEntityTooLargeError; if w is not nil, it also tells the server
+// to close the connection after the response, instead of reading the rest
+// of the body. w may be nil. With DecompressBody, the decompressed body is
+// limited.
+func limitBody(w http.ResponseWriter, r *http.Request, opts *Options) error {
+	limit := opts.bodyLimit()
+	if r.ContentLength > limit {
+		return bodyTooLarge(limit)
+	}
+	if r.Body != nil && r.Body != http.NoBody {
+		if opts.DecompressBody {
+			if err := decompressBody(r); err != nil {
+				return err
+			}
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+	}
+	return nil
+}
+
+// bodyError returns a RequestEntityTooLargeError if err results from
+// reading beyond the limit of limitBody, and err otherwise.
+func bodyError(err error) error {
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		return bodyTooLarge(mbe.Limit)
+	}
+	return err
+}
+
+// bodyTooLarge returns the error for a body larger than limit bytes.
+func bodyTooLarge(limit int64) error {
+	return RequestEntityTooLargeError{Message: fmt.Sprintf("Request body exceeds the limit of %d bytes", limit)}
+}
+
+// WithMaxQueryParams rejects requests with more than n query parameters
+// with a BadRequestError. Parameters are counted before the query string
+// is parsed, so the limit also bounds the work of query binding.
+func WithMaxQueryParams(n int) Option {
+	return func(o *Options) {
+		o.MaxQueryParams = n
+	}
+}
+
+// WithMaxHeaders rejects requests with more than n header fields with a
+// RequestHeaderFieldsTooLargeError. Repeated headers count once per value.
+// Use it in addition to the MaxHeaderBytes of http.Server, which limits
+// the size of the headers but not their number.
+func WithMaxHeaders(n int) Option {
+	return func(o *Options) {
+		o.MaxHeaders = n
+	}
+}
+
+// checkRequestLimits checks r against the limits in opts.
+func checkRequestLimits(r *http.Request, opts *Options) error {
+	if opts.MaxHeaders > 0 {
+		n := 0
+		for _, values := range r.Header {
+			n += len(values)
+		}
+		if n > opts.MaxHeaders {
+			return RequestHeaderFieldsTooLargeError{Message: "Too many request headers"}
+		}
+	}
+	if opts.MaxQueryParams > 0 && countQueryParams(r.URL.RawQuery) > opts.MaxQueryParams {
+		return BadRequestError{Message: "Too many query parameters"}
+	}
+	return nil
+}
+
+// countQueryParams returns the number of parameters in a raw query string.
+func countQueryParams(query string) int {
+	n := 0
+	for query != "" {
+		var param string
+		param, query, _ = strings.Cut(query, "&")
+		if param != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// MaxURLLength returns a middleware that rejects requests whose URL, i.e.
+// the path and query string as sent by the client, is longer than n bytes
+// with a URITooLongError. It complements the limits of the query string
+// and headers in WithMaxQueryParams and WithMaxHeaders, and of the body.
+func MaxURLLength(n int) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			uri := r.RequestURI
+			if uri == "" {
+				uri = r.URL.RequestURI()
+			}
+			if len(uri) > n {
+				writeJSONError(w, r, URITooLongError{Message: fmt.Sprintf("URL exceeds the limit of %d bytes", n)})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
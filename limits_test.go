@@ -0,0 +1,174 @@
+package generichttp_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/olivere/generichttp"
+	"github.com/olivere/generichttp/testutil"
+)
+
+type limitsFilter struct {
+	Tags []string `query:"tag"`
+}
+
+func echoLimitsFilter(w http.ResponseWriter, req generichttp.Request[limitsFilter]) (*generichttp.Response[limitsFilter], error) {
+	return generichttp.OK(req.Data), nil
+}
+
+// tagQuery returns a query string with n tag parameters.
+func tagQuery(n int) string {
+	params := make([]string, n)
+	for i := range params {
+		params[i] = fmt.Sprintf("tag=t%d", i)
+	}
+	return strings.Join(params, "&")
+}
+
+func TestMaxQueryParams(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		code  int
+	}{
+		{"at the limit", tagQuery(3), http.StatusOK},
+		{"empty parameters don't count", tagQuery(3) + "&&", http.StatusOK},
+		{"over the limit", tagQuery(4), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		for kind, h := range map[string]http.Handler{
+			"JSON": generichttp.JSON(echoLimitsFilter, generichttp.WithMaxQueryParams(3)),
+			"Auto": generichttp.Auto(echoLimitsFilter, generichttp.WithMaxQueryParams(3)),
+		} {
+			t.Run(tt.name+"/"+kind, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil))
+				if w.Code != tt.code {
+					t.Fatalf("status = %d, want %d", w.Code, tt.code)
+				}
+				if tt.code != http.StatusOK {
+					testutil.AssertJSON(t, w, map[string]any{"message": "Too many query parameters"})
+				}
+			})
+		}
+	}
+
+	w := httptest.NewRecorder()
+	generichttp.JSON(echoLimitsFilter).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?"+tagQuery(1000), nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("without a limit: status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestMaxHeaders(t *testing.T) {
+	h := generichttp.JSON(echoLimitsFilter, generichttp.WithMaxHeaders(3))
+	tests := []struct {
+		name   string
+		header http.Header
+		code   int
+	}{
+		{"at the limit", http.Header{"X-A": {"1"}, "X-B": {"2"}, "X-C": {"3"}}, http.StatusOK},
+		{"over the limit", http.Header{"X-A": {"1"}, "X-B": {"2"}, "X-C": {"3"}, "X-D": {"4"}}, http.StatusRequestHeaderFieldsTooLarge},
+		{"repeated header", http.Header{"X-A": {"1", "2", "3", "4"}}, http.StatusRequestHeaderFieldsTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header = tt.header
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.code {
+				t.Fatalf("status = %d, want %d", w.Code, tt.code)
+			}
+			if tt.code != http.StatusOK {
+				testutil.AssertJSON(t, w, map[string]any{"message": "Too many request headers"})
+			}
+		})
+	}
+}
+
+type limitsNote struct {
+	Text string `json:"text"`
+}
+
+func echoLimitsNote(w http.ResponseWriter, req generichttp.Request[limitsNote]) (*generichttp.Response[limitsNote], error) {
+	return generichttp.OK(req.Data), nil
+}
+
+func TestMaxBodySize(t *testing.T) {
+	const limit = 64
+	// noteBody returns a JSON body of exactly n bytes.
+	noteBody := func(n int) string {
+		return `{"text":"` + strings.Repeat("x", n-len(`{"text":""}`)) + `"}`
+	}
+	tests := []struct {
+		name   string
+		size   int
+		length bool // whether the Content-Length is known
+		code   int
+	}{
+		{"at the limit", limit, true, http.StatusOK},
+		{"one byte over", limit + 1, true, http.StatusRequestEntityTooLarge},
+		{"at the limit, chunked", limit, false, http.StatusOK},
+		{"one byte over, chunked", limit + 1, false, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		for kind, h := range map[string]http.Handler{
+			"JSON": generichttp.JSON(echoLimitsNote, generichttp.WithMaxBodySize(limit)),
+			"Auto": generichttp.Auto(echoLimitsNote, generichttp.WithMaxBodySize(limit)),
+		} {
+			t.Run(tt.name+"/"+kind, func(t *testing.T) {
+				var body io.Reader = strings.NewReader(noteBody(tt.size))
+				if !tt.length {
+					body = struct{ io.Reader }{body} // hides the size from NewRequest
+				}
+				r := httptest.NewRequest(http.MethodPost, "/", body)
+				r.Header.Set("Content-Type", "application/json")
+				if want := int64(-1); !tt.length && r.ContentLength != want {
+					t.Fatalf("ContentLength = %d, want %d", r.ContentLength, want)
+				}
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, r)
+				if w.Code != tt.code {
+					t.Fatalf("status = %d, want %d: %s", w.Code, tt.code, w.Body)
+				}
+				if tt.code != http.StatusOK {
+					testutil.AssertJSON(t, w, map[string]any{"message": "Request body exceeds the limit of 64 bytes"})
+				}
+			})
+		}
+	}
+}
+
+func TestMaxURLLength(t *testing.T) {
+	h := generichttp.MaxURLLength(32)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	tests := []struct {
+		name   string
+		target string
+		code   int
+	}{
+		{"short", "/search?q=go", http.StatusNoContent},
+		{"at the limit", "/search?q=" + strings.Repeat("a", 32-len("/search?q=")), http.StatusNoContent},
+		{"over-length query string", "/search?q=" + strings.Repeat("a", 33-len("/search?q=")), http.StatusRequestURITooLong},
+		{"over-length path", "/" + strings.Repeat("a", 32), http.StatusRequestURITooLong},
+		{"escapes count as sent", "/search?q=" + strings.Repeat("%20", 8), http.StatusRequestURITooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if w.Code != tt.code {
+				t.Fatalf("status = %d, want %d", w.Code, tt.code)
+			}
+			if tt.code == http.StatusRequestURITooLong {
+				testutil.AssertJSON(t, w, map[string]any{"message": "URL exceeds the limit of 32 bytes"})
+			}
+		})
+	}
+}
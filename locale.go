@@ -0,0 +1,96 @@
+package generichttp
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// defaultLanguage is the language of the default error messages.
+const defaultLanguage = "en"
+
+// translations holds the registered translations of error messages,
+// keyed by language and message.
+var translations = struct {
+	sync.RWMutex
+	m map[string]map[string]string
+}{m: make(map[string]map[string]string)}
+
+// RegisterTranslation registers the translation of an error message for
+// the given language, e.g. "de" or "de-CH". Errors rendered by JSON, Auto,
+// and Router use the translation if the Accept-Language header of the
+// request prefers that language.
+//
+// The message is the default, English message of the error, i.e. the one
+// returned by HTTPError. If the request prefers English over all
+// languages with a translation, the default message is used. To translate the standard errors, register their default
+// messages, e.g.:
+//
+//	generichttp.RegisterTranslation("de", "Bad request", "Ungültige Anfrage")
+//	generichttp.RegisterTranslation("de", "Not found", "Nicht gefunden")
+//
+// Errors may also translate themselves by implementing
+// HTTPErrorLocalized(lang string) string, which takes precedence over
+// registered translations. Return an empty string for unknown languages.
+func RegisterTranslation(lang, message, translation string) {
+	lang = strings.ToLower(lang)
+	translations.Lock()
+	defer translations.Unlock()
+	if translations.m[lang] == nil {
+		translations.m[lang] = make(map[string]string)
+	}
+	translations.m[lang][message] = translation
+}
+
+// localizedErrorMessage returns the message for err in the language
+// preferred by r. It falls back to errorMessage if r is nil or there is
+// no translation for any of the accepted languages.
+func localizedErrorMessage(r *http.Request, err error) string {
+	msg := errorMessage(err)
+	if r == nil {
+		return msg
+	}
+	header := r.Header.Get("Accept-Language")
+	if header == "" {
+		return msg
+	}
+	localized, _ := err.(interface{ HTTPErrorLocalized(string) string })
+	for _, lang := range acceptedLanguages(header) {
+		if lang == defaultLanguage {
+			return msg
+		}
+		if localized != nil {
+			if s := localized.HTTPErrorLocalized(lang); s != "" {
+				return s
+			}
+		}
+		translations.RLock()
+		s, found := translations.m[lang][msg]
+		translations.RUnlock()
+		if found {
+			return s
+		}
+	}
+	return msg
+}
+
+// acceptedLanguages returns the languages of an Accept-Language header,
+// ordered by q value. A language with a region, e.g. "de-ch", is followed
+// by its base language "de". Wildcards and languages with a q value of 0
+// are skipped.
+func acceptedLanguages(header string) []string {
+	ranges := parseAccept(header)
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+	var langs []string
+	for _, r := range ranges {
+		if r.q == 0 || r.value == "*" {
+			continue
+		}
+		langs = append(langs, r.value)
+		if base, _, found := strings.Cut(r.value, "-"); found {
+			langs = append(langs, base)
+		}
+	}
+	return langs
+}
@@ -0,0 +1,42 @@
+package generichttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// LongPoll returns a handler for a long-polling endpoint. It calls wait
+// with the context of the request, bounded by timeout, and renders the
+// data returned by wait as JSON, e.g.:
+//
+//	router.Get("/events", generichttp.JSON(generichttp.LongPoll(func(ctx context.Context) (*Event, error) {
+//		select {
+//		case e := <-events:
+//			return e, nil
+//		case <-ctx.Done():
+//			return nil, ctx.Err()
+//		}
+//	}, 30*time.Second)))
+//
+// wait must return when ctx is done. If it returns the error of ctx, i.e.
+// times out, or returns no data, the response is 204 No Content, so
+// clients simply poll again. Other errors are rendered as usual.
+//
+// Make sure the timeout is shorter than the WriteTimeout of the server and
+// the timeouts of proxies in between.
+func LongPoll[T any](wait func(ctx context.Context) (*T, error), timeout time.Duration) Handler[struct{}, T] {
+	return func(w http.ResponseWriter, req Request[struct{}]) (*Response[T], error) {
+		ctx, cancel := context.WithTimeout(req.Context(), timeout)
+		defer cancel()
+		data, err := wait(ctx)
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+		if err != nil || data == nil {
+			return nil, Halt(NewResponseWithCode[T](http.StatusNoContent, nil))
+		}
+		return NewResponse(data), nil
+	}
+}
@@ -0,0 +1,114 @@
+package generichttp
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// MaintenanceOptions configures the Maintenance middleware.
+type MaintenanceOptions struct {
+	// Enabled reports whether the service is in maintenance mode. It is
+	// called on every request, so it must be fast, e.g. the Enabled method
+	// of a MaintenanceSwitch or an atomic.Bool's Load method.
+	Enabled func() bool
+	// Allow lists the paths that are served in maintenance mode, e.g.
+	// health checks and the endpoint that toggles the mode. A path ending
+	// in a slash matches all paths below it. It defaults to "/health",
+	// "/healthz", "/livez", and "/readyz".
+	Allow []string
+	// RetryAfter is sent in the Retry-After header of rejected requests. It
+	// defaults to five minutes.
+	RetryAfter time.Duration
+	// Message is the error message of rejected requests. It defaults to
+	// "Service is down for maintenance".
+	Message string
+}
+
+// Maintenance returns a middleware that rejects requests with a
+// ServiceUnavailableError and a Retry-After header while the service is in
+// maintenance mode, e.g. during a deploy or a database migration. Requests
+// to the paths in opts.Allow are always served, so that load balancers
+// don't take the instance out of rotation and the mode can be switched off
+// again.
+func Maintenance(opts MaintenanceOptions) Middleware {
+	if opts.Allow == nil {
+		opts.Allow = []string{"/health", "/healthz", "/livez", "/readyz"}
+	}
+	if opts.RetryAfter <= 0 {
+		opts.RetryAfter = 5 * time.Minute
+	}
+	if opts.Message == "" {
+		opts.Message = "Service is down for maintenance"
+	}
+	retryAfter := strconv.Itoa(int(math.Ceil(opts.RetryAfter.Seconds())))
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if opts.Enabled == nil || !opts.Enabled() || maintenanceAllowed(r.URL.Path, opts.Allow) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("Retry-After", retryAfter)
+			writeJSONError(w, r, ServiceUnavailableError{Message: opts.Message})
+		})
+	}
+}
+
+// maintenanceAllowed reports whether path matches one of the allowed paths.
+func maintenanceAllowed(path string, allow []string) bool {
+	for _, p := range allow {
+		if path == p || (strings.HasSuffix(p, "/") && strings.HasPrefix(path, p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaintenanceSwitch is a flag for the Maintenance middleware that can be
+// toggled at runtime. It is also a http.Handler for an admin endpoint:
+// GET returns the state, PUT enables, and DELETE disables maintenance
+// mode, e.g.:
+//
+//	var maintenance generichttp.MaintenanceSwitch
+//	mux.Handle("/admin/maintenance", requireAdmin(&maintenance))
+//	mw := generichttp.Maintenance(generichttp.MaintenanceOptions{
+//		Enabled: maintenance.Enabled,
+//		Allow:   []string{"/healthz", "/admin/maintenance"},
+//	})
+//
+// Protect the endpoint, as this package doesn't. The zero value is
+// disabled and ready to use.
+type MaintenanceSwitch struct {
+	on atomic.Bool
+}
+
+// Enabled reports whether maintenance mode is enabled.
+func (s *MaintenanceSwitch) Enabled() bool {
+	return s.on.Load()
+}
+
+// Set enables or disables maintenance mode.
+func (s *MaintenanceSwitch) Set(enabled bool) {
+	s.on.Store(enabled)
+}
+
+// ServeHTTP implements http.Handler, see MaintenanceSwitch.
+func (s *MaintenanceSwitch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPut:
+		s.Set(true)
+	case http.MethodDelete:
+		s.Set(false)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT, DELETE")
+		writeJSONError(w, r, MethodNotAllowedError{})
+		return
+	}
+	WriteJSON(w, struct {
+		Enabled bool `json:"enabled"`
+	}{s.Enabled()})
+}
@@ -0,0 +1,107 @@
+package generichttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/olivere/generichttp"
+	"github.com/olivere/generichttp/testutil"
+)
+
+func TestMaintenance(t *testing.T) {
+	var maintenance generichttp.MaintenanceSwitch
+	mux := http.NewServeMux()
+	mux.Handle("/admin/maintenance", &maintenance)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	h := generichttp.Maintenance(generichttp.MaintenanceOptions{
+		Enabled:    maintenance.Enabled,
+		Allow:      []string{"/healthz", "/admin/maintenance", "/static/"},
+		RetryAfter: 90 * time.Second,
+	})(mux)
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+		return w
+	}
+	paths := []struct {
+		path    string
+		allowed bool
+	}{
+		{"/api/items", false},
+		{"/healthz", true},
+		{"/healthz/deep", false},
+		{"/healthzz", false},
+		{"/static/app.js", true},
+		{"/static", false},
+		{"/", false},
+	}
+
+	for _, p := range paths {
+		if w := serve(http.MethodGet, p.path); w.Code != http.StatusNoContent {
+			t.Errorf("disabled: GET %s: status = %d, want %d", p.path, w.Code, http.StatusNoContent)
+		}
+	}
+
+	w := serve(http.MethodPut, "/admin/maintenance")
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT: status = %d, want %d", w.Code, http.StatusOK)
+	}
+	testutil.AssertJSON(t, w, map[string]any{"enabled": true})
+
+	for _, p := range paths {
+		w := serve(http.MethodGet, p.path)
+		if p.allowed {
+			if w.Code != http.StatusNoContent {
+				t.Errorf("enabled: GET %s: status = %d, want %d", p.path, w.Code, http.StatusNoContent)
+			}
+			continue
+		}
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("enabled: GET %s: status = %d, want %d", p.path, w.Code, http.StatusServiceUnavailable)
+			continue
+		}
+		if got := w.Header().Get("Retry-After"); got != "90" {
+			t.Errorf("enabled: GET %s: Retry-After = %q, want %q", p.path, got, "90")
+		}
+		testutil.AssertJSON(t, w, map[string]any{"message": "Service is down for maintenance"})
+	}
+
+	w = serve(http.MethodGet, "/admin/maintenance")
+	testutil.AssertJSON(t, w, map[string]any{"enabled": true})
+	w = serve(http.MethodDelete, "/admin/maintenance")
+	testutil.AssertJSON(t, w, map[string]any{"enabled": false})
+	if w := serve(http.MethodGet, "/api/items"); w.Code != http.StatusNoContent {
+		t.Errorf("disabled again: status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+
+	if w := serve(http.MethodPost, "/admin/maintenance"); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestMaintenanceDefaults(t *testing.T) {
+	h := generichttp.Maintenance(generichttp.MaintenanceOptions{
+		Enabled: func() bool { return true },
+		Message: "Migrating the database",
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	for _, path := range []string{"/health", "/healthz", "/livez", "/readyz"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusNoContent {
+			t.Errorf("GET %s: status = %d, want %d", path, w.Code, http.StatusNoContent)
+		}
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/items", nil))
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "300" {
+		t.Errorf("status = %d, Retry-After = %q; want %d, 300", w.Code, w.Header().Get("Retry-After"), http.StatusServiceUnavailable)
+	}
+	testutil.AssertJSON(t, w, map[string]any{"message": "Migrating the database"})
+}
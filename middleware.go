@@ -0,0 +1,7 @@
+package generichttp
+
+import "net/http"
+
+// Middleware wraps a http.Handler to add behavior before and/or after
+// the wrapped handler is being called.
+type Middleware func(http.Handler) http.Handler
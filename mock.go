@@ -0,0 +1,149 @@
+package generichttp
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// MockOptions configures Router.Mock.
+type MockOptions struct {
+	// Examples overrides the response data of routes, keyed by method and
+	// pattern, e.g. "GET /items/{id}". The data is rendered as JSON.
+	Examples map[string]any
+}
+
+// Mock returns a new Router with the routes of rt that answers requests
+// with example responses instead of calling the handlers, so that e.g.
+// frontend teams can develop against an API before it is implemented:
+//
+//	http.ListenAndServe(":8080", router.Mock(generichttp.MockOptions{}))
+//
+// Routes with handlers created by JSON or Auto respond with an example of
+// their response type. The example has the value of the example struct
+// tag for fields that have one, e.g. `example:"Jane"`, the first value of
+// an enum or oneof constraint, or a plausible value by type and format
+// otherwise. Slices and maps get one element. Other routes respond with a
+// NotImplementedError unless they have an example in opts.
+func (rt *Router) Mock(opts MockOptions) *Router {
+	mock := NewRouter()
+	mock.AutoOptions, mock.AutoHead = rt.AutoOptions, rt.AutoHead
+	for _, route := range rt.Routes() {
+		data, found := opts.Examples[route.Method+" "+route.Pattern]
+		if !found && route.ResponseType != nil {
+			data = exampleOf(route.ResponseType).Interface()
+		}
+		var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if data == nil {
+				writeJSONError(w, r, NotImplementedError{})
+				return
+			}
+			WriteJSON(w, data)
+		})
+		mock.Handle(route.Method, route.Pattern, h, WithSummary(route.Summary), WithTags(route.Tags...))
+	}
+	return mock
+}
+
+// exampleTime is the time used in examples.
+var exampleTime = time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+// exampleOf returns an example value of type t.
+func exampleOf(t reflect.Type) reflect.Value {
+	v := reflect.New(t).Elem()
+	fillExample(v, "", make(map[reflect.Type]bool))
+	return v
+}
+
+// fillExample fills v with an example value. The hint is the value of the
+// example struct tag, or of an enum or oneof constraint, of its field.
+// Types already being filled are left zero to stop at recursive types.
+func fillExample(v reflect.Value, hint string, visiting map[reflect.Type]bool) {
+	t := v.Type()
+	if t == timeType {
+		v.Set(reflect.ValueOf(exampleTime))
+		return
+	}
+	if hint != "" && setValue(v, hint) == nil {
+		return
+	}
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString("string")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1.5)
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Pointer:
+		if visiting[t.Elem()] {
+			return
+		}
+		p := reflect.New(t.Elem())
+		fillExample(p.Elem(), hint, visiting)
+		v.Set(p)
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 || visiting[t.Elem()] {
+			v.Set(reflect.MakeSlice(t, 0, 0))
+			return
+		}
+		s := reflect.MakeSlice(t, 1, 1)
+		fillExample(s.Index(0), hint, visiting)
+		v.Set(s)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			fillExample(v.Index(i), hint, visiting)
+		}
+	case reflect.Map:
+		m := reflect.MakeMap(t)
+		if t.Key().Kind() == reflect.String && !visiting[t.Elem()] {
+			key := reflect.New(t.Key()).Elem()
+			key.SetString("key")
+			elem := reflect.New(t.Elem()).Elem()
+			fillExample(elem, "", visiting)
+			m.SetMapIndex(key, elem)
+		}
+		v.Set(m)
+	case reflect.Struct:
+		visiting[t] = true
+		defer delete(visiting, t)
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() && !f.Anonymous {
+				continue
+			}
+			if !v.Field(i).CanSet() {
+				continue
+			}
+			fillExample(v.Field(i), exampleHint(f), visiting)
+		}
+	}
+}
+
+// exampleHint returns the example value of a field from its struct tags.
+func exampleHint(f reflect.StructField) string {
+	if example, found := f.Tag.Lookup("example"); found {
+		return example
+	}
+	if enum, found := f.Tag.Lookup("enum"); found {
+		return enumValues(enum)[0]
+	}
+	for _, rule := range strings.Split(f.Tag.Get("validate"), ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "oneof":
+			if values := strings.Fields(value); len(values) > 0 {
+				return values[0]
+			}
+		case "email":
+			return "user@example.com"
+		case "url":
+			return "https://example.com"
+		}
+	}
+	return ""
+}
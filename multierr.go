@@ -0,0 +1,88 @@
+package generichttp
+
+import (
+	"encoding/xml"
+	"net/http"
+)
+
+// errorItem is a single error in the body rendered for an error that
+// aggregates others.
+type errorItem struct {
+	Status  int    `json:"status" xml:"status"`
+	Message string `json:"message" xml:"message"`
+}
+
+// errorItems is the list of errors in the body rendered for an error that
+// aggregates others.
+type errorItems []errorItem
+
+// MarshalXML implements xml.Marshaler. It renders the items as error
+// elements and omits an empty list, which the errors>error form of the
+// xml struct tag cannot.
+func (items errorItems) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return e.EncodeElement(struct {
+		Items []errorItem `xml:"error"`
+	}{items}, start)
+}
+
+// joinedErrors returns the errors aggregated by err, e.g. by errors.Join,
+// with nested aggregates flattened. It returns nil if err doesn't aggregate
+// errors or has a HTTPCode of its own.
+func joinedErrors(err error) []error {
+	if _, ok := err.(interface{ HTTPCode() int }); ok {
+		return nil
+	}
+	multi, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return nil
+	}
+	var errs []error
+	for _, e := range multi.Unwrap() {
+		if e == nil {
+			continue
+		}
+		if nested := joinedErrors(e); nested != nil {
+			errs = append(errs, nested...)
+			continue
+		}
+		errs = append(errs, e)
+	}
+	return errs
+}
+
+// primaryError returns the error of errs that determines the status code
+// and message of their aggregate: the first of the errors with the highest
+// status code, so e.g. a 500 outranks a 400, and of several 400s the first
+// one wins.
+func primaryError(errs []error) error {
+	var primary error
+	for _, e := range errs {
+		if primary == nil || errorCode(e) > errorCode(primary) {
+			primary = e
+		}
+	}
+	return primary
+}
+
+// newErrorBody returns the body rendered for err, translated to the
+// language preferred by r. If err aggregates errors, the body lists them
+// with their status codes and messages. A ValidationError adds the names
+// of its fields. The details of internal errors are added if enabled, see
+// WithDevErrors.
+func newErrorBody(r *http.Request, err error) errorBody {
+	body := errorBody{Message: localizedErrorMessage(r, err)}
+	if ve, ok := err.(ValidationError); ok {
+		body.Fields = ve.Fields
+	}
+	for _, e := range joinedErrors(err) {
+		body.Errors = append(body.Errors, errorItem{
+			Status:  errorCode(e),
+			Message: localizedErrorMessage(r, e),
+		})
+	}
+	addDevDetails(&body, err)
+	return body
+}
@@ -0,0 +1,117 @@
+package generichttp
+
+import (
+	"net/http"
+	"reflect"
+)
+
+// MultiStatus is response data for endpoints that act on several items at
+// once, e.g. a bulk update, and report the outcome of each item with its
+// own status code. Use MultiStatusResponse to render it with status 207
+// Multi-Status, e.g.:
+//
+//	var ms generichttp.MultiStatus[Item]
+//	for _, id := range req.Data.IDs {
+//		item, err := store.Update(ctx, id, req.Data.Changes)
+//		if err != nil {
+//			ms.AddError(err)
+//			continue
+//		}
+//		ms.Add(http.StatusOK, item)
+//	}
+//	return generichttp.MultiStatusResponse(&ms), nil
+//
+// It is rendered as JSON, with the results in the order they were added:
+//
+//	{"results":[{"status":200,"data":{...}},{"status":404,"error":{"message":"Not found"}}]}
+//
+// Errors are rendered in the format of WriteJSONError, translated and
+// logged like errors returned from a handler. See Batch for a generic
+// batch endpoint that fans out to other routes instead.
+type MultiStatus[T any] struct {
+	Results []MultiStatusResult[T]
+}
+
+// MultiStatusResult is the result of a single item of a MultiStatus.
+type MultiStatusResult[T any] struct {
+	// Status is the HTTP status code of the item. It defaults to the code
+	// of Err, see WriteJSONError, or to 200 if Err is nil.
+	Status int
+	// Data is the data of a successful item.
+	Data *T
+	// Err is the error of a failed item.
+	Err error
+}
+
+// Add adds the result of a successful item with the given status code
+// and data, which may be nil.
+func (ms *MultiStatus[T]) Add(status int, data *T) {
+	ms.Results = append(ms.Results, MultiStatusResult[T]{Status: status, Data: data})
+}
+
+// AddError adds the result of a failed item, with the status code of err.
+func (ms *MultiStatus[T]) AddError(err error) {
+	ms.Results = append(ms.Results, MultiStatusResult[T]{Err: err})
+}
+
+// MultiStatusResponse creates a new Response with ms and HTTP status code
+// 207.
+func MultiStatusResponse[T any](ms *MultiStatus[T]) *Response[MultiStatus[T]] {
+	return NewResponseWithCode(http.StatusMultiStatus, ms)
+}
+
+// multiStatusBody is the JSON encoding of a MultiStatus.
+type multiStatusBody[T any] struct {
+	Results []multiStatusItem[T] `json:"results"`
+}
+
+// multiStatusItem is the JSON encoding of a MultiStatusResult.
+type multiStatusItem[T any] struct {
+	Status int        `json:"status"`
+	Data   *T         `json:"data,omitempty"`
+	Error  *errorBody `json:"error,omitempty"`
+}
+
+// render implements renderer.
+func (ms *MultiStatus[T]) render(w http.ResponseWriter, r *http.Request, code int) {
+	body := multiStatusBody[T]{Results: make([]multiStatusItem[T], len(ms.Results))}
+	for i, res := range ms.Results {
+		item := multiStatusItem[T]{Status: res.Status, Data: res.Data}
+		if res.Err != nil {
+			if item.Status == 0 {
+				item.Status = errorCode(res.Err)
+			}
+			logError(r, item.Status, res.Err)
+			eb := newErrorBody(r, res.Err)
+			item.Error = &eb
+		}
+		if item.Status == 0 {
+			item.Status = http.StatusOK
+		}
+		body.Results[i] = item
+	}
+	writeJSONCode(requestContext(r), w, code, body)
+}
+
+// content implements renderer.
+func (*MultiStatus[T]) content() (string, *Schema) {
+	data, _ := schemaOf(reflect.TypeOf((*T)(nil)).Elem())
+	return mediaTypeJSON, &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"results": {
+				Type: "array",
+				Items: &Schema{
+					Type: "object",
+					Properties: map[string]*Schema{
+						"status": {Type: "integer"},
+						"data":   data,
+						"error":  errorSchema,
+					},
+					Required: []string{"status"},
+				},
+			},
+		},
+		Required: []string{"results"},
+	}
+}
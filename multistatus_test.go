@@ -0,0 +1,79 @@
+package generichttp_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/olivere/generichttp"
+	"github.com/olivere/generichttp/testutil"
+)
+
+type bulkItem struct {
+	ID string `json:"id"`
+}
+
+func TestMultiStatus(t *testing.T) {
+	h := func(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[generichttp.MultiStatus[bulkItem]], error) {
+		var ms generichttp.MultiStatus[bulkItem]
+		ms.Add(http.StatusOK, &bulkItem{ID: "1"})
+		ms.Add(http.StatusCreated, &bulkItem{ID: "2"})
+		ms.Add(http.StatusNoContent, nil)
+		ms.AddError(generichttp.NotFoundError{Message: "No such item"})
+		ms.Results = append(ms.Results, generichttp.MultiStatusResult[bulkItem]{
+			Status: http.StatusConflict,
+			Err:    generichttp.BadRequestError{Message: "Item was changed"},
+		})
+		ms.Results = append(ms.Results, generichttp.MultiStatusResult[bulkItem]{Data: &bulkItem{ID: "6"}})
+		return generichttp.MultiStatusResponse(&ms), nil
+	}
+	want := map[string]any{"results": []any{
+		map[string]any{"status": 200, "data": map[string]any{"id": "1"}},
+		map[string]any{"status": 201, "data": map[string]any{"id": "2"}},
+		map[string]any{"status": 204},
+		map[string]any{"status": 404, "error": map[string]any{"message": "No such item"}},
+		map[string]any{"status": 409, "error": map[string]any{"message": "Item was changed"}},
+		map[string]any{"status": 200, "data": map[string]any{"id": "6"}},
+	}}
+	for kind, handler := range map[string]http.Handler{"JSON": generichttp.JSON(h), "Auto": generichttp.Auto(h)} {
+		t.Run(kind, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items/bulk", nil))
+			if w.Code != http.StatusMultiStatus {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusMultiStatus)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+			testutil.AssertJSON(t, w, want)
+		})
+	}
+}
+
+func TestMultiStatusInternalError(t *testing.T) {
+	h := generichttp.JSON(func(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[generichttp.MultiStatus[bulkItem]], error) {
+		var ms generichttp.MultiStatus[bulkItem]
+		ms.AddError(errors.New("connection to 10.0.0.7 refused"))
+		return generichttp.MultiStatusResponse(&ms), nil
+	})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items/bulk", nil))
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusMultiStatus)
+	}
+	testutil.AssertJSON(t, w, map[string]any{"results": []any{
+		map[string]any{"status": 500, "error": map[string]any{"message": "Internal server error"}},
+	}})
+}
+
+func TestMultiStatusEmpty(t *testing.T) {
+	h := generichttp.JSON(func(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[generichttp.MultiStatus[bulkItem]], error) {
+		return generichttp.MultiStatusResponse(&generichttp.MultiStatus[bulkItem]{}), nil
+	})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items/bulk", nil))
+	if got, want := w.Body.String(), `{"results":[]}`+"\n"; w.Code != http.StatusMultiStatus || got != want {
+		t.Errorf("status = %d, body = %q; want %d, %q", w.Code, got, http.StatusMultiStatus, want)
+	}
+}
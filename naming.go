@@ -0,0 +1,391 @@
+package generichttp
+
+import (
+	"bytes"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// NamingPolicy maps the name of a struct field to its name in JSON, see
+// WithNamingPolicy.
+type NamingPolicy func(name string) string
+
+// WithNamingPolicy renames the fields of the JSON response data with
+// policy, e.g. with SnakeCase to render a field UserID as "user_id". It
+// applies to fields without an explicit name in their json struct tag;
+// explicit names always win. Other options of the json struct tag, i.e.
+// "-", omitempty, omitzero, and string, are respected. Field selection,
+// see WithFieldSelection, uses the renamed fields.
+//
+// The policy applies to the response data of JSON, and of Auto if the
+// response is JSON. Request bodies are decoded as before, i.e. by the Go
+// names of the fields and their json struct tags.
+//
+// Renaming walks the response data via reflection and builds an
+// intermediate value for encoding, which costs roughly as much as a
+// second round of encoding. Types with a custom encoding, i.e. that
+// implement json.Marshaler or encoding.TextMarshaler, are encoded as is.
+func WithNamingPolicy(policy NamingPolicy) Option {
+	return func(o *Options) {
+		o.NamingPolicy = policy
+	}
+}
+
+// SnakeCase is a NamingPolicy that converts names to snake case, e.g.
+// "UserID" to "user_id" and "HTTPServer" to "http_server".
+func SnakeCase(name string) string {
+	var sb strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (!unicode.IsUpper(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]))) && runes[i-1] != '_' {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// CamelCase is a NamingPolicy that converts names to camel case by
+// lowering the leading upper-case letters, e.g. "UserID" to "userID" and
+// "HTTPServer" to "httpServer".
+func CamelCase(name string) string {
+	runes := []rune(name)
+	for i := range runes {
+		if !unicode.IsUpper(runes[i]) {
+			break
+		}
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// jsonName returns the name of struct field f in JSON with policy applied,
+// or "" if the field is omitted.
+func jsonName(f reflect.StructField, policy NamingPolicy) string {
+	tag := f.Tag.Get("json")
+	name, _, _ := strings.Cut(tag, ",")
+	switch {
+	case tag == "-":
+		return ""
+	case name != "":
+		return name
+	case policy != nil:
+		return policy(f.Name)
+	}
+	return f.Name
+}
+
+// namedField is a field of a struct as encoded by encoding/json.
+type namedField struct {
+	field     reflect.StructField
+	index     []int
+	tagged    bool   // the json struct tag names the field
+	name      string // set by dominantFields
+	omitEmpty bool
+	omitZero  bool
+	quoted    bool
+}
+
+// namedFieldsCache caches the fields of struct types.
+var namedFieldsCache sync.Map // map[reflect.Type][]namedField
+
+// namedFields returns the candidate fields of struct type t for encoding,
+// with the fields of embedded structs flattened, in index order. Like
+// encoding/json, it walks embedded structs breadth first, visits each type
+// once, and lists the fields of a type embedded several times at the same
+// depth twice, so that they annihilate each other, see dominantFields.
+func namedFields(t reflect.Type) []namedField {
+	if fields, ok := namedFieldsCache.Load(t); ok {
+		return fields.([]namedField)
+	}
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+	var (
+		fields    []namedField
+		next      = []embedded{{typ: t}}
+		count     map[reflect.Type]int
+		nextCount = map[reflect.Type]int{}
+		visited   = map[reflect.Type]bool{}
+	)
+	for len(next) > 0 {
+		current := next
+		next = nil
+		count, nextCount = nextCount, map[reflect.Type]int{}
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+			for i := 0; i < e.typ.NumField(); i++ {
+				f := e.typ.Field(i)
+				ft := f.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if f.Anonymous {
+					if !f.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !f.IsExported() {
+					continue
+				}
+				tag := f.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				index := make([]int, len(e.index)+1)
+				copy(index, e.index)
+				index[len(e.index)] = i
+				if name == "" && f.Anonymous && ft.Kind() == reflect.Struct {
+					nextCount[ft]++
+					if nextCount[ft] == 1 {
+						next = append(next, embedded{typ: ft, index: index})
+					}
+					continue
+				}
+				nf := namedField{
+					field:     f,
+					index:     index,
+					tagged:    name != "",
+					omitEmpty: hasTagOption(opts, "omitempty"),
+					omitZero:  hasTagOption(opts, "omitzero"),
+					quoted:    hasTagOption(opts, "string"),
+				}
+				fields = append(fields, nf)
+				if count[e.typ] > 1 {
+					fields = append(fields, nf)
+				}
+			}
+		}
+	}
+	sort.SliceStable(fields, func(i, j int) bool {
+		return slices.Compare(fields[i].index, fields[j].index) < 0
+	})
+	namedFieldsCache.Store(t, fields)
+	return fields
+}
+
+// dominantFields returns the fields of struct type t that encoding/json
+// encodes, with their names under policy, in index order. Of several
+// fields with the same name, the shallowest one wins, and of those at the
+// same depth, the one named by its json struct tag. If that leaves more
+// than one, none of them is encoded.
+func dominantFields(t reflect.Type, policy NamingPolicy) []namedField {
+	candidates := namedFields(t)
+	type dominant struct {
+		i         int
+		ambiguous bool
+	}
+	fields := make([]namedField, len(candidates))
+	byName := make(map[string]dominant, len(candidates))
+	for i, f := range candidates {
+		f.name = jsonName(f.field, policy)
+		fields[i] = f
+		d, found := byName[f.name]
+		if !found {
+			byName[f.name] = dominant{i: i}
+			continue
+		}
+		other := fields[d.i]
+		switch {
+		case len(f.index) < len(other.index) || (len(f.index) == len(other.index) && f.tagged && !other.tagged):
+			byName[f.name] = dominant{i: i}
+		case len(f.index) == len(other.index) && f.tagged == other.tagged:
+			d.ambiguous = true
+			byName[f.name] = d
+		}
+	}
+	n := 0
+	for i, f := range fields {
+		if d := byName[f.name]; d.i == i && !d.ambiguous {
+			fields[n] = f
+			n++
+		}
+	}
+	return fields[:n]
+}
+
+// hasTagOption reports whether the comma-separated options of a struct tag
+// contain opt.
+func hasTagOption(opts, opt string) bool {
+	for _, o := range strings.Split(opts, ",") {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// applyNaming returns data with the fields of its structs renamed by
+// policy, as a value to be encoded. Data that renders itself is returned
+// unchanged.
+func applyNaming(data any, policy NamingPolicy) any {
+	if policy == nil {
+		return data
+	}
+	if _, ok := data.(renderer); ok {
+		return data
+	}
+	return renameValue(reflect.ValueOf(data), policy)
+}
+
+// renameValue returns v with the fields of its structs renamed by policy.
+func renameValue(v reflect.Value, policy NamingPolicy) any {
+	if !v.IsValid() {
+		return nil
+	}
+	t := v.Type()
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+		return v.Interface()
+	}
+	if v.CanAddr() && (reflect.PointerTo(t).Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType)) {
+		return v.Addr().Interface()
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return renameValue(v.Elem(), policy)
+	case reflect.Struct:
+		return renameStruct(v, policy)
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		fallthrough
+	case reflect.Array:
+		s := make([]any, v.Len())
+		for i := range s {
+			s[i] = renameValue(v.Index(i), policy)
+		}
+		return s
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		m := reflect.MakeMapWithSize(reflect.MapOf(t.Key(), reflect.TypeOf((*any)(nil)).Elem()), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			val := renameValue(iter.Value(), policy)
+			m.SetMapIndex(iter.Key(), reflect.ValueOf(&val).Elem())
+		}
+		return m.Interface()
+	}
+	return v.Interface()
+}
+
+// renameStruct returns the fields of struct v renamed by policy, in the
+// order of encoding/json. Of several fields with the same name, only the
+// dominant one is encoded, see dominantFields.
+func renameStruct(v reflect.Value, policy NamingPolicy) namedObject {
+	fields := dominantFields(v.Type(), policy)
+	obj := make(namedObject, 0, len(fields))
+	for _, f := range fields {
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok {
+			continue
+		}
+		if (f.omitEmpty && isEmptyValue(fv)) || (f.omitZero && fv.IsZero()) {
+			continue
+		}
+		name := f.name
+		val := renameValue(fv, policy)
+		if f.quoted && val != nil {
+			switch fv.Kind() {
+			case reflect.Bool, reflect.String,
+				reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+				reflect.Float32, reflect.Float64:
+				if b, err := Marshal(val); err == nil {
+					val = string(b)
+				}
+			}
+		}
+		obj = append(obj, namedValue{name: name, value: val})
+	}
+	return obj
+}
+
+// fieldByIndex returns the field of v with the given index, like
+// reflect.Value.FieldByIndex, but reports false instead of panicking for
+// a field of an embedded struct pointer that is nil.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// isEmptyValue reports whether v is empty in terms of the omitempty option
+// of encoding/json.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+// namedValue is a field of a namedObject.
+type namedValue struct {
+	name  string
+	value any
+}
+
+// namedObject is a struct with renamed fields. Unlike a map, it keeps the
+// order of the fields when encoded.
+type namedObject []namedValue
+
+// MarshalJSON implements json.Marshaler.
+func (obj namedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, nv := range obj {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := Marshal(nv.name)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		val, err := Marshal(nv.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,148 @@
+package generichttp
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type namingEmbedded struct {
+	ID   int
+	Name string `json:"name"`
+}
+
+type namingOther struct {
+	ID    int
+	Label string
+}
+
+type namingTagged struct {
+	Alias int `json:"ID"`
+}
+
+type namingShared struct {
+	Shared string
+}
+
+type namingLeft struct {
+	namingShared
+	Left int
+}
+
+type namingRight struct {
+	namingShared
+	Right int
+}
+
+type namingRecursive struct {
+	*namingRecursive
+	Name string
+}
+
+// identityNaming is a NamingPolicy that keeps the names of encoding/json.
+func identityNaming(name string) string { return name }
+
+func TestNamingMatchesEncodingJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		data any
+	}{
+		{"outer field shadows embedded", struct {
+			namingEmbedded
+			ID int
+		}{namingEmbedded{ID: 1, Name: "inner"}, 2}},
+		{"shadowing field omitted by omitempty", struct {
+			Name string `json:"name,omitempty"`
+			namingEmbedded
+		}{"", namingEmbedded{ID: 1, Name: "inner"}}},
+		{"ambiguous at same depth", struct {
+			namingEmbedded
+			namingOther
+		}{namingEmbedded{ID: 1, Name: "a"}, namingOther{ID: 2, Label: "b"}}},
+		{"tagged wins at same depth", struct {
+			namingOther
+			namingTagged
+		}{namingOther{ID: 1, Label: "a"}, namingTagged{Alias: 2}}},
+		{"same type embedded twice at same depth", struct {
+			namingLeft
+			namingRight
+		}{namingLeft{namingShared{"l"}, 1}, namingRight{namingShared{"r"}, 2}}},
+		{"nil embedded pointer", struct {
+			*namingEmbedded
+			Label string
+		}{nil, "x"}},
+		{"embedded pointer", struct {
+			*namingEmbedded
+			Label string
+		}{&namingEmbedded{ID: 1, Name: "a"}, "x"}},
+		{"self-embedding type", namingRecursive{&namingRecursive{nil, "inner"}, "outer"}},
+		{"named embedded struct", struct {
+			namingEmbedded `json:"embedded"`
+			ID             int
+		}{namingEmbedded{ID: 1, Name: "a"}, 2}},
+		{"slice of structs", []struct {
+			namingEmbedded
+			ID int
+		}{{namingEmbedded{ID: 1}, 2}, {namingEmbedded{ID: 3}, 4}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := json.Marshal(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.Marshal(applyNaming(tt.data, identityNaming))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("got  %s\nwant %s", got, want)
+			}
+		})
+	}
+}
+
+func TestNamingPolicies(t *testing.T) {
+	type profile struct {
+		HTTPServer string
+		UserID     int
+	}
+	data := struct {
+		profile
+		UserID   int
+		Explicit string `json:"Explicit_Name"`
+	}{profile{"srv", 1}, 2, "x"}
+	tests := []struct {
+		policy NamingPolicy
+		want   string
+	}{
+		{SnakeCase, `{"http_server":"srv","user_id":2,"Explicit_Name":"x"}`},
+		{CamelCase, `{"httpServer":"srv","userID":2,"Explicit_Name":"x"}`},
+	}
+	for _, tt := range tests {
+		got, err := json.Marshal(applyNaming(data, tt.policy))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("got  %s\nwant %s", got, tt.want)
+		}
+	}
+}
+
+func TestFieldSelectionUsesDominantField(t *testing.T) {
+	type inner struct {
+		ID struct{ Deep int }
+	}
+	type outer struct {
+		inner
+		ID int
+	}
+	ft, ok := jsonFieldType(reflect.TypeOf(outer{}), "ID", nil)
+	if !ok {
+		t.Fatal("field ID not found")
+	}
+	if ft.Kind() != reflect.Int {
+		t.Errorf("type of ID = %s, want int of the outer field", ft)
+	}
+}
@@ -0,0 +1,153 @@
+package generichttp
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// acceptRange is a single entry of an Accept header.
+type acceptRange struct {
+	value string
+	q     float64
+}
+
+// parseAccept parses an Accept header into its ranges. Parameters other
+// than q are ignored. Ranges with an invalid q value are skipped.
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		value, params, _ := strings.Cut(part, ";")
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+		q := 1.0
+		valid := true
+		for _, param := range strings.Split(params, ";") {
+			key, val, _ := strings.Cut(param, "=")
+			if strings.TrimSpace(strings.ToLower(key)) != "q" {
+				continue
+			}
+			f, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+			if err != nil || f < 0 || f > 1 {
+				valid = false
+				break
+			}
+			q = f
+		}
+		if valid {
+			ranges = append(ranges, acceptRange{value: value, q: q})
+		}
+	}
+	return ranges
+}
+
+// NegotiateContentType returns the offer the client prefers according to
+// the Accept header, following the rules of RFC 9110 (formerly RFC 7231):
+// the q value of an offer is taken from the most specific matching range,
+// i.e. "type/subtype" before "type/*" before "*/*", so e.g.
+// "*/*, text/html;q=0" accepts anything but HTML. Offers with a q value of
+// 0 are not acceptable. Ties are resolved by the order of offers, which
+// should be the order of preference of the server. Ranges with an invalid
+// q value are ignored, as are media type parameters other than q.
+//
+// An empty Accept header accepts the first offer. If no offer is
+// acceptable, it returns an empty string; respond with 406 Not Acceptable
+// or fall back to a default.
+func NegotiateContentType(accept string, offers []string) string {
+	if strings.TrimSpace(accept) == "" {
+		if len(offers) > 0 {
+			return offers[0]
+		}
+		return ""
+	}
+	ranges := parseAccept(accept)
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		typ, _, _ := strings.Cut(strings.ToLower(offer), "/")
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			var s int
+			switch {
+			case r.value == strings.ToLower(offer):
+				s = 2
+			case r.value == typ+"/*":
+				s = 1
+			case r.value == "*/*":
+				s = 0
+			default:
+				continue
+			}
+			if s > specificity {
+				q, specificity = r.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// NegotiateEncoding returns the offer the client prefers according to the
+// Accept-Encoding header, e.g. "gzip", following the rules of RFC 9110:
+// the q value of an offer is taken from its coding, or from the wildcard
+// "*" if it isn't listed. Offers with a q value of 0 are not acceptable.
+// The "identity" coding is acceptable unless excluded, e.g. by
+// "identity;q=0" or "*;q=0", but if not listed, any listed coding is
+// preferred. Ties are resolved by the order of offers,
+// which should be the order of preference of the server. If no offer is
+// acceptable, it returns an empty string.
+func NegotiateEncoding(header string, offers []string) string {
+	ranges := parseAccept(header)
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		coding := strings.ToLower(offer)
+		q, listed, wildcard := 0.0, false, false
+		for _, r := range ranges {
+			switch {
+			case r.value == coding:
+				q, listed = r.q, true
+			case r.value == "*" && !listed:
+				q, wildcard = r.q, true
+			}
+		}
+		if coding == "identity" && !listed && !wildcard {
+			// Acceptable, but less preferred than any listed coding.
+			q = math.SmallestNonzeroFloat64
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// WithStrictAccept rejects requests whose Accept header accepts none of
+// the media types a handler renders, e.g. "application/xml" for JSON, with
+// a NotAcceptableError that lists the supported media types. Without it,
+// JSON renders JSON regardless of the Accept header, and Auto falls back
+// to JSON. It doesn't apply to response data that renders itself, e.g.
+// TextResponse and FileResponse.
+func WithStrictAccept() Option {
+	return func(o *Options) {
+		o.StrictAccept = true
+	}
+}
+
+// checkAccept returns a NotAcceptableError if strict Accept checks are
+// enabled and the Accept header of r accepts none of offers.
+func checkAccept[W any](r *http.Request, offers []string, opts *Options) error {
+	if !opts.StrictAccept {
+		return nil
+	}
+	if _, ok := any(new(W)).(renderer); ok {
+		return nil
+	}
+	if NegotiateContentType(r.Header.Get("Accept"), offers) != "" {
+		return nil
+	}
+	return NotAcceptableError{Message: "Not acceptable, supported media types: " + strings.Join(offers, ", ")}
+}
@@ -0,0 +1,162 @@
+package generichttp_test
+
+import (
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/olivere/generichttp"
+	"github.com/olivere/generichttp/testutil"
+)
+
+func TestNegotiateContentType(t *testing.T) {
+	offers := []string{"application/json", "application/xml"}
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", "application/json"},
+		{"   ", "application/json"},
+		{"application/json", "application/json"},
+		{"application/xml", "application/xml"},
+		{"APPLICATION/XML", "application/xml"},
+		{"*/*", "application/json"},
+		{"application/*", "application/json"},
+		{"text/*", ""},
+		{"text/html", ""},
+		{"application/xml, application/json", "application/json"},
+		{"application/json;q=0.9, application/xml", "application/xml"},
+		{"application/*;q=0.5, application/xml", "application/xml"},
+		{"*/*;q=0.1, application/xml;q=0.2", "application/xml"},
+		{"*/*, application/json;q=0", "application/xml"},
+		{"application/*;q=0, application/json", "application/json"},
+		{"application/json;q=0", ""},
+		{"*/*;q=0", ""},
+		{"application/json;q=0.001, application/xml;q=0", "application/json"},
+		{"application/xml;q=abc, application/json;q=0.1", "application/json"},
+		{"application/xml;q=1.5", ""},
+		{"application/xml;q=-1, */*;q=0.5", "application/json"},
+		{"application/json; charset=utf-8; q=0.8, application/xml; level=1; q=0.9", "application/xml"},
+		{" application/json ; Q=0.5 ,, application/xml;q=0.4", "application/json"},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "application/xml"},
+	}
+	for _, tt := range tests {
+		if got := generichttp.NegotiateContentType(tt.accept, offers); got != tt.want {
+			t.Errorf("NegotiateContentType(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+
+	if got := generichttp.NegotiateContentType("", nil); got != "" {
+		t.Errorf("NegotiateContentType without offers = %q, want none", got)
+	}
+	if got := generichttp.NegotiateContentType("text/html", []string{"Text/HTML"}); got != "Text/HTML" {
+		t.Errorf("NegotiateContentType = %q, want the offer as given", got)
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	offers := []string{"gzip", "deflate", "identity"}
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", "identity"},
+		{"gzip", "gzip"},
+		{"GZIP", "gzip"},
+		{"deflate, gzip", "gzip"},
+		{"deflate;q=0.5, gzip;q=0.4", "deflate"},
+		{"gzip;q=0, deflate", "deflate"},
+		{"br", "identity"},
+		{"*", "gzip"},
+		{"gzip;q=0.5, *;q=0.8", "deflate"},
+		{"*;q=0.8, gzip;q=0.5", "deflate"},
+		{"*;q=0", ""},
+		{"*;q=0, identity", "identity"},
+		{"identity;q=0", ""},
+		{"identity;q=0, gzip;q=0.1", "gzip"},
+		{"gzip;q=0, identity;q=0", ""},
+		{"identity, gzip", "gzip"},
+		{"identity;q=1, gzip;q=0.5", "identity"},
+		{"gzip;q=x", "identity"},
+	}
+	for _, tt := range tests {
+		if got := generichttp.NegotiateEncoding(tt.header, offers); got != tt.want {
+			t.Errorf("NegotiateEncoding(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestStrictAccept(t *testing.T) {
+	type item struct {
+		Name string `json:"name" xml:"name"`
+	}
+	var called bool
+	h := func(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[item], error) {
+		called = true
+		return generichttp.OK(&item{Name: "Widget"}), nil
+	}
+	const (
+		jsonType = "application/json"
+		xmlType  = "application/xml"
+	)
+	tests := []struct {
+		name        string
+		handler     http.Handler
+		accept      string
+		code        int
+		contentType string
+		message     string
+	}{
+		{"JSON/html", generichttp.JSON(h), "text/html", http.StatusOK, jsonType, ""},
+		{"JSON/xml", generichttp.JSON(h), xmlType, http.StatusOK, jsonType, ""},
+		{"JSON/strict/html", generichttp.JSON(h, generichttp.WithStrictAccept()), "text/html", http.StatusNotAcceptable, jsonType, "Not acceptable, supported media types: application/json"},
+		{"JSON/strict/xml", generichttp.JSON(h, generichttp.WithStrictAccept()), xmlType, http.StatusNotAcceptable, jsonType, "Not acceptable, supported media types: application/json"},
+		{"JSON/strict/json", generichttp.JSON(h, generichttp.WithStrictAccept()), "application/*", http.StatusOK, jsonType, ""},
+		{"JSON/strict/none", generichttp.JSON(h, generichttp.WithStrictAccept()), "", http.StatusOK, jsonType, ""},
+		{"Auto/html", generichttp.Auto(h), "text/html", http.StatusOK, jsonType, ""},
+		{"Auto/xml", generichttp.Auto(h), xmlType, http.StatusOK, xmlType, ""},
+		{"Auto/strict/html", generichttp.Auto(h, generichttp.WithStrictAccept()), "text/html", http.StatusNotAcceptable, jsonType, "Not acceptable, supported media types: application/json, application/xml"},
+		{"Auto/strict/excluded", generichttp.Auto(h, generichttp.WithStrictAccept()), "*/*, application/json;q=0, application/xml;q=0", http.StatusNotAcceptable, jsonType, "Not acceptable, supported media types: application/json, application/xml"},
+		{"Auto/strict/xml", generichttp.Auto(h, generichttp.WithStrictAccept()), xmlType, http.StatusOK, xmlType, ""},
+		{"Auto/strict/none", generichttp.Auto(h, generichttp.WithStrictAccept()), "", http.StatusOK, jsonType, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called = false
+			r := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, r)
+			if w.Code != tt.code {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.code, w.Body)
+			}
+			if ct, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type")); ct != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", w.Header().Get("Content-Type"), tt.contentType)
+			}
+			if tt.message != "" {
+				if called {
+					t.Error("handler called for an unacceptable request")
+				}
+				testutil.AssertJSON(t, w, map[string]any{"message": tt.message})
+			} else if !called {
+				t.Error("handler not called")
+			}
+		})
+	}
+}
+
+func TestStrictAcceptRenderer(t *testing.T) {
+	h := generichttp.JSON(func(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[generichttp.TextResponse], error) {
+		return generichttp.Text(http.StatusOK, "hello"), nil
+	}, generichttp.WithStrictAccept())
+	r := httptest.NewRequest(http.MethodGet, "/hello", nil)
+	r.Header.Set("Accept", "text/plain")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Body.String() != "hello" {
+		t.Errorf("status = %d, body = %q; want %d, hello", w.Code, w.Body, http.StatusOK)
+	}
+}
@@ -0,0 +1,67 @@
+package generichttp
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// normalizers are the operations supported by the normalize struct tag.
+var normalizers = map[string]func(string) string{
+	"trim":  strings.TrimSpace,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"nfc":   norm.NFC.String,
+}
+
+// normalizeRequest applies the normalize struct tags of the decoded
+// request data. See NewRequest for the supported operations.
+func normalizeRequest(data any) error {
+	if data == nil || !typeHasTag(reflect.TypeOf(data), "normalize") {
+		return nil
+	}
+	return walkFields(reflect.ValueOf(data), "", normalizeField)
+}
+
+// normalizeField applies the normalize struct tag of field f to v.
+func normalizeField(f reflect.StructField, v reflect.Value, path string) error {
+	tag := f.Tag.Get("normalize")
+	if tag == "" {
+		return nil
+	}
+	var ops []func(string) string
+	for _, name := range strings.Split(tag, ",") {
+		op, found := normalizers[strings.TrimSpace(name)]
+		if !found {
+			return fmt.Errorf("generichttp: invalid normalize tag on field %s: %q", f.Name, tag)
+		}
+		ops = append(ops, op)
+	}
+	if !v.CanSet() {
+		return nil
+	}
+	normalizeValue(v, ops)
+	return nil
+}
+
+// normalizeValue applies ops to the strings in v.
+func normalizeValue(v reflect.Value, ops []func(string) string) {
+	switch v.Kind() {
+	case reflect.String:
+		s := v.String()
+		for _, op := range ops {
+			s = op(s)
+		}
+		v.SetString(s)
+	case reflect.Pointer:
+		if !v.IsNil() {
+			normalizeValue(v.Elem(), ops)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			normalizeValue(v.Index(i), ops)
+		}
+	}
+}
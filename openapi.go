@@ -0,0 +1,202 @@
+package generichttp
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// OpenAPI is an OpenAPI 3 document.
+type OpenAPI struct {
+	OpenAPI string                     `json:"openapi"`
+	Info    OpenAPIInfo                `json:"info"`
+	Paths   map[string]OpenAPIPathItem `json:"paths"`
+}
+
+// OpenAPIInfo is the info object of an OpenAPI document.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// OpenAPIPathItem holds the operations of a path, keyed by the
+// lower-case HTTP method.
+type OpenAPIPathItem map[string]*OpenAPIOperation
+
+// OpenAPIOperation describes a single route.
+type OpenAPIOperation struct {
+	Summary     string                      `json:"summary,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Parameters  []OpenAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+	Deprecated  bool                        `json:"deprecated,omitempty"`
+}
+
+// OpenAPIParameter describes a parameter of an operation.
+type OpenAPIParameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+// OpenAPIRequestBody describes the request body of an operation.
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse describes a response of an operation.
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType holds the schema of a request or response body.
+type OpenAPIMediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// wildcardRegexp matches wildcards in http.ServeMux patterns.
+var wildcardRegexp = regexp.MustCompile(`\{([^}]*)\}`)
+
+// errorSchema is the schema of the errors rendered by WriteJSONError.
+var errorSchema = &Schema{
+	Type: "object",
+	Properties: map[string]*Schema{
+		"message": {Type: "string"},
+		"errors": {
+			Type: "array",
+			Items: &Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"status":  {Type: "integer"},
+					"message": {Type: "string"},
+				},
+				Required: []string{"status", "message"},
+			},
+		},
+		"fields": {
+			Type:  "array",
+			Items: &Schema{Type: "string"},
+		},
+	},
+	Required: []string{"message"},
+}
+
+// OpenAPI returns an OpenAPI 3 document describing all routes registered
+// with JSON handlers. Request and response schemas are derived from the
+// type parameters of the handlers, see SchemaFor. Use WithSummary and
+// WithTags on registration to annotate operations.
+func (rt *Router) OpenAPI(info OpenAPIInfo) (*OpenAPI, error) {
+	doc := &OpenAPI{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   make(map[string]OpenAPIPathItem),
+	}
+	patterns := make(map[string]*route)
+	rt.eachRoute(func(pattern string, rte *route) {
+		patterns[pattern] = rte
+	})
+	for pattern, rte := range patterns {
+		path, params := openAPIPath(pattern)
+		for method, e := range rte.endpoints {
+			th, ok := e.handler.(typedHandler)
+			if !ok {
+				continue
+			}
+			reqType, respType := th.dataTypes()
+			op := &OpenAPIOperation{
+				Summary:    e.summary,
+				Tags:       e.tags,
+				Parameters: params,
+				Deprecated: e.deprecation != nil,
+				Responses: map[string]*OpenAPIResponse{
+					"default": {
+						Description: "Error",
+						Content:     map[string]OpenAPIMediaType{"application/json": {Schema: errorSchema}},
+					},
+				},
+			}
+			if hasRequestBody(method) && !isEmptyStruct(reqType) {
+				schema, err := schemaOf(reqType)
+				if err != nil {
+					return nil, err
+				}
+				op.RequestBody = &OpenAPIRequestBody{
+					Required: true,
+					Content:  map[string]OpenAPIMediaType{"application/json": {Schema: schema}},
+				}
+			}
+			resp := &OpenAPIResponse{Description: http.StatusText(http.StatusOK)}
+			if rd, ok := reflect.New(respType).Interface().(renderer); ok {
+				mediaType, schema := rd.content()
+				resp.Content = map[string]OpenAPIMediaType{mediaType: {Schema: schema}}
+			} else if !isEmptyStruct(respType) {
+				schema, err := schemaOf(respType)
+				if err != nil {
+					return nil, err
+				}
+				resp.Content = map[string]OpenAPIMediaType{"application/json": {Schema: schema}}
+			}
+			op.Responses["200"] = resp
+			if doc.Paths[path] == nil {
+				doc.Paths[path] = make(OpenAPIPathItem)
+			}
+			doc.Paths[path][strings.ToLower(method)] = op
+		}
+	}
+	return doc, nil
+}
+
+// OpenAPIHandler returns a http.Handler that serves the OpenAPI document
+// of the router, e.g. at "/openapi.json".
+func (rt *Router) OpenAPIHandler(info OpenAPIInfo) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		doc, err := rt.OpenAPI(info)
+		if err != nil {
+			WriteJSONError(w, err)
+			return
+		}
+		WriteJSON(w, doc)
+	})
+}
+
+// openAPIPath converts a http.ServeMux pattern into an OpenAPI path and
+// its path parameters, e.g. "/items/{id}" or "/files/{path...}".
+func openAPIPath(pattern string) (string, []OpenAPIParameter) {
+	if i := strings.Index(pattern, "/"); i > 0 {
+		pattern = pattern[i:] // strip host
+	}
+	pattern = strings.TrimSuffix(pattern, "{$}")
+	var params []OpenAPIParameter
+	path := wildcardRegexp.ReplaceAllStringFunc(pattern, func(s string) string {
+		name := strings.TrimSuffix(s[1:len(s)-1], "...")
+		params = append(params, OpenAPIParameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+		return "{" + name + "}"
+	})
+	return path, params
+}
+
+// hasRequestBody reports whether requests with the given method usually
+// have a body.
+func hasRequestBody(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		return true
+	}
+	return false
+}
+
+// isEmptyStruct reports whether t is a struct without fields, e.g. struct{}.
+func isEmptyStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t.NumField() == 0
+}
@@ -0,0 +1,110 @@
+package generichttp
+
+import (
+	"context"
+	"net/http"
+)
+
+// Option configures the handlers returned by JSON and Auto. Options given
+// to a handler take precedence over those of the Router it is registered
+// with, see NewRouter.
+type Option func(*Options)
+
+// Options holds the configuration of the handlers returned by JSON and
+// Auto. Each field has an Option that sets it, e.g. WithMaxBodySize for
+// MaxBodySize. Use Options to configure once and share the configuration
+// across routes, e.g. with NewRouter and WithOptions. The zero value
+// equals DefaultOptions.
+type Options struct {
+	// ValidateUTF8 rejects invalid UTF-8 in request data, see
+	// WithValidateUTF8.
+	ValidateUTF8 bool
+	// ValidateEnum validates enum struct tags, see WithEnumValidation.
+	ValidateEnum bool
+	// MaxQueryParams limits the number of query parameters, see
+	// WithMaxQueryParams. Zero means no limit.
+	MaxQueryParams int
+	// MaxHeaders limits the number of request headers, see WithMaxHeaders.
+	// Zero means no limit.
+	MaxHeaders int
+	// FieldSelection enables the fields query parameter, see
+	// WithFieldSelection.
+	FieldSelection bool
+	// EmptyCollections renders nil slices and maps as [] and {}, see
+	// WithEmptyCollections.
+	EmptyCollections bool
+	// MaxBodySize limits the size of request bodies in bytes, see
+	// WithMaxBodySize. Zero means the default of 1 MiB.
+	MaxBodySize int64
+	// VerboseDecodeErrors includes the messages of the decoder in errors,
+	// see WithVerboseDecodeErrors.
+	VerboseDecodeErrors bool
+	// NamingPolicy renames the fields of JSON responses, see
+	// WithNamingPolicy. Nil keeps the names.
+	NamingPolicy NamingPolicy
+	// DevErrors includes the details of internal errors in responses, see
+	// WithDevErrors.
+	DevErrors bool
+	// StreamBody passes the request body to the handler instead of
+	// decoding it, see WithStreamBody.
+	StreamBody bool
+	// DecompressBody decompresses gzip and deflate request bodies, see
+	// WithDecompressBody.
+	DecompressBody bool
+	// StrictAccept rejects requests that accept none of the media types
+	// of a handler, see WithStrictAccept.
+	StrictAccept bool
+	// Precondition checks requests before their body is read, see
+	// WithPrecondition.
+	Precondition func(r *http.Request) error
+	// EmptyResponse sets how responses without data are rendered, see
+	// WithEmptyResponse.
+	EmptyResponse EmptyResponse
+	// EncoderSettings selects the settings for encoding each response, see
+	// WithEncoderSettings. Nil uses the global settings.
+	EncoderSettings func(ctx context.Context) *EncoderSettings
+}
+
+// DefaultOptions returns the default options of JSON and Auto: request
+// bodies are limited to 1 MiB, and all other checks and features are off.
+func DefaultOptions() Options {
+	return *defaultOptions
+}
+
+// Apply returns a copy of o with opts applied, in order.
+func (o Options) Apply(opts ...Option) Options {
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithOptions sets all options to o, e.g. to start from a shared
+// configuration. Options after it override single fields.
+func WithOptions(o Options) Option {
+	return func(dst *Options) {
+		*dst = o
+	}
+}
+
+// defaultOptions are used when no options are given, e.g. in NewRequest.
+var defaultOptions = &Options{MaxBodySize: defaultMaxBodySize}
+
+// newOptions returns base with opts applied.
+func newOptions(base Options, opts []Option) *Options {
+	o := base.Apply(opts...)
+	return &o
+}
+
+// WithValidateUTF8 rejects requests with invalid UTF-8 in their string
+// data with a BadRequestError.
+//
+// As encoding/json silently replaces invalid UTF-8 with U+FFFD, the raw
+// request body is checked before decoding. After decoding, all strings in
+// the request data are checked via reflection, which covers e.g. form data.
+// Both checks add some overhead, so this option is off by default.
+func WithValidateUTF8() Option {
+	return func(o *Options) {
+		o.ValidateUTF8 = true
+	}
+}
@@ -0,0 +1,76 @@
+package generichttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/olivere/generichttp"
+)
+
+type optionsNote struct {
+	Text string `json:"text"`
+}
+
+func echoOptionsNote(w http.ResponseWriter, req generichttp.Request[optionsNote]) (*generichttp.Response[optionsNote], error) {
+	return generichttp.OK(req.Data), nil
+}
+
+func TestOptionsPrecedence(t *testing.T) {
+	router := generichttp.NewRouter(generichttp.WithMaxBodySize(32), generichttp.WithValidateUTF8())
+	router.Post("/global", generichttp.JSON(echoOptionsNote))
+	router.Post("/route", generichttp.JSON(echoOptionsNote, generichttp.WithMaxBodySize(64)))
+	router.Post("/auto", generichttp.Auto(echoOptionsNote, generichttp.WithMaxBodySize(64)))
+	router.Post("/reset", generichttp.JSON(echoOptionsNote, generichttp.WithOptions(generichttp.DefaultOptions())))
+	router.Post("/wrapped", generichttp.Recover()(generichttp.JSON(echoOptionsNote)))
+	sub := router.Subrouter("/v1")
+	sub.Post("/global", generichttp.JSON(echoOptionsNote))
+	sub.Post("/route", generichttp.JSON(echoOptionsNote, generichttp.WithMaxBodySize(64)))
+
+	large := `{"text":"` + strings.Repeat("x", 40) + `"}` // over 32 bytes, under 64
+	invalid := "{\"text\":\"\xff\"}"
+	tests := []struct {
+		target string
+		body   string
+		code   int
+	}{
+		{"/global", large, http.StatusRequestEntityTooLarge},
+		{"/global", invalid, http.StatusBadRequest},
+		{"/route", large, http.StatusOK},
+		{"/route", invalid, http.StatusBadRequest},
+		{"/auto", large, http.StatusOK},
+		{"/auto", invalid, http.StatusBadRequest},
+		{"/reset", large, http.StatusOK},
+		{"/reset", invalid, http.StatusOK},
+		{"/wrapped", large, http.StatusOK},
+		{"/v1/global", large, http.StatusRequestEntityTooLarge},
+		{"/v1/route", large, http.StatusOK},
+		{"/v1/route", invalid, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != tt.code {
+			t.Errorf("POST %s %q: status = %d, want %d: %s", tt.target, tt.body, w.Code, tt.code, w.Body)
+		}
+	}
+}
+
+func TestOptionsApply(t *testing.T) {
+	base := generichttp.DefaultOptions()
+	o := base.Apply(generichttp.WithMaxBodySize(1), generichttp.WithValidateUTF8(), generichttp.WithMaxBodySize(2))
+	if o.MaxBodySize != 2 || !o.ValidateUTF8 {
+		t.Errorf("MaxBodySize = %d, ValidateUTF8 = %v; want 2, true", o.MaxBodySize, o.ValidateUTF8)
+	}
+	if base.MaxBodySize != 1<<20 || base.ValidateUTF8 {
+		t.Errorf("base changed to MaxBodySize = %d, ValidateUTF8 = %v", base.MaxBodySize, base.ValidateUTF8)
+	}
+
+	o = base.Apply(generichttp.WithValidateUTF8(), generichttp.WithOptions(generichttp.Options{MaxHeaders: 5}))
+	if o.ValidateUTF8 || o.MaxHeaders != 5 {
+		t.Errorf("ValidateUTF8 = %v, MaxHeaders = %d; want WithOptions to replace all options", o.ValidateUTF8, o.MaxHeaders)
+	}
+}
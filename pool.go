@@ -0,0 +1,105 @@
+package generichttp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+// smallBodySize is the maximum Content-Length of request bodies that are
+// read into a pooled buffer before decoding.
+const smallBodySize = 32 << 10
+
+// bufferPool holds buffers for reading small request bodies.
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// decodeJSONBody decodes the JSON body of r into v. The body must be
+// limited in size by the caller, see limitBody.
+//
+// Bodies with a known, small Content-Length are read into a pooled buffer
+// and decoded with Unmarshal, which saves the allocations of a Decoder and
+// its internal buffer. The buffer is reused for other requests once
+// Unmarshal returns, see Unmarshal. All other bodies are streamed into a
+// Decoder, see decodeValue. Both reject data after the JSON value.
+func decodeJSONBody(r *http.Request, v any) error {
+	if typeHasTag(reflect.TypeOf(v), "time") {
+		return decodeJSONBodyWithTimes(r, v)
+	}
+	if n := r.ContentLength; n > 0 && n <= smallBodySize {
+		buf := bufferPool.Get().(*bytes.Buffer)
+		defer bufferPool.Put(buf)
+		buf.Reset()
+		buf.Grow(int(n))
+		data := buf.AvailableBuffer()[:n]
+		if _, err := io.ReadFull(r.Body, data); err != nil {
+			return err
+		}
+		return Unmarshal(data, v)
+	}
+	return decodeValue(NewDecoder(r.Body), v)
+}
+
+// decodeValue decodes the next JSON value of dec into v. Like Unmarshal,
+// it returns an error if anything but white space follows the value.
+func decodeValue(dec Decoder, v any) error {
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	var extra json.RawMessage
+	err := dec.Decode(&extra)
+	switch {
+	case err == io.EOF:
+		return nil
+	case err != nil:
+		return err
+	}
+	e := &trailingDataError{c: extra[0]}
+	if d, ok := dec.(interface{ InputOffset() int64 }); ok {
+		e.Offset = d.InputOffset() - int64(len(extra)) + 1
+	}
+	return e
+}
+
+// pooledEncoder is an Encoder writing through a buffered writer. Both are
+// reused across responses via encoderPool.
+//
+// Decoders are not pooled: json.Decoder cannot be reset, and reusing one
+// would leak buffered data of one request into the next.
+type pooledEncoder struct {
+	bw  *bufio.Writer
+	enc Encoder
+}
+
+// encoderPool holds pooledEncoders.
+var encoderPool = sync.Pool{
+	New: func() any {
+		pe := &pooledEncoder{bw: bufio.NewWriter(nil)}
+		pe.enc = NewEncoder(pe.bw)
+		return pe
+	},
+}
+
+// encodeJSON encodes v to w with a pooled Encoder. The output is streamed
+// to w in chunks of the size of the buffered writer, i.e. large responses
+// are not buffered completely. The writer is detached after encoding so
+// that w is not retained in the pool. Encoders that failed are not
+// returned to the pool, as encoders may keep errors.
+func encodeJSON(w io.Writer, v any) error {
+	pe := encoderPool.Get().(*pooledEncoder)
+	pe.bw.Reset(w)
+	err := pe.enc.Encode(v)
+	if err == nil {
+		err = pe.bw.Flush()
+	}
+	pe.bw.Reset(nil)
+	if err == nil {
+		encoderPool.Put(pe)
+	}
+	return err
+}
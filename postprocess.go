@@ -0,0 +1,49 @@
+package generichttp
+
+import (
+	"context"
+	"net/http"
+)
+
+// PostProcessor transforms the status code and data of a response before
+// it is encoded as JSON, e.g. to wrap the data in an envelope or to mask
+// sensitive fields. The context is the one of the request, or
+// context.Background() for WriteJSONCode, which has no request.
+//
+// A PostProcessor cannot fail. If it cannot transform the data, it should
+// return a status code and data describing the error instead, e.g.
+// http.StatusInternalServerError and map[string]string{"message": "..."}.
+// Panics are not recovered.
+type PostProcessor func(ctx context.Context, status int, data any) (int, any)
+
+// postProcessors are the registered post-processors, in order.
+var postProcessors []PostProcessor
+
+// RegisterPostProcessor registers p to run on every response written with
+// WriteJSONCode, including the responses of JSON and Auto rendered as
+// JSON. Post-processors run in the order of registration, each getting
+// the status code and data returned by the previous one. Error responses,
+// XML, and responses that render themselves, e.g. Text, are not processed.
+//
+// Register post-processors on startup, before serving requests. Without
+// any, responses are written unchanged.
+func RegisterPostProcessor(p PostProcessor) {
+	postProcessors = append(postProcessors, p)
+}
+
+// postProcess applies the registered post-processors.
+func postProcess(ctx context.Context, status int, data any) (int, any) {
+	for _, p := range postProcessors {
+		status, data = p(ctx, status, data)
+	}
+	return status, data
+}
+
+// requestContext returns the context of r, or context.Background() if r
+// is nil.
+func requestContext(r *http.Request) context.Context {
+	if r == nil {
+		return context.Background()
+	}
+	return r.Context()
+}
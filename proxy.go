@@ -0,0 +1,209 @@
+package generichttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httputil"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ProxyOptions configures the handler returned by Proxy.
+type ProxyOptions struct {
+	// Rewrite modifies the request to the upstream service, e.g. to add
+	// credentials. It is called after the URL is set to the target, the
+	// headers are filtered by RequestHeaders, and the X-Forwarded headers
+	// are set.
+	Rewrite func(*httputil.ProxyRequest)
+	// ModifyResponse modifies the response of the upstream service. It is
+	// called after the headers are filtered by ResponseHeaders. If it
+	// returns an error, the client gets the error in the JSON error format
+	// instead, see Proxy.
+	ModifyResponse func(*http.Response) error
+	// RequestHeaders lists the headers of the client request that are
+	// forwarded upstream, e.g. "Accept" and "Authorization". Nil forwards
+	// all headers. Hop-by-hop headers are never forwarded.
+	RequestHeaders []string
+	// ResponseHeaders lists the headers of the upstream response that are
+	// passed to the client, e.g. "Cache-Control" and "ETag". Nil passes all
+	// headers. Hop-by-hop headers are never passed.
+	ResponseHeaders []string
+	// XForwarded sets the X-Forwarded-For, X-Forwarded-Host, and
+	// X-Forwarded-Proto headers of the upstream request. They are set after
+	// filtering by RequestHeaders, so they don't need to be allowed there.
+	XForwarded bool
+	// Transport performs the upstream requests. It defaults to
+	// http.DefaultTransport.
+	Transport http.RoundTripper
+	// FlushInterval is the interval of flushing the response body to the
+	// client while copying it, see httputil.ReverseProxy. A negative
+	// value flushes after each write, e.g. for server-sent events.
+	FlushInterval time.Duration
+}
+
+// bodyHeaders describe the body of a request or response and are always
+// forwarded by Proxy, regardless of the allowlists.
+var bodyHeaders = []string{"Content-Type", "Content-Length", "Content-Encoding"}
+
+// Proxy returns a handler that forwards requests to the upstream service
+// at target, like httputil.ReverseProxy, e.g. for an API gateway:
+//
+//	users, _ := url.Parse("http://users.internal:8080/api")
+//	router.Mount("/users", generichttp.Proxy(users, generichttp.ProxyOptions{
+//		RequestHeaders:  []string{"Accept", "Authorization"},
+//		ResponseHeaders: []string{"Cache-Control", "ETag"},
+//	}))
+//
+// The path of the request is appended to the path of target, and the
+// query parameters of both are combined. Headers can be restricted with
+// allowlists; the Content-Type, Content-Length, and Content-Encoding
+// headers are always forwarded, and hop-by-hop headers never.
+//
+// Failures are rendered in the JSON error format of the package: an
+// upstream that can't be reached is answered with a BadGatewayError, and
+// one that times out with a GatewayTimeoutError, see UpstreamError. An
+// error returned by ModifyResponse is rendered as is if it has an HTTP
+// code, e.g. a ForbiddenError, and as a BadGatewayError otherwise. Nothing
+// is written if the client went away. Errors are logged with ErrorLog.
+func Proxy(target *url.URL, opts ProxyOptions) http.Handler {
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			if opts.RequestHeaders != nil {
+				pr.Out.Header = filterHeaders(pr.Out.Header, opts.RequestHeaders)
+			}
+			if opts.XForwarded {
+				pr.SetXForwarded()
+			}
+			if opts.Rewrite != nil {
+				opts.Rewrite(pr)
+			}
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			if opts.ResponseHeaders != nil {
+				resp.Header = filterHeaders(resp.Header, opts.ResponseHeaders)
+			}
+			if opts.ModifyResponse != nil {
+				if err := opts.ModifyResponse(resp); err != nil {
+					return proxyResponseError{err}
+				}
+			}
+			return nil
+		},
+		Transport:     opts.Transport,
+		FlushInterval: opts.FlushInterval,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			if errors.Is(err, context.Canceled) && r.Context().Err() != nil {
+				return
+			}
+			var pe proxyResponseError
+			if errors.As(err, &pe) {
+				if _, ok := pe.err.(interface{ HTTPCode() int }); ok {
+					writeJSONError(w, r, pe.err)
+					return
+				}
+				err = pe.err
+			}
+			writeJSONError(w, r, UpstreamError(err))
+		},
+	}
+}
+
+// proxyResponseError wraps an error of ProxyOptions.ModifyResponse, so that
+// Proxy can tell it from transport errors.
+type proxyResponseError struct {
+	err error
+}
+
+// Error implements the error interface.
+func (e proxyResponseError) Error() string { return e.err.Error() }
+
+// Unwrap returns the error of ModifyResponse.
+func (e proxyResponseError) Unwrap() error { return e.err }
+
+// filterHeaders returns the headers of h that are in allow or describe
+// the body, without hop-by-hop headers.
+func filterHeaders(h http.Header, allow []string) http.Header {
+	filtered := make(http.Header, len(allow)+len(bodyHeaders))
+	PassHeaders(filtered, h, bodyHeaders)
+	PassHeaders(filtered, h, allow)
+	return filtered
+}
+
+// hopByHopHeaders are the headers that apply to a single connection and
+// must not be forwarded by proxies, see RFC 7230, section 6.1.
+var hopByHopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// PassHeaders copies the headers of the upstream response that are in
+// allow to the header of resp, e.g. in a handler that proxies to another
+// service:
+//
+//	up, err := client.Do(upstreamReq)
+//	...
+//	return generichttp.OK(&item).PassHeaders(up, "Cache-Control", "ETag", "Last-Modified"), nil
+//
+// Header names are case-insensitive. Hop-by-hop headers, i.e. Connection,
+// Keep-Alive, Transfer-Encoding and the others of RFC 7230, and headers
+// listed in the Connection header of upstream are never copied, even if
+// allowed. Copied headers replace those of resp, except for Set-Cookie,
+// whose values are added. It returns resp.
+func (resp *Response[T]) PassHeaders(upstream *http.Response, allow ...string) *Response[T] {
+	if upstream == nil {
+		return resp
+	}
+	if resp.Header == nil {
+		resp.Header = make(http.Header)
+	}
+	PassHeaders(resp.Header, upstream.Header, allow)
+	return resp
+}
+
+// PassHeaders copies the headers of src that are in allow to dst, except
+// for hop-by-hop headers, like Response.PassHeaders. Use it to forward
+// headers to a http.ResponseWriter or an upstream request.
+func PassHeaders(dst, src http.Header, allow []string) {
+	hopByHop := connectionHeaders(src)
+	for _, name := range allow {
+		name = textproto.CanonicalMIMEHeaderKey(name)
+		values := src[name]
+		if len(values) == 0 || hopByHop[name] {
+			continue
+		}
+		values = append([]string(nil), values...)
+		if name == "Set-Cookie" {
+			dst[name] = append(dst[name], values...)
+			continue
+		}
+		dst[name] = values
+	}
+}
+
+// connectionHeaders returns the hop-by-hop headers of h: the standard ones
+// and those listed in its Connection header.
+func connectionHeaders(h http.Header) map[string]bool {
+	names := make(map[string]bool, len(hopByHopHeaders))
+	for _, name := range hopByHopHeaders {
+		names[name] = true
+	}
+	for _, v := range h.Values("Connection") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names[textproto.CanonicalMIMEHeaderKey(name)] = true
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,258 @@
+package generichttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func mustParseURL(t *testing.T, s string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestProxyHeaderAllowlists(t *testing.T) {
+	var got http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("X-Internal", "secret")
+		io.WriteString(w, `{}`)
+	}))
+	defer upstream.Close()
+
+	h := Proxy(mustParseURL(t, upstream.URL), ProxyOptions{
+		RequestHeaders:  []string{"Accept"},
+		ResponseHeaders: []string{"ETag"},
+		XForwarded:      true,
+	})
+	r := httptest.NewRequest(http.MethodGet, "http://api.example.com/items", nil)
+	r.Header.Set("Accept", "application/json")
+	r.Header.Set("Authorization", "Bearer token")
+	r.Header.Set("Cookie", "session=1")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if v := got.Get("Accept"); v != "application/json" {
+		t.Errorf("upstream Accept = %q, want it forwarded", v)
+	}
+	for _, name := range []string{"Authorization", "Cookie"} {
+		if v := got.Get(name); v != "" {
+			t.Errorf("upstream %s = %q, want it filtered", name, v)
+		}
+	}
+	want := map[string]string{
+		"X-Forwarded-For":   "192.0.2.1",
+		"X-Forwarded-Host":  "api.example.com",
+		"X-Forwarded-Proto": "http",
+	}
+	for name, v := range want {
+		if got.Get(name) != v {
+			t.Errorf("upstream %s = %q, want %q", name, got.Get(name), v)
+		}
+	}
+	if v := w.Header().Get("ETag"); v != `"v1"` {
+		t.Errorf("ETag = %q, want it passed", v)
+	}
+	if v := w.Header().Get("Content-Type"); v != "application/json" {
+		t.Errorf("Content-Type = %q, want it passed", v)
+	}
+	if v := w.Header().Get("X-Internal"); v != "" {
+		t.Errorf("X-Internal = %q, want it filtered", v)
+	}
+}
+
+func TestProxyUpstreamErrors(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closedURL := closed.URL
+	closed.Close()
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+
+	tests := []struct {
+		name   string
+		target string
+		opts   ProxyOptions
+		code   int
+		msg    string
+	}{
+		{
+			name:   "unreachable",
+			target: closedURL,
+			code:   http.StatusBadGateway,
+			msg:    "Bad gateway",
+		},
+		{
+			name:   "deadline exceeded",
+			target: "http://upstream.invalid",
+			opts: ProxyOptions{Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+				return nil, context.DeadlineExceeded
+			})},
+			code: http.StatusGatewayTimeout,
+			msg:  "Gateway timeout",
+		},
+		{
+			name:   "response header timeout",
+			target: slow.URL,
+			opts:   ProxyOptions{Transport: &http.Transport{ResponseHeaderTimeout: 20 * time.Millisecond}},
+			code:   http.StatusGatewayTimeout,
+			msg:    "Gateway timeout",
+		},
+		{
+			name:   "modify response error",
+			target: "http://upstream.invalid",
+			opts: ProxyOptions{
+				Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+					return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody, Request: r}, nil
+				}),
+				ModifyResponse: func(*http.Response) error { return errors.New("invalid upstream response") },
+			},
+			code: http.StatusBadGateway,
+			msg:  "Bad gateway",
+		},
+		{
+			name:   "modify response HTTP error",
+			target: "http://upstream.invalid",
+			opts: ProxyOptions{
+				Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+					return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody, Request: r}, nil
+				}),
+				ModifyResponse: func(*http.Response) error { return ForbiddenError{Message: "Not yours"} },
+			},
+			code: http.StatusForbidden,
+			msg:  "Not yours",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Proxy(mustParseURL(t, tt.target), tt.opts)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+			if w.Code != tt.code {
+				t.Fatalf("status = %d, want %d", w.Code, tt.code)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+			var body errorBody
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Message != tt.msg {
+				t.Errorf("message = %q, want %q", body.Message, tt.msg)
+			}
+		})
+	}
+}
+
+// hopByHopHeader returns hop-by-hop headers, including one named in the
+// Connection header, next to end-to-end headers.
+func hopByHopHeader() http.Header {
+	return http.Header{
+		"Connection":          {"keep-alive, X-Hop"},
+		"Keep-Alive":          {"timeout=5"},
+		"Proxy-Authorization": {"Basic Zm9vOmJhcg=="},
+		"Proxy-Connection":    {"keep-alive"},
+		"Trailer":             {"X-Checksum"},
+		"X-Hop":               {"1"},
+		"Accept":              {"application/json"},
+		"Cache-Control":       {"no-cache"},
+	}
+}
+
+var hopByHopNames = []string{"Connection", "Keep-Alive", "Proxy-Authorization", "Proxy-Connection", "Trailer", "X-Hop"}
+
+func TestPassHeadersHopByHop(t *testing.T) {
+	src := hopByHopHeader()
+	src["Set-Cookie"] = []string{"b=2"}
+	dst := http.Header{"Set-Cookie": {"a=1"}, "Cache-Control": {"private"}}
+	allow := append([]string{"accept", "cache-control", "set-cookie", "transfer-encoding"}, hopByHopNames...)
+	PassHeaders(dst, src, allow)
+
+	want := http.Header{
+		"Accept":        {"application/json"},
+		"Cache-Control": {"no-cache"},
+		"Set-Cookie":    {"a=1", "b=2"},
+	}
+	if !reflect.DeepEqual(dst, want) {
+		t.Errorf("headers = %v, want %v", dst, want)
+	}
+	dst["Accept"][0] = "text/plain"
+	if src.Get("Accept") != "application/json" {
+		t.Error("PassHeaders shares the values of src")
+	}
+
+	up := &http.Response{Header: hopByHopHeader()}
+	resp := OK(&struct{}{}).PassHeaders(up, allow...)
+	if !reflect.DeepEqual(resp.Header, http.Header{"Accept": {"application/json"}, "Cache-Control": {"no-cache"}}) {
+		t.Errorf("response headers = %v, want only end-to-end headers", resp.Header)
+	}
+	if resp := OK(&struct{}{}).PassHeaders(nil, "Accept"); resp.Header != nil {
+		t.Errorf("response headers = %v without upstream, want none", resp.Header)
+	}
+}
+
+func TestProxyHopByHop(t *testing.T) {
+	for name, allow := range map[string][]string{
+		"all headers": nil,
+		"allowlist":   append([]string{"Accept", "Cache-Control"}, hopByHopNames...),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var upstream http.Header
+			h := Proxy(mustParseURL(t, "http://upstream.invalid"), ProxyOptions{
+				RequestHeaders:  allow,
+				ResponseHeaders: allow,
+				Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+					upstream = r.Header.Clone()
+					return &http.Response{StatusCode: http.StatusOK, Header: hopByHopHeader(), Body: http.NoBody, Request: r}, nil
+				}),
+			})
+			r := httptest.NewRequest(http.MethodGet, "/items", nil)
+			r.Header = hopByHopHeader()
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			for _, name := range hopByHopNames {
+				if v := upstream.Get(name); v != "" {
+					t.Errorf("upstream %s = %q, want it removed", name, v)
+				}
+				if v := w.Header().Get(name); v != "" {
+					t.Errorf("response %s = %q, want it removed", name, v)
+				}
+			}
+			for _, hdr := range []http.Header{upstream, w.Header()} {
+				if hdr.Get("Accept") != "application/json" || hdr.Get("Cache-Control") != "no-cache" {
+					t.Errorf("headers = %v, want the end-to-end headers", hdr)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package generichttp
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// QuotaStore counts requests for the Quota middleware. Implementations
+// must be safe for concurrent use, and should be shared between the
+// instances of a service, e.g. with INCR and EXPIRE in Redis.
+type QuotaStore interface {
+	// Take counts a request for key in the current window of the given
+	// length. Windows are fixed, i.e. they start at multiples of window
+	// since the Unix epoch, in UTC. It returns the number of requests in
+	// the window including this one, and the end of the window.
+	Take(ctx context.Context, key string, window time.Duration) (count int64, reset time.Time, err error)
+}
+
+// QuotaLimit is a limit of requests per window, e.g. 1000 per day.
+type QuotaLimit struct {
+	Limit  int64
+	Window time.Duration
+}
+
+// QuotaOptions configures the Quota middleware.
+type QuotaOptions struct {
+	// Store counts the requests. It defaults to a store in memory, see
+	// NewMemoryQuotaStore.
+	Store QuotaStore
+	// Key returns the key whose requests are counted, e.g. a tenant or an
+	// API key. It defaults to the tenant of the Tenant middleware, see
+	// TenantFromContext. Requests with an empty key are not counted.
+	Key func(r *http.Request) string
+	// Quotas are the limits per key, e.g. per minute against bursts and
+	// per day for billing. A request must be within all of them.
+	Quotas []QuotaLimit
+	// Message is the error message of rejected requests. It defaults to
+	// "Quota exceeded".
+	Message string
+}
+
+// Quota returns a middleware that enforces usage quotas per key, e.g. per
+// tenant:
+//
+//	quota := generichttp.Quota(generichttp.QuotaOptions{
+//		Quotas: []generichttp.QuotaLimit{
+//			{Limit: 100, Window: time.Minute},
+//			{Limit: 10000, Window: 24 * time.Hour},
+//		},
+//	})
+//	handler := tenant(quota(router))
+//
+// Every request counts against all quotas of its key, including rejected
+// ones, as the store only counts up. All responses have the rate limit
+// headers of the quota with the fewest remaining requests, see
+// SetRateLimitHeaders, and for older clients X-RateLimit-Limit,
+// X-RateLimit-Remaining, and X-RateLimit-Reset, the end of the window in
+// seconds since the Unix epoch. Handlers get the state with
+// RateLimitFromContext. Once a quota is exhausted, requests are rejected
+// with a TooManyRequestsError and a Retry-After header until its window
+// ends.
+//
+// If the store fails, the error is logged, see ErrorLog, and the request
+// is served, so that an outage of the store doesn't take down the
+// service.
+func Quota(opts QuotaOptions) Middleware {
+	if opts.Store == nil {
+		opts.Store = NewMemoryQuotaStore()
+	}
+	if opts.Key == nil {
+		opts.Key = func(r *http.Request) string {
+			id, _ := TenantFromContext(r.Context())
+			return id
+		}
+	}
+	if opts.Message == "" {
+		opts.Message = "Quota exceeded"
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := opts.Key(r)
+			if key == "" || len(opts.Quotas) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			var (
+				limit, remaining int64
+				reset            time.Time
+				exceeded         bool
+			)
+			for i, q := range opts.Quotas {
+				count, end, err := opts.Store.Take(r.Context(), key+"|"+q.Window.String(), q.Window)
+				if err != nil {
+					logError(r, http.StatusInternalServerError, err)
+					next.ServeHTTP(w, r)
+					return
+				}
+				left := max(q.Limit-count, 0)
+				over := count > q.Limit
+				// Report the quota that is exceeded, or the one with the
+				// fewest requests left; of several exceeded ones, the one
+				// that resets last.
+				if i == 0 || (over && (!exceeded || end.After(reset))) || (!exceeded && !over && left < remaining) {
+					limit, remaining, reset = q.Limit, left, end
+				}
+				exceeded = exceeded || over
+			}
+			state := RateLimitState{Limit: limit, Remaining: remaining, Reset: reset}
+			h := w.Header()
+			SetRateLimitHeaders(h, state)
+			h.Set("X-RateLimit-Limit", strconv.FormatInt(limit, 10))
+			h.Set("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+			h.Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+			if exceeded {
+				h.Set("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(reset).Seconds()))))
+				writeJSONError(w, r, TooManyRequestsError{Message: opts.Message})
+				return
+			}
+			next.ServeHTTP(w, WithRateLimit(r, state))
+		})
+	}
+}
+
+// memoryQuotaStore is a QuotaStore in memory.
+type memoryQuotaStore struct {
+	mu        sync.Mutex
+	counters  map[string]memoryQuotaCounter
+	lastSweep time.Time
+}
+
+type memoryQuotaCounter struct {
+	count int64
+	reset time.Time
+}
+
+// NewMemoryQuotaStore returns a QuotaStore that counts requests in memory.
+// Counters of past windows are removed at most once a minute while the
+// store is in use. The counts are not shared between instances of a
+// service, so each instance enforces the quotas on its own.
+func NewMemoryQuotaStore() QuotaStore {
+	return &memoryQuotaStore{counters: make(map[string]memoryQuotaCounter)}
+}
+
+// Take implements QuotaStore.
+func (s *memoryQuotaStore) Take(_ context.Context, key string, window time.Duration) (int64, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		for k, c := range s.counters {
+			if !now.Before(c.reset) {
+				delete(s.counters, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	c := s.counters[key]
+	if !now.Before(c.reset) {
+		c = memoryQuotaCounter{reset: quotaWindowEnd(now, window)}
+	}
+	c.count++
+	s.counters[key] = c
+	return c.count, c.reset, nil
+}
+
+// quotaWindowEnd returns the end of the fixed window of the given length
+// that contains now. Windows start at multiples of window since the Unix
+// epoch, in UTC; unlike time.Time.Truncate, which counts from the zero
+// time, this aligns e.g. 7-day windows the same way as stores that count
+// in Unix time, like Redis.
+func quotaWindowEnd(now time.Time, window time.Duration) time.Time {
+	epoch := time.Unix(0, 0).UTC()
+	return epoch.Add(now.Sub(epoch) / window * window).Add(window)
+}
@@ -0,0 +1,132 @@
+package generichttp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/olivere/generichttp"
+	"github.com/olivere/generichttp/testutil"
+)
+
+func quotaHandler(quotas ...generichttp.QuotaLimit) http.Handler {
+	quota := generichttp.Quota(generichttp.QuotaOptions{
+		Key:    func(r *http.Request) string { return r.Header.Get("X-API-Key") },
+		Quotas: quotas,
+	})
+	return quota(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+}
+
+func serveQuota(h http.Handler, key string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if key != "" {
+		r.Header.Set("X-API-Key", key)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestQuotaExhaustion(t *testing.T) {
+	h := quotaHandler(generichttp.QuotaLimit{Limit: 2, Window: time.Hour})
+	for i, remaining := range []string{"1", "0"} {
+		w := serveQuota(h, "alice")
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("request %d: status = %d, want %d", i+1, w.Code, http.StatusNoContent)
+		}
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != remaining {
+			t.Errorf("request %d: X-RateLimit-Remaining = %q, want %q", i+1, got, remaining)
+		}
+		if got := w.Header().Get("X-RateLimit-Limit"); got != "2" {
+			t.Errorf("request %d: X-RateLimit-Limit = %q, want 2", i+1, got)
+		}
+	}
+
+	w := serveQuota(h, "alice")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	testutil.AssertJSON(t, w, map[string]any{"message": "Quota exceeded"})
+	if got := w.Header().Get("X-RateLimit-Remaining"); got != "0" {
+		t.Errorf("X-RateLimit-Remaining = %q, want 0", got)
+	}
+	reset, err := strconv.ParseInt(w.Header().Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		t.Fatalf("X-RateLimit-Reset: %v", err)
+	}
+	if want := time.Now().Truncate(time.Hour).Add(time.Hour).Unix(); reset != want {
+		t.Errorf("X-RateLimit-Reset = %d, want %d, the end of the hour", reset, want)
+	}
+	retry, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	if err != nil || retry <= 0 || retry > 3600 {
+		t.Errorf("Retry-After = %q, want seconds until the end of the hour", w.Header().Get("Retry-After"))
+	}
+
+	if w := serveQuota(h, "bob"); w.Code != http.StatusNoContent {
+		t.Errorf("other key: status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if w := serveQuota(h, ""); w.Code != http.StatusNoContent || w.Header().Get("X-RateLimit-Limit") != "" {
+		t.Errorf("no key: status = %d, X-RateLimit-Limit = %q; want %d and not counted",
+			w.Code, w.Header().Get("X-RateLimit-Limit"), http.StatusNoContent)
+	}
+}
+
+func TestQuotaReset(t *testing.T) {
+	const window = 100 * time.Millisecond
+	h := quotaHandler(generichttp.QuotaLimit{Limit: 1, Window: window})
+	if w := serveQuota(h, "alice"); w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if w := serveQuota(h, "alice"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	// Windows are fixed, so the next one starts within one window.
+	time.Sleep(window)
+	if w := serveQuota(h, "alice"); w.Code != http.StatusNoContent {
+		t.Fatalf("after reset: status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+}
+
+func TestQuotaReportsTightestLimit(t *testing.T) {
+	h := quotaHandler(
+		generichttp.QuotaLimit{Limit: 10, Window: time.Minute},
+		generichttp.QuotaLimit{Limit: 3, Window: 24 * time.Hour},
+	)
+	w := serveQuota(h, "alice")
+	if got := w.Header().Get("X-RateLimit-Limit"); got != "3" {
+		t.Errorf("X-RateLimit-Limit = %q, want 3 of the daily quota", got)
+	}
+	if got := w.Header().Get("RateLimit-Remaining"); got != "2" {
+		t.Errorf("RateLimit-Remaining = %q, want 2", got)
+	}
+}
+
+func TestMemoryQuotaStoreWeeklyWindow(t *testing.T) {
+	const week = 7 * 24 * time.Hour
+	s := generichttp.NewMemoryQuotaStore()
+	before := time.Now()
+	count, reset, err := s.Take(context.Background(), "alice", week)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("count = %d, want 1", count)
+	}
+	// The Unix epoch was a Thursday, so weekly windows start on Thursdays
+	// at midnight UTC.
+	utc := reset.UTC()
+	if utc.Weekday() != time.Thursday || utc.Hour() != 0 || utc.Minute() != 0 || utc.Second() != 0 || utc.Nanosecond() != 0 {
+		t.Errorf("reset = %v, want Thursday midnight UTC", utc)
+	}
+	if reset.Unix()%int64(week/time.Second) != 0 {
+		t.Errorf("reset = %d, want a multiple of a week since the Unix epoch", reset.Unix())
+	}
+	if !reset.After(before) || reset.Sub(before) > week {
+		t.Errorf("reset = %v, want within a week after %v", reset, before)
+	}
+}
@@ -0,0 +1,49 @@
+package generichttp
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimitState is the state of a rate limit or quota for a request, see
+// SetRateLimitHeaders.
+type RateLimitState struct {
+	// Limit is the number of requests allowed per window.
+	Limit int64
+	// Remaining is the number of requests left in the current window.
+	Remaining int64
+	// Reset is the end of the current window.
+	Reset time.Time
+}
+
+// rateLimitKey is the context key of the RateLimitState of a request.
+type rateLimitKey struct{}
+
+// SetRateLimitHeaders sets the RateLimit-Limit, RateLimit-Remaining, and
+// RateLimit-Reset headers of the IETF draft "RateLimit header fields for
+// HTTP" from s, with the reset in seconds from now. Limiters should set
+// them on every response, not just when rejecting requests, so that
+// clients can back off before they are throttled.
+func SetRateLimitHeaders(h http.Header, s RateLimitState) {
+	reset := max(int64(math.Ceil(time.Until(s.Reset).Seconds())), 0)
+	h.Set("RateLimit-Limit", strconv.FormatInt(s.Limit, 10))
+	h.Set("RateLimit-Remaining", strconv.FormatInt(max(s.Remaining, 0), 10))
+	h.Set("RateLimit-Reset", strconv.FormatInt(reset, 10))
+}
+
+// WithRateLimit returns a shallow copy of r with s in its context, e.g. in
+// a custom limiter before calling the next handler, so that handlers can
+// get the state with RateLimitFromContext. The Quota middleware does this.
+func WithRateLimit(r *http.Request, s RateLimitState) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), rateLimitKey{}, s))
+}
+
+// RateLimitFromContext returns the RateLimitState stored by WithRateLimit,
+// e.g. to include it in a response body.
+func RateLimitFromContext(ctx context.Context) (RateLimitState, bool) {
+	s, ok := ctx.Value(rateLimitKey{}).(RateLimitState)
+	return s, ok
+}
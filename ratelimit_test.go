@@ -0,0 +1,121 @@
+package generichttp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/olivere/generichttp"
+)
+
+func TestSetRateLimitHeaders(t *testing.T) {
+	tests := []struct {
+		name      string
+		state     generichttp.RateLimitState
+		remaining string
+		reset     string
+	}{
+		{"remaining", generichttp.RateLimitState{Limit: 100, Remaining: 42, Reset: time.Now().Add(30*time.Second + 500*time.Millisecond)}, "42", "31"},
+		{"exhausted", generichttp.RateLimitState{Limit: 100, Remaining: -3, Reset: time.Now().Add(time.Minute)}, "0", "60"},
+		{"past reset", generichttp.RateLimitState{Limit: 100, Remaining: 0, Reset: time.Now().Add(-time.Minute)}, "0", "0"},
+	}
+	for _, tt := range tests {
+		h := make(http.Header)
+		generichttp.SetRateLimitHeaders(h, tt.state)
+		want := http.Header{
+			"Ratelimit-Limit":     {"100"},
+			"Ratelimit-Remaining": {tt.remaining},
+			"Ratelimit-Reset":     {tt.reset},
+		}
+		for name, v := range want {
+			if got := h.Get(name); got != v[0] {
+				t.Errorf("%s: %s = %q, want %q", tt.name, name, got, v[0])
+			}
+		}
+		if len(h) != len(want) {
+			t.Errorf("%s: headers = %v, want %v", tt.name, h, want)
+		}
+	}
+}
+
+func TestRateLimitFromContext(t *testing.T) {
+	if _, ok := generichttp.RateLimitFromContext(context.Background()); ok {
+		t.Error("got a state without WithRateLimit")
+	}
+	state := generichttp.RateLimitState{Limit: 10, Remaining: 9, Reset: time.Now().Add(time.Minute)}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r2 := generichttp.WithRateLimit(r, state)
+	if got, ok := generichttp.RateLimitFromContext(r2.Context()); !ok || got != state {
+		t.Errorf("got %+v, %v; want %+v", got, ok, state)
+	}
+	if _, ok := generichttp.RateLimitFromContext(r.Context()); ok {
+		t.Error("WithRateLimit changed the original request")
+	}
+}
+
+func TestQuotaRateLimitHeaders(t *testing.T) {
+	var (
+		seen   generichttp.RateLimitState
+		called bool
+	)
+	quota := generichttp.Quota(generichttp.QuotaOptions{
+		Key:    func(r *http.Request) string { return r.Header.Get("X-API-Key") },
+		Quotas: []generichttp.QuotaLimit{{Limit: 2, Window: time.Hour}},
+	})
+	h := quota(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		seen, _ = generichttp.RateLimitFromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	end := time.Now().Truncate(time.Hour).Add(time.Hour)
+
+	for i, tt := range []struct {
+		code      int
+		remaining int64
+	}{
+		{http.StatusNoContent, 1},
+		{http.StatusNoContent, 0},
+		{http.StatusTooManyRequests, 0},
+	} {
+		called, seen = false, generichttp.RateLimitState{}
+		w := serveQuota(h, "alice")
+		if w.Code != tt.code {
+			t.Fatalf("request %d: status = %d, want %d", i+1, w.Code, tt.code)
+		}
+		remaining := strconv.FormatInt(tt.remaining, 10)
+		for name, want := range map[string]string{
+			"RateLimit-Limit":       "2",
+			"RateLimit-Remaining":   remaining,
+			"X-RateLimit-Limit":     "2",
+			"X-RateLimit-Remaining": remaining,
+			"X-RateLimit-Reset":     strconv.FormatInt(end.Unix(), 10),
+		} {
+			if got := w.Header().Get(name); got != want {
+				t.Errorf("request %d: %s = %q, want %q", i+1, name, got, want)
+			}
+		}
+		reset, err := strconv.Atoi(w.Header().Get("RateLimit-Reset"))
+		if left := time.Until(end).Seconds(); err != nil || float64(reset) < left || float64(reset) > left+1 {
+			t.Errorf("request %d: RateLimit-Reset = %q, want the seconds until %v", i+1, w.Header().Get("RateLimit-Reset"), end)
+		}
+
+		if tt.code == http.StatusTooManyRequests {
+			if called {
+				t.Errorf("request %d: handler called for a throttled request", i+1)
+			}
+			if retry, err := strconv.Atoi(w.Header().Get("Retry-After")); err != nil || retry < reset-1 || retry > reset {
+				t.Errorf("request %d: Retry-After = %q, want about %d", i+1, w.Header().Get("Retry-After"), reset)
+			}
+			continue
+		}
+		if got := w.Header().Get("Retry-After"); got != "" {
+			t.Errorf("request %d: Retry-After = %q, want none", i+1, got)
+		}
+		if seen.Limit != 2 || seen.Remaining != tt.remaining || !seen.Reset.Equal(end) {
+			t.Errorf("request %d: state = %+v, want limit 2, remaining %d, reset %v", i+1, seen, tt.remaining, end)
+		}
+	}
+}
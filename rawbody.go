@@ -0,0 +1,67 @@
+package generichttp
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"net/http"
+	"strings"
+)
+
+// WithStreamBody passes the request body to the handler instead of
+// decoding it, e.g. for proxies and large uploads that shouldn't be
+// buffered. The handler reads Request.Body, and Request.Data is nil; use
+// struct{} as the request type. Query parameters are not bound and the
+// checks on request data, e.g. WithValidateUTF8, don't apply.
+//
+// The body is still limited by WithMaxBodySize, so raise the limit for
+// uploads; reading beyond it fails with a *http.MaxBytesError. Return it
+// from the handler, possibly wrapped, to answer with a
+// RequestEntityTooLargeError. With WithDecompressBody, the
+// handler reads the decompressed body.
+//
+// Middleware that duplicates the body with TeeRequestBody sees the bytes
+// as the handler reads them, before decompression.
+func WithStreamBody() Option {
+	return func(o *Options) {
+		o.StreamBody = true
+	}
+}
+
+// WithDecompressBody decompresses request bodies with a Content-Encoding
+// of gzip or deflate before decoding them, or before passing them to the
+// handler with WithStreamBody. WithMaxBodySize limits the decompressed
+// size, which protects against compression bombs. Bodies with other
+// encodings are rejected with an UnsupportedMediaTypeError.
+func WithDecompressBody() Option {
+	return func(o *Options) {
+		o.DecompressBody = true
+	}
+}
+
+// decompressBody replaces the body of r with a decompressing reader
+// according to its Content-Encoding header, and removes the header.
+func decompressBody(r *http.Request) error {
+	encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+	switch encoding {
+	case "", "identity":
+		return nil
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return BadRequestError{Message: "Invalid compressed body"}
+		}
+		r.Body = readCloser{Reader: zr, Closer: r.Body}
+	case "deflate":
+		zr, err := zlib.NewReader(r.Body)
+		if err != nil {
+			return BadRequestError{Message: "Invalid compressed body"}
+		}
+		r.Body = readCloser{Reader: zr, Closer: r.Body}
+	default:
+		return UnsupportedMediaTypeError{Message: "Unsupported content encoding"}
+	}
+	r.Header.Del("Content-Encoding")
+	r.Header.Del("Content-Length")
+	r.ContentLength = -1
+	return nil
+}
@@ -0,0 +1,58 @@
+package generichttp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+)
+
+// panicError is the error for a panic recovered by Recover.
+type panicError struct {
+	value any
+}
+
+// Error implements the error interface.
+func (e panicError) Error() string { return fmt.Sprintf("panic: %v", e.value) }
+
+// Unwrap returns the panic value if it is an error.
+func (e panicError) Unwrap() error {
+	err, _ := e.value.(error)
+	return err
+}
+
+// Recover returns a middleware that recovers from panics in the next
+// handler and renders them as an internal server error, which is logged
+// with the panic value if ErrorLog is set. With WithDevErrors, the
+// response includes the panic value and the stack trace of the panic.
+// Other options are ignored.
+//
+// If the response has already been started when the handler panics, the
+// connection is aborted instead, so that the client doesn't mistake the
+// truncated response for a complete one. Panics with http.ErrAbortHandler
+// are passed on.
+func Recover(opts ...Option) Middleware {
+	o := newOptions(*defaultOptions, opts)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tw := NewTeeResponseWriter(w, io.Discard)
+			defer func() {
+				p := recover()
+				if p == nil {
+					return
+				}
+				if err, ok := p.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(p)
+				}
+				err := devErrorFor(panicError{value: p}, debug.Stack(), o)
+				if tw.Status() != 0 {
+					logError(r, http.StatusInternalServerError, err)
+					panic(http.ErrAbortHandler)
+				}
+				writeJSONError(w, r, err)
+			}()
+			next.ServeHTTP(tw, r)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package generichttp
+
+import (
+	"net/http"
+	"reflect"
+)
+
+// RedirectResponse is response data for hybrid web and API endpoints. It
+// redirects browsers to Location and renders Data as JSON for API clients.
+// Use RedirectOrJSON to create a response with it.
+type RedirectResponse[T any] struct {
+	// Location is the URL browsers are redirected to.
+	Location string
+	// Code is the HTTP status code of the redirect. It defaults to 303 See
+	// Other, which makes browsers follow up e.g. a form POST with a GET.
+	Code int
+	// Data is rendered as JSON for API clients.
+	Data *T
+}
+
+// RedirectOrJSON returns a response that redirects browsers to location
+// and renders data as JSON with status 200 for API clients, so one handler
+// serves both, e.g. after creating a resource from a form:
+//
+//	return generichttp.RedirectOrJSON("/items/"+item.ID, item), nil
+//
+// Browsers are clients whose Accept header prefers text/html over
+// application/json, honoring q values and wildcards. Clients without an
+// Accept header or with only wildcards get JSON.
+func RedirectOrJSON[T any](location string, data *T) *Response[RedirectResponse[T]] {
+	return NewResponse(&RedirectResponse[T]{Location: location, Data: data})
+}
+
+// render implements renderer.
+func (rr *RedirectResponse[T]) render(w http.ResponseWriter, r *http.Request, code int) {
+	if r != nil && NegotiateContentType(r.Header.Get("Accept"), redirectOffers) == "text/html" {
+		redirectCode := rr.Code
+		if redirectCode == 0 {
+			redirectCode = http.StatusSeeOther
+		}
+		http.Redirect(w, r, rr.Location, redirectCode)
+		return
+	}
+	if rr.Data == nil {
+		if code == 0 {
+			code = http.StatusOK
+		}
+		w.WriteHeader(code)
+		return
+	}
+	writeJSONResponse(w, r, code, rr.Data)
+}
+
+// content implements renderer.
+func (*RedirectResponse[T]) content() (string, *Schema) {
+	schema, _ := schemaOf(reflect.TypeOf((*T)(nil)).Elem())
+	return mediaTypeJSON, schema
+}
+
+// redirectOffers are the media types negotiated by RedirectResponse, with
+// JSON preferred for ties.
+var redirectOffers = []string{mediaTypeJSON, "text/html"}
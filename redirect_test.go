@@ -0,0 +1,95 @@
+package generichttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/olivere/generichttp"
+	"github.com/olivere/generichttp/testutil"
+)
+
+type redirectItem struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func createRedirectItem(w http.ResponseWriter, req generichttp.Request[redirectItem]) (*generichttp.Response[generichttp.RedirectResponse[redirectItem]], error) {
+	return generichttp.RedirectOrJSON("/items/"+req.Data.ID, req.Data), nil
+}
+
+func TestRedirectOrJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		accept   string
+		redirect bool
+	}{
+		{"browser", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", true},
+		{"html only", "text/html", true},
+		{"html preferred", "application/json;q=0.5, text/html", true},
+		{"api client", "application/json", false},
+		{"json preferred", "text/html;q=0.5, application/json", false},
+		{"tie", "text/html, application/json", false},
+		{"no accept", "", false},
+		{"wildcard", "*/*", false},
+	}
+	for _, tt := range tests {
+		for kind, h := range map[string]http.Handler{
+			"JSON": generichttp.JSON(createRedirectItem),
+			"Auto": generichttp.Auto(createRedirectItem),
+		} {
+			t.Run(tt.name+"/"+kind, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`{"id":"7","name":"Widget"}`))
+				r.Header.Set("Content-Type", "application/json")
+				if tt.accept != "" {
+					r.Header.Set("Accept", tt.accept)
+				}
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, r)
+				if tt.redirect {
+					if w.Code != http.StatusSeeOther {
+						t.Fatalf("status = %d, want %d", w.Code, http.StatusSeeOther)
+					}
+					if loc := w.Header().Get("Location"); loc != "/items/7" {
+						t.Errorf("Location = %q, want %q", loc, "/items/7")
+					}
+					return
+				}
+				if w.Code != http.StatusOK {
+					t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+				}
+				if loc := w.Header().Get("Location"); loc != "" {
+					t.Errorf("Location = %q, want none", loc)
+				}
+				if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+					t.Errorf("Content-Type = %q, want application/json", ct)
+				}
+				testutil.AssertJSON(t, w, redirectItem{ID: "7", Name: "Widget"})
+			})
+		}
+	}
+}
+
+func TestRedirectOrJSONCode(t *testing.T) {
+	h := generichttp.JSON(func(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[generichttp.RedirectResponse[redirectItem]], error) {
+		resp := generichttp.RedirectOrJSON[redirectItem]("/login", nil)
+		resp.Data.Code = http.StatusFound
+		return resp, nil
+	})
+	r := httptest.NewRequest(http.MethodGet, "/account", nil)
+	r.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "/login" {
+		t.Errorf("status = %d, Location = %q; want %d to /login", w.Code, w.Header().Get("Location"), http.StatusFound)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/account", nil)
+	r.Header.Set("Accept", "application/json")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Body.Len() != 0 {
+		t.Errorf("status = %d, body = %q; want %d without a body", w.Code, w.Body, http.StatusOK)
+	}
+}
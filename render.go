@@ -0,0 +1,65 @@
+package generichttp
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// renderer is implemented by response data that renders itself instead of
+// being encoded as JSON or XML, e.g. TextResponse.
+type renderer interface {
+	// render writes the response with the given HTTP status code. The
+	// request may be nil, e.g. in Respond.
+	render(w http.ResponseWriter, r *http.Request, code int)
+	// content returns the media type and schema of the response for the
+	// OpenAPI document.
+	content() (mediaType string, schema *Schema)
+}
+
+// writeJSONResponse renders data as JSON, unless it renders itself.
+func writeJSONResponse(w http.ResponseWriter, r *http.Request, code int, data any) {
+	if rd, ok := data.(renderer); ok {
+		rd.render(w, r, code)
+		return
+	}
+	writeJSONCode(requestContext(r), w, code, data)
+}
+
+// TextResponse is response data that is rendered as plain text instead of
+// JSON, e.g. for health checks or robots.txt. Use Text to create a response
+// with it.
+type TextResponse struct {
+	Body string
+}
+
+// Text returns a response that JSON and Auto render as text/plain with the
+// given HTTP status code and body, e.g.:
+//
+//	func health(w http.ResponseWriter, r generichttp.Request[struct{}]) (*generichttp.Response[generichttp.TextResponse], error) {
+//		return generichttp.Text(http.StatusOK, "ok"), nil
+//	}
+//
+// Errors returned from the handler are still rendered as JSON or XML. To
+// return text from a handler with a different response type, use
+// Halt(Text(...)).
+func Text(status int, body string) *Response[TextResponse] {
+	return NewResponseWithCode(status, &TextResponse{Body: body})
+}
+
+// render implements renderer.
+func (t *TextResponse) render(w http.ResponseWriter, _ *http.Request, code int) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(len(t.Body)))
+	if code == 0 {
+		code = http.StatusOK
+	}
+	w.WriteHeader(code)
+	_, err := io.WriteString(w, t.Body)
+	handleWriteError(err)
+}
+
+// content implements renderer.
+func (*TextResponse) content() (string, *Schema) {
+	return "text/plain", &Schema{Type: "string"}
+}
@@ -0,0 +1,79 @@
+package generichttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/olivere/generichttp"
+	"github.com/olivere/generichttp/testutil"
+)
+
+func TestText(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+	}
+	tests := []struct {
+		name string
+		h    http.Handler
+		code int
+		body string
+	}{
+		{
+			name: "ok",
+			h: generichttp.JSON(func(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[generichttp.TextResponse], error) {
+				return generichttp.Text(http.StatusOK, "ok"), nil
+			}),
+			code: http.StatusOK,
+			body: "ok",
+		},
+		{
+			name: "status",
+			h: generichttp.Auto(func(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[generichttp.TextResponse], error) {
+				return generichttp.Text(http.StatusServiceUnavailable, "degraded: database ünreachable\n"), nil
+			}),
+			code: http.StatusServiceUnavailable,
+			body: "degraded: database ünreachable\n",
+		},
+		{
+			name: "halt",
+			h: generichttp.JSON(func(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[item], error) {
+				return nil, generichttp.Halt(generichttp.Text(http.StatusOK, "User-agent: *\nDisallow: /\n"))
+			}),
+			code: http.StatusOK,
+			body: "User-agent: *\nDisallow: /\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept", "application/json")
+			w := httptest.NewRecorder()
+			tt.h.ServeHTTP(w, r)
+			if w.Code != tt.code {
+				t.Errorf("status = %d, want %d", w.Code, tt.code)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+				t.Errorf("Content-Type = %q, want %q", ct, "text/plain; charset=utf-8")
+			}
+			if w.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", w.Body, tt.body)
+			}
+		})
+	}
+}
+
+func TestTextError(t *testing.T) {
+	h := generichttp.JSON(func(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[generichttp.TextResponse], error) {
+		return nil, generichttp.NotFoundError{Message: "No robots here"}
+	})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	testutil.AssertJSON(t, w, map[string]any{"message": "No robots here"})
+}
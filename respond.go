@@ -0,0 +1,90 @@
+package generichttp
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Respond renders resp as JSON, just like the handler returned by JSON
+// does with the default options. Use it in middleware to short-circuit a request with a typed
+// response instead of calling the next handler.
+func Respond[T any](w http.ResponseWriter, resp *Response[T]) {
+	if resp == nil {
+		resp = &Response[T]{}
+	}
+	if resp.Data == nil {
+		writeEmpty(w, resp, EmptyNoBody, mediaTypeJSON)
+		return
+	}
+	resp.writeHeader(w)
+	writeJSONResponse(w, nil, resp.StatusCode, resp.Data)
+}
+
+// RespondError renders err as JSON, just like the handler returned by JSON
+// does. Use it in middleware to short-circuit a request with an error
+// instead of calling the next handler.
+func RespondError(w http.ResponseWriter, err error) {
+	WriteJSONError(w, err)
+}
+
+// HandlerMiddleware wraps a Handler. Unlike Middleware, it works on the
+// typed request and response. To short-circuit, return without calling
+// next: either a *Response[W], an error, or Halt to respond with data of
+// a different type.
+//
+// An authentication gate could be written like this:
+//
+//	func requireToken[R, W any](next generichttp.Handler[R, W]) generichttp.Handler[R, W] {
+//		return func(w http.ResponseWriter, req generichttp.Request[R]) (
+//			*generichttp.Response[W], error,
+//		) {
+//			if req.Header.Get("Authorization") == "" {
+//				w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+//				return nil, generichttp.Halt(generichttp.NewResponseWithCode(
+//					http.StatusUnauthorized, &challenge{Realm: "api"}))
+//			}
+//			return next(w, req)
+//		}
+//	}
+type HandlerMiddleware[R, W any] func(next Handler[R, W]) Handler[R, W]
+
+// Wrap wraps h with the given middleware. The first middleware is the
+// outermost one, i.e. it is called first.
+func Wrap[R, W any](h Handler[R, W], mw ...HandlerMiddleware[R, W]) Handler[R, W] {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+// Halt returns an error that makes JSON and Auto render resp instead of
+// an error. Use it to short-circuit a handler with a response whose data
+// type differs from the one of the handler.
+func Halt[T any](resp *Response[T]) error {
+	h := &haltError{code: http.StatusOK}
+	if resp != nil {
+		h.code = resp.StatusCode
+		h.header = resp.Header
+		if resp.Data != nil {
+			h.data = resp.Data
+		}
+	}
+	return h
+}
+
+// haltError is the error returned by Halt.
+type haltError struct {
+	code   int
+	header http.Header
+	data   any
+}
+
+// Error implements the error interface.
+func (*haltError) Error() string { return "generichttp: response halted" }
+
+// asHalt returns the haltError in err, if any.
+func asHalt(err error) (*haltError, bool) {
+	var h *haltError
+	ok := errors.As(err, &h)
+	return h, ok
+}
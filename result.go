@@ -0,0 +1,54 @@
+package generichttp
+
+import "net/http"
+
+// Result holds either a successful response or an error, as an
+// alternative to returning (*Response[T], error) from a handler, see
+// ResultHandler. Create it with Ok, OkResponse, or Err.
+type Result[T any] struct {
+	resp *Response[T]
+	err  error
+}
+
+// Ok returns a successful Result with the given data and HTTP status code
+// 200.
+func Ok[T any](data T) Result[T] {
+	return Result[T]{resp: NewResponse(&data)}
+}
+
+// OkResponse returns a successful Result with the given response, e.g. to
+// set the HTTP status code with Created, or headers.
+func OkResponse[T any](resp *Response[T]) Result[T] {
+	return Result[T]{resp: resp}
+}
+
+// Err returns a failed Result with the given error, which is rendered
+// like an error returned by a Handler, e.g.:
+//
+//	return generichttp.Err[User](generichttp.NotFoundError{})
+func Err[T any](err error) Result[T] {
+	return Result[T]{err: err}
+}
+
+// Get returns the response and error of r, as returned by a Handler. The
+// zero Result is a successful response without data.
+func (r Result[T]) Get() (*Response[T], error) {
+	if r.resp == nil && r.err == nil {
+		return &Response[T]{}, nil
+	}
+	return r.resp, r.err
+}
+
+// ResultHandler is a handler that returns a single Result instead of a
+// response and an error. Use FromResult to turn it into a Handler.
+type ResultHandler[R, W any] func(w http.ResponseWriter, r Request[R]) Result[W]
+
+// FromResult returns a Handler that calls h and returns the response or
+// error of its Result. Use it with JSON and Auto, e.g.:
+//
+//	router.Get("/users/{id}", generichttp.JSON(generichttp.FromResult(getUser)))
+func FromResult[R, W any](h ResultHandler[R, W]) Handler[R, W] {
+	return func(w http.ResponseWriter, r Request[R]) (*Response[W], error) {
+		return h(w, r).Get()
+	}
+}
@@ -0,0 +1,407 @@
+package generichttp
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Router dispatches requests by HTTP method and path. Patterns follow the
+// syntax of http.ServeMux, e.g. "/items/{id}", but must not contain a
+// method; use Handle or one of the method-specific functions instead.
+//
+// Requests for unknown paths are answered with a NotFoundError, requests
+// with a method that is not registered for a path are answered with a
+// MethodNotAllowedError and an Allow header.
+//
+// Register all routes before serving requests.
+type Router struct {
+	// AutoOptions answers OPTIONS requests for registered paths with
+	// HTTP status code 204 and an Allow header listing the allowed methods.
+	// An OPTIONS handler registered for a path takes precedence.
+	// NewRouter enables AutoOptions.
+	AutoOptions bool
+
+	// AutoHead answers HEAD requests for paths with a GET handler by
+	// running the GET handler and discarding the response body. The
+	// Content-Length header is set to the size of the discarded body unless
+	// the handler sets it. Use WithoutAutoHead to disable it for GET
+	// handlers with side effects. A HEAD handler registered for a path
+	// takes precedence. NewRouter enables AutoHead.
+	AutoHead bool
+
+	// DisallowedMethods are rejected with a MethodNotAllowedError for all
+	// paths, even if a handler is registered for them. NewRouter sets it to
+	// TRACE and CONNECT, which are rarely wanted and can be abused, e.g. for
+	// cross-site tracing or to tunnel through the server. Set it to nil to
+	// allow all methods. OPTIONS should not be disallowed, so that
+	// AutoOptions and CORS preflight requests work.
+	DisallowedMethods []string
+
+	// Timeout is the default timeout of routes, see WithTimeout. Timeouts
+	// of routes and groups take precedence. Zero means no timeout.
+	Timeout time.Duration
+
+	mux     *http.ServeMux
+	routes  map[string]*route
+	groups  map[string]*Group
+	mounts  []mount
+	options *Options
+}
+
+// mount is a handler mounted at a path prefix.
+type mount struct {
+	prefix  string
+	handler http.Handler
+}
+
+// route holds the endpoints per HTTP method for a single pattern.
+type route struct {
+	pattern   string
+	endpoints map[string]*endpoint
+}
+
+// endpoint is a handler registered for a method and pattern.
+type endpoint struct {
+	handler    http.Handler
+	summary    string
+	tags       []string
+	noAutoHead bool
+	group      *Group
+	timeout    time.Duration
+	// deprecation is set for deprecated routes, see WithDeprecation.
+	deprecation *deprecation
+}
+
+// serve serves the request with the handler of e, within the limits of
+// its group, if any, and its timeout, and sets its deprecation headers.
+func (rt *Router) serve(e *endpoint, w http.ResponseWriter, r *http.Request) {
+	if e.deprecation != nil {
+		e.deprecation.setHeaders(w.Header())
+	}
+	h := e.handler
+	if timeout := rt.timeout(e); timeout > 0 {
+		h = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			serveTimeout(e.handler, timeout, w, r)
+		})
+	}
+	if e.group != nil {
+		e.group.serve(h, w, r)
+		return
+	}
+	h.ServeHTTP(w, r)
+}
+
+// timeout returns the timeout of e: its own, the one of its group, or the
+// default of the router, in that order.
+func (rt *Router) timeout(e *endpoint) time.Duration {
+	if e.timeout != 0 {
+		return e.timeout
+	}
+	if e.group != nil && e.group.timeout != 0 {
+		return e.group.timeout
+	}
+	return rt.Timeout
+}
+
+// RouteOption configures a route on registration.
+type RouteOption func(*endpoint)
+
+// WithSummary sets the summary of a route, e.g. for OpenAPI.
+func WithSummary(summary string) RouteOption {
+	return func(e *endpoint) {
+		e.summary = summary
+	}
+}
+
+// WithTags sets the tags of a route, e.g. for OpenAPI.
+func WithTags(tags ...string) RouteOption {
+	return func(e *endpoint) {
+		e.tags = append(e.tags, tags...)
+	}
+}
+
+// WithTimeout limits the duration of requests to a route to d, e.g.:
+//
+//	router.Post("/reports", generichttp.JSON(createReport), generichttp.WithTimeout(10*time.Second))
+//
+// Requests that take longer are answered with a GatewayTimeoutError, see
+// the Timeout middleware for details. It overrides the timeout of the
+// group of the route and the default of the router; a negative d disables
+// them for the route.
+func WithTimeout(d time.Duration) RouteOption {
+	return func(e *endpoint) {
+		e.timeout = d
+	}
+}
+
+// WithoutAutoHead disables answering HEAD requests with the GET handler
+// of a route, e.g. if the handler has side effects. See Router.AutoHead.
+func WithoutAutoHead() RouteOption {
+	return func(e *endpoint) {
+		e.noAutoHead = true
+	}
+}
+
+// NewRouter creates a new Router. The options apply to all handlers
+// returned by JSON and Auto that are registered with the router, e.g.:
+//
+//	router := generichttp.NewRouter(generichttp.WithMaxBodySize(64<<10), generichttp.WithValidateUTF8())
+//	router.Post("/uploads", generichttp.JSON(upload, generichttp.WithMaxBodySize(8<<20)))
+//
+// The options of a handler take precedence, i.e. they apply on top of
+// those of the router, so the uploads above may be up to 8 MiB. Use
+// WithOptions to pass an Options struct. Handlers that are wrapped, e.g.
+// in a Middleware, keep their own options.
+func NewRouter(opts ...Option) *Router {
+	rt := &Router{
+		AutoOptions:       true,
+		AutoHead:          true,
+		DisallowedMethods: []string{http.MethodTrace, http.MethodConnect},
+		mux:               http.NewServeMux(),
+		routes:            make(map[string]*route),
+	}
+	if len(opts) > 0 {
+		rt.options = newOptions(*defaultOptions, opts)
+	}
+	return rt
+}
+
+// Handle registers the handler for the given method and pattern.
+func (rt *Router) Handle(method, pattern string, h http.Handler, opts ...RouteOption) {
+	rte, found := rt.routes[pattern]
+	if !found {
+		rte = &route{
+			pattern:   pattern,
+			endpoints: make(map[string]*endpoint),
+		}
+		rt.routes[pattern] = rte
+		rt.mux.Handle(pattern, rt.dispatch(rte))
+	}
+	if ch, ok := h.(configurableHandler); ok && rt.options != nil {
+		h = ch.withOptions(*rt.options)
+	}
+	e := &endpoint{handler: h}
+	for _, opt := range opts {
+		opt(e)
+	}
+	rte.endpoints[strings.ToUpper(method)] = e
+}
+
+// HandleMethods registers the handler for each of the given methods and
+// the pattern, e.g. for an endpoint that accepts both GET and POST:
+//
+//	router.HandleMethods([]string{http.MethodGet, http.MethodPost}, "/search", search)
+//
+// It is the same as calling Handle for each method, so the methods are
+// listed in the Allow header and other methods are answered with a
+// MethodNotAllowedError.
+func (rt *Router) HandleMethods(methods []string, pattern string, h http.Handler, opts ...RouteOption) {
+	for _, method := range methods {
+		rt.Handle(method, pattern, h, opts...)
+	}
+}
+
+// Mount serves all requests whose path starts with prefix with sub, e.g. a
+// versioned API or a module with its own routes. The prefix is stripped
+// from the path before calling sub, so sub sees "/items/7" for a request
+// to "/api/v1/items/7" with prefix "/api/v1". A request for the prefix
+// itself is redirected to the prefix with a trailing slash, which sub sees
+// as "/".
+//
+// The prefix must start with a slash and must not contain wildcards. If
+// sub is a Router, its routes are included in Routes and OpenAPI with the
+// prefix. See Subrouter to create one that shares the configuration of rt.
+func (rt *Router) Mount(prefix string, sub http.Handler) {
+	if !strings.HasPrefix(prefix, "/") {
+		panic("generichttp: mount prefix must start with a slash: " + prefix)
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	rt.mux.Handle(prefix+"/", http.StripPrefix(prefix, sub))
+	rt.mounts = append(rt.mounts, mount{prefix: prefix, handler: sub})
+}
+
+// Subrouter returns a new Router mounted at prefix, see Mount, e.g.:
+//
+//	v1 := router.Subrouter("/api/v1")
+//	v1.Get("/items/{id}", generichttp.JSON(getItem))
+//
+// The subrouter inherits the options and settings of rt, e.g. AutoHead and
+// Timeout, as they are at the time of the call. Patterns are registered
+// without the prefix.
+//
+// A Router has no middleware of its own, so there is none to inherit:
+// middleware wrapping rt, e.g. Recover()(router), applies to the routes of
+// the subrouter too, as their requests pass through rt. Middleware
+// wrapping single handlers of rt, and the groups of rt, only apply to
+// those handlers.
+func (rt *Router) Subrouter(prefix string) *Router {
+	sub := &Router{
+		AutoOptions:       rt.AutoOptions,
+		AutoHead:          rt.AutoHead,
+		DisallowedMethods: rt.DisallowedMethods,
+		Timeout:           rt.Timeout,
+		mux:               http.NewServeMux(),
+		routes:            make(map[string]*route),
+		options:           rt.options,
+	}
+	rt.Mount(prefix, sub)
+	return sub
+}
+
+// eachRoute calls fn for every route of rt and of the routers mounted in
+// it, with the full pattern.
+func (rt *Router) eachRoute(fn func(pattern string, rte *route)) {
+	for _, rte := range rt.routes {
+		fn(rte.pattern, rte)
+	}
+	for _, m := range rt.mounts {
+		if sub, ok := m.handler.(*Router); ok {
+			sub.eachRoute(func(pattern string, rte *route) {
+				fn(m.prefix+pattern, rte)
+			})
+		}
+	}
+}
+
+// Get registers the handler for GET requests on the given pattern.
+func (rt *Router) Get(pattern string, h http.Handler, opts ...RouteOption) {
+	rt.Handle(http.MethodGet, pattern, h, opts...)
+}
+
+// Post registers the handler for POST requests on the given pattern.
+func (rt *Router) Post(pattern string, h http.Handler, opts ...RouteOption) {
+	rt.Handle(http.MethodPost, pattern, h, opts...)
+}
+
+// Put registers the handler for PUT requests on the given pattern.
+func (rt *Router) Put(pattern string, h http.Handler, opts ...RouteOption) {
+	rt.Handle(http.MethodPut, pattern, h, opts...)
+}
+
+// Patch registers the handler for PATCH requests on the given pattern.
+func (rt *Router) Patch(pattern string, h http.Handler, opts ...RouteOption) {
+	rt.Handle(http.MethodPatch, pattern, h, opts...)
+}
+
+// Delete registers the handler for DELETE requests on the given pattern.
+func (rt *Router) Delete(pattern string, h http.Handler, opts ...RouteOption) {
+	rt.Handle(http.MethodDelete, pattern, h, opts...)
+}
+
+// ServeHTTP dispatches the request to the handler registered for its
+// method and path.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if rt.disallowed(r.Method) {
+		writeJSONError(w, r, MethodNotAllowedError{})
+		return
+	}
+	if _, pattern := rt.mux.Handler(r); pattern == "" {
+		writeJSONError(w, r, NotFoundError{})
+		return
+	}
+	rt.mux.ServeHTTP(w, r)
+}
+
+// disallowed reports whether method is one of rt.DisallowedMethods.
+func (rt *Router) disallowed(method string) bool {
+	for _, m := range rt.DisallowedMethods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// dispatch returns a http.Handler that selects the handler of rte by
+// the request method.
+func (rt *Router) dispatch(rte *route) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if e, found := rte.endpoints[r.Method]; found {
+			rt.serve(e, w, r)
+			return
+		}
+		if e := rt.autoHeadEndpoint(rte, r.Method); e != nil {
+			hw := &headResponseWriter{ResponseWriter: w, status: http.StatusOK}
+			rt.serve(e, hw, r)
+			hw.finish()
+			return
+		}
+		w.Header().Set("Allow", strings.Join(rt.allowedMethods(rte), ", "))
+		if r.Method == http.MethodOptions && rt.AutoOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeJSONError(w, r, MethodNotAllowedError{})
+	})
+}
+
+// allowedMethods returns the sorted list of methods allowed for rte.
+func (rt *Router) allowedMethods(rte *route) []string {
+	methods := make([]string, 0, len(rte.endpoints)+1)
+	for method := range rte.endpoints {
+		if rt.disallowed(method) {
+			continue
+		}
+		methods = append(methods, method)
+	}
+	if _, found := rte.endpoints[http.MethodOptions]; !found && rt.AutoOptions {
+		methods = append(methods, http.MethodOptions)
+	}
+	if rt.autoHeadEndpoint(rte, http.MethodHead) != nil {
+		methods = append(methods, http.MethodHead)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// autoHeadEndpoint returns the GET endpoint of rte that answers a HEAD
+// request, or nil if method is not HEAD or there is no such endpoint.
+func (rt *Router) autoHeadEndpoint(rte *route, method string) *endpoint {
+	if method != http.MethodHead || !rt.AutoHead {
+		return nil
+	}
+	if _, found := rte.endpoints[http.MethodHead]; found {
+		return nil
+	}
+	e, found := rte.endpoints[http.MethodGet]
+	if !found || e.noAutoHead {
+		return nil
+	}
+	return e
+}
+
+// headResponseWriter discards the response body of a GET handler
+// answering a HEAD request. It delays writing the header until the
+// handler finished, so that Content-Length can be set.
+type headResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	written     int64
+}
+
+// WriteHeader records the status code.
+func (w *headResponseWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+}
+
+// Write discards p, counting its size.
+func (w *headResponseWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	w.written += int64(len(p))
+	return len(p), nil
+}
+
+// finish writes the header to the underlying http.ResponseWriter.
+func (w *headResponseWriter) finish() {
+	if w.Header().Get("Content-Length") == "" && w.written > 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(w.written, 10))
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
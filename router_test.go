@@ -0,0 +1,345 @@
+package generichttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/olivere/generichttp"
+	"github.com/olivere/generichttp/testutil"
+)
+
+type routerItem struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func createRouterItem(w http.ResponseWriter, req generichttp.Request[routerItem]) (*generichttp.Response[routerItem], error) {
+	return generichttp.Created(req.Data), nil
+}
+
+func serveRouter(h http.Handler, method, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestRouterAutoOptions(t *testing.T) {
+	router := generichttp.NewRouter()
+	router.Post("/items", generichttp.JSON(createRouterItem))
+
+	w := serveRouter(router, http.MethodOptions, "/items")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if got := w.Header().Get("Allow"); got != "OPTIONS, POST" {
+		t.Errorf("Allow = %q, want %q", got, "OPTIONS, POST")
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("body = %q, want none", w.Body)
+	}
+
+	w = serveRouter(router, http.MethodGet, "/items")
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET: status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+	if got := w.Header().Get("Allow"); got != "OPTIONS, POST" {
+		t.Errorf("GET: Allow = %q, want %q", got, "OPTIONS, POST")
+	}
+	testutil.AssertJSON(t, w, map[string]any{"message": "Method not allowed"})
+
+	w = serveRouter(router, http.MethodOptions, "/unknown")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown path: status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestRouterAutoOptionsDisabled(t *testing.T) {
+	router := generichttp.NewRouter()
+	router.AutoOptions = false
+	router.Post("/items", generichttp.JSON(createRouterItem))
+
+	w := serveRouter(router, http.MethodOptions, "/items")
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+	if got := w.Header().Get("Allow"); got != "POST" {
+		t.Errorf("Allow = %q, want %q", got, "POST")
+	}
+}
+
+func TestRouterOptionsHandlerTakesPrecedence(t *testing.T) {
+	router := generichttp.NewRouter()
+	router.Post("/items", generichttp.JSON(createRouterItem))
+	router.Handle(http.MethodOptions, "/items", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Methods", "POST")
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	w := serveRouter(router, http.MethodOptions, "/items")
+	if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Methods") != "POST" {
+		t.Errorf("status = %d, headers = %v; want the OPTIONS handler", w.Code, w.Header())
+	}
+}
+
+func getRouterItem(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[routerItem], error) {
+	id := req.PathValue("id")
+	if id == "missing" {
+		return nil, generichttp.NotFoundError{Message: "No such item"}
+	}
+	resp := generichttp.OK(&routerItem{ID: id, Name: "Widget"})
+	resp.Header = http.Header{"Etag": {`"v1"`}, "X-Item": {id}}
+	return resp, nil
+}
+
+func TestRouterAutoHead(t *testing.T) {
+	router := generichttp.NewRouter()
+	router.Get("/items/{id}", generichttp.JSON(getRouterItem))
+
+	for _, target := range []string{"/items/7", "/items/missing"} {
+		get := serveRouter(router, http.MethodGet, target)
+		head := serveRouter(router, http.MethodHead, target)
+		if head.Code != get.Code {
+			t.Errorf("%s: HEAD status = %d, GET status = %d", target, head.Code, get.Code)
+		}
+		if head.Body.Len() != 0 {
+			t.Errorf("%s: HEAD body = %q, want none", target, head.Body)
+		}
+		if got, want := head.Header().Get("Content-Length"), strconv.Itoa(get.Body.Len()); got != want {
+			t.Errorf("%s: HEAD Content-Length = %q, want %q, the size of the GET body", target, got, want)
+		}
+		for _, name := range []string{"Content-Type", "Etag", "X-Item"} {
+			if got, want := head.Header().Get(name), get.Header().Get(name); got != want {
+				t.Errorf("%s: HEAD %s = %q, GET %s = %q", target, name, got, name, want)
+			}
+		}
+	}
+
+	w := serveRouter(router, http.MethodOptions, "/items/7")
+	if got := w.Header().Get("Allow"); got != "GET, HEAD, OPTIONS" {
+		t.Errorf("Allow = %q, want %q", got, "GET, HEAD, OPTIONS")
+	}
+}
+
+func TestRouterWithoutAutoHead(t *testing.T) {
+	router := generichttp.NewRouter()
+	router.Get("/items/{id}", generichttp.JSON(getRouterItem), generichttp.WithoutAutoHead())
+
+	w := serveRouter(router, http.MethodHead, "/items/7")
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+	if got := w.Header().Get("Allow"); got != "GET, OPTIONS" {
+		t.Errorf("Allow = %q, want %q", got, "GET, OPTIONS")
+	}
+}
+
+func TestRouterDisallowedMethods(t *testing.T) {
+	router := generichttp.NewRouter()
+	router.Get("/items/{id}", generichttp.JSON(getRouterItem))
+	router.Handle(http.MethodTrace, "/debug", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, tt := range []struct{ method, target string }{
+		{http.MethodTrace, "/items/7"},
+		{http.MethodTrace, "/debug"},
+		{http.MethodTrace, "/unknown"},
+		{"trace", "/items/7"},
+		{http.MethodConnect, "/items/7"},
+	} {
+		w := serveRouter(router, tt.method, tt.target)
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.target, w.Code, http.StatusMethodNotAllowed)
+			continue
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%s %s: Content-Type = %q, want application/json", tt.method, tt.target, ct)
+		}
+		testutil.AssertJSON(t, w, map[string]any{"message": "Method not allowed"})
+	}
+
+	if w := serveRouter(router, http.MethodOptions, "/items/7"); w.Code != http.StatusNoContent {
+		t.Errorf("OPTIONS: status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+
+	router.DisallowedMethods = []string{http.MethodGet}
+	if w := serveRouter(router, http.MethodTrace, "/debug"); w.Code != http.StatusOK {
+		t.Errorf("TRACE allowed again: status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := serveRouter(router, http.MethodGet, "/items/7"); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET disallowed: status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+// deadlineHandler reports the time left until the deadline of the request
+// context in the X-Time-Left header, or "none".
+var deadlineHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	left := "none"
+	if deadline, ok := r.Context().Deadline(); ok {
+		left = time.Until(deadline).Round(time.Hour).String()
+	}
+	w.Header().Set("X-Time-Left", left)
+	w.WriteHeader(http.StatusNoContent)
+})
+
+func TestRouterTimeout(t *testing.T) {
+	router := generichttp.NewRouter()
+	router.Timeout = time.Hour
+	router.Get("/default", deadlineHandler)
+	router.Get("/route", deadlineHandler, generichttp.WithTimeout(3*time.Hour))
+	router.Get("/disabled", deadlineHandler, generichttp.WithTimeout(-1))
+	group := router.Group("reports", generichttp.WithGroupTimeout(5*time.Hour))
+	group.Get("/group", deadlineHandler)
+	group.Get("/group/route", deadlineHandler, generichttp.WithTimeout(7*time.Hour))
+
+	for target, want := range map[string]string{
+		"/default":     "1h0m0s",
+		"/route":       "3h0m0s",
+		"/disabled":    "none",
+		"/group":       "5h0m0s",
+		"/group/route": "7h0m0s",
+	} {
+		w := serveRouter(router, http.MethodGet, target)
+		if got := w.Header().Get("X-Time-Left"); w.Code != http.StatusNoContent || got != want {
+			t.Errorf("%s: status = %d, time left = %q; want %d, %q", target, w.Code, got, http.StatusNoContent, want)
+		}
+	}
+}
+
+func TestRouterTimeoutSlowHandler(t *testing.T) {
+	router := generichttp.NewRouter()
+	router.Get("/slow", generichttp.JSON(func(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[routerItem], error) {
+		<-req.Context().Done()
+		return generichttp.OK(&routerItem{ID: "late"}), nil
+	}), generichttp.WithTimeout(20*time.Millisecond))
+
+	start := time.Now()
+	w := serveRouter(router, http.MethodGet, "/slow")
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusGatewayTimeout)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	testutil.AssertJSON(t, w, map[string]any{"message": "Request timed out"})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %v, want about the timeout", elapsed)
+	}
+}
+
+type routerSearch struct {
+	Q string `json:"q" query:"q"`
+}
+
+func TestRouterHandleMethods(t *testing.T) {
+	router := generichttp.NewRouter()
+	router.HandleMethods([]string{http.MethodGet, http.MethodPost}, "/search", generichttp.JSON(
+		func(w http.ResponseWriter, req generichttp.Request[routerSearch]) (*generichttp.Response[map[string]string], error) {
+			return generichttp.OK(&map[string]string{"method": req.Method, "q": req.Data.Q}), nil
+		}))
+
+	w := serveRouter(router, http.MethodGet, "/search?q=gophers")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET: status = %d, want %d", w.Code, http.StatusOK)
+	}
+	testutil.AssertJSON(t, w, map[string]any{"method": "GET", "q": "gophers"})
+
+	r := httptest.NewRequest(http.MethodPost, "/search", strings.NewReader(`{"q":"gophers"}`))
+	r.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST: status = %d, want %d", w.Code, http.StatusOK)
+	}
+	testutil.AssertJSON(t, w, map[string]any{"method": "POST", "q": "gophers"})
+
+	for _, method := range []string{http.MethodPut, http.MethodDelete} {
+		w := serveRouter(router, method, "/search")
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("%s: status = %d, want %d", method, w.Code, http.StatusMethodNotAllowed)
+		}
+		if got, want := w.Header().Get("Allow"), "GET, HEAD, OPTIONS, POST"; got != want {
+			t.Errorf("%s: Allow = %q, want %q", method, got, want)
+		}
+		testutil.AssertJSON(t, w, map[string]any{"message": "Method not allowed"})
+	}
+}
+
+func TestRouterSubrouter(t *testing.T) {
+	router := generichttp.NewRouter()
+	router.AutoHead = false
+	api := router.Subrouter("/api")
+	v1 := api.Subrouter("/v1/")
+	v1.Get("/items/{id}", generichttp.JSON(getRouterItem))
+	api.Get("/health", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Path", r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	router.Mount("/static", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Path", r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	w := serveRouter(router, http.MethodGet, "/api/v1/items/7")
+	if w.Code != http.StatusOK {
+		t.Fatalf("nested route: status = %d, want %d", w.Code, http.StatusOK)
+	}
+	testutil.AssertJSON(t, w, routerItem{ID: "7", Name: "Widget"})
+
+	w = serveRouter(router, http.MethodGet, "/api/v1/items/missing")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("nested handler error: status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	testutil.AssertJSON(t, w, map[string]any{"message": "No such item"})
+
+	for _, target := range []string{"/api/v1/unknown", "/api/v2/items/7", "/unknown"} {
+		w := serveRouter(router, http.MethodGet, target)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: status = %d, want %d", target, w.Code, http.StatusNotFound)
+		}
+		testutil.AssertJSON(t, w, map[string]any{"message": "Not found"})
+	}
+
+	if w := serveRouter(router, http.MethodGet, "/api/health"); w.Code != http.StatusNoContent || w.Header().Get("X-Path") != "/health" {
+		t.Errorf("/api/health: status = %d, path = %q; want %d, /health", w.Code, w.Header().Get("X-Path"), http.StatusNoContent)
+	}
+	if w := serveRouter(router, http.MethodGet, "/static/css/app.css"); w.Header().Get("X-Path") != "/css/app.css" {
+		t.Errorf("mounted handler saw path %q, want /css/app.css", w.Header().Get("X-Path"))
+	}
+	if w := serveRouter(router, http.MethodGet, "/static"); w.Code/100 != 3 || w.Header().Get("Location") != "/static/" {
+		t.Errorf("/static: status = %d, Location = %q; want a redirect to /static/", w.Code, w.Header().Get("Location"))
+	}
+
+	// Settings are inherited, so HEAD isn't answered automatically.
+	w = serveRouter(router, http.MethodHead, "/api/v1/items/7")
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("HEAD: status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+
+	var patterns []string
+	for _, r := range router.Routes() {
+		patterns = append(patterns, r.Method+" "+r.Pattern)
+	}
+	if got, want := strings.Join(patterns, ", "), "GET /api/health, GET /api/v1/items/{id}"; got != want {
+		t.Errorf("Routes = %s, want %s", got, want)
+	}
+}
+
+func TestRouterSubrouterMiddleware(t *testing.T) {
+	router := generichttp.NewRouter()
+	v1 := router.Subrouter("/v1")
+	v1.Get("/panic", generichttp.JSON(func(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[struct{}], error) {
+		panic("boom")
+	}))
+	h := generichttp.Recover()(router)
+
+	w := serveRouter(h, http.MethodGet, "/v1/panic")
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	testutil.AssertJSON(t, w, map[string]any{"message": "Internal server error"})
+}
@@ -0,0 +1,60 @@
+package generichttp
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+)
+
+// Route describes a route registered with a Router.
+type Route struct {
+	Method  string
+	Pattern string
+	Summary string
+	Tags    []string
+	Handler http.Handler
+	// RequestType and ResponseType are the types of the request and
+	// response data of handlers created by JSON or Auto, or nil for other
+	// handlers. See HandlerTypes.
+	RequestType  reflect.Type
+	ResponseType reflect.Type
+}
+
+// Routes returns the routes registered with the router, sorted by pattern
+// and method, e.g. for generating documentation, mock servers, or contract
+// tests.
+func (rt *Router) Routes() []Route {
+	var routes []Route
+	rt.eachRoute(func(pattern string, rte *route) {
+		for method, e := range rte.endpoints {
+			r := Route{
+				Method:  method,
+				Pattern: pattern,
+				Summary: e.summary,
+				Tags:    e.tags,
+				Handler: e.handler,
+			}
+			r.RequestType, r.ResponseType, _ = HandlerTypes(e.handler)
+			routes = append(routes, r)
+		}
+	})
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Pattern != routes[j].Pattern {
+			return routes[i].Pattern < routes[j].Pattern
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// HandlerTypes returns the types of the request and response data of a
+// handler created by JSON or Auto, i.e. R and W. It reports false for
+// other handlers, including handlers wrapped in middleware.
+func HandlerTypes(h http.Handler) (req, resp reflect.Type, ok bool) {
+	th, ok := h.(typedHandler)
+	if !ok {
+		return nil, nil, false
+	}
+	req, resp = th.dataTypes()
+	return req, resp, true
+}
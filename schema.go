@@ -0,0 +1,284 @@
+package generichttp
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is a subset of JSON Schema, sufficient to describe the request
+// and response data of handlers.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// SchemaFor returns the JSON Schema of T. It follows the rules of
+// encoding/json, i.e. it respects the json struct tag, including
+// omitempty and "-", and flattens embedded structs.
+//
+// A property is required if it has a `validate:"required"` tag, or if
+// it is neither omitempty nor a pointer. The min, max, len, and oneof
+// constraints of the validate tag are mapped to the corresponding
+// JSON Schema keywords, email and url to formats. The enum tag, see
+// WithEnumValidation, is mapped to enum as well.
+//
+// SchemaFor returns an error for types that cannot be represented in JSON,
+// e.g. channels and functions, and for recursive types.
+func SchemaFor[T any]() (*Schema, error) {
+	return schemaOf(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// schemaOf returns the JSON Schema of t.
+func schemaOf(t reflect.Type) (*Schema, error) {
+	return (&schemaBuilder{visiting: make(map[reflect.Type]bool)}).build(t)
+}
+
+// schemaBuilder builds a Schema while detecting recursive types.
+type schemaBuilder struct {
+	visiting map[reflect.Type]bool
+}
+
+func (b *schemaBuilder) build(t reflect.Type) (*Schema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}, nil
+	case t == rawMessageType:
+		return &Schema{}, nil
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return &Schema{}, nil
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return &Schema{Type: "string"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}, nil
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+	case reflect.Interface:
+		return &Schema{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}, nil
+		}
+		items, err := b.build(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		switch t.Key().Kind() {
+		case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			if !t.Key().Implements(textMarshalerType) {
+				return nil, fmt.Errorf("generichttp: unsupported map key type %s", t.Key())
+			}
+		}
+		values, err := b.build(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Struct:
+		if b.visiting[t] {
+			return nil, fmt.Errorf("generichttp: recursive type %s", t)
+		}
+		b.visiting[t] = true
+		defer delete(b.visiting, t)
+		s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		if err := b.fields(s, t); err != nil {
+			return nil, err
+		}
+		sort.Strings(s.Required)
+		return s, nil
+	default:
+		return nil, fmt.Errorf("generichttp: unsupported type %s", t)
+	}
+}
+
+// fields adds the properties of struct type t to s.
+func (b *schemaBuilder) fields(s *Schema, t reflect.Type) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if err := b.fields(s, ft); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		prop, err := b.build(f.Type)
+		if err != nil {
+			return fmt.Errorf("%w (field %s)", err, f.Name)
+		}
+		if strings.Contains(opts, "string") && prop.Type != "object" && prop.Type != "array" {
+			prop.Type = "string"
+		}
+		required := !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Pointer
+		if applyValidateTag(prop, f.Tag.Get("validate")) {
+			required = true
+		}
+		if tag, found := f.Tag.Lookup("enum"); found {
+			target := prop
+			if prop.Type == "array" && prop.Items != nil {
+				target = prop.Items
+			}
+			applyValidateTag(target, "oneof="+strings.Join(enumValues(tag), " "))
+		}
+		if required {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+	return nil
+}
+
+// applyValidateTag maps the constraints of a validate struct tag to s.
+// It reports whether the tag marks the field as required.
+func applyValidateTag(s *Schema, tag string) (required bool) {
+	if tag == "" {
+		return false
+	}
+	for _, rule := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			required = true
+		case "email":
+			s.Format = "email"
+		case "url":
+			s.Format = "uri"
+		case "oneof":
+			for _, v := range strings.Fields(value) {
+				if n, err := strconv.ParseFloat(v, 64); err == nil && (s.Type == "integer" || s.Type == "number") {
+					s.Enum = append(s.Enum, n)
+				} else {
+					s.Enum = append(s.Enum, v)
+				}
+			}
+		case "min", "gte", "max", "lte", "len":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			lower := key == "min" || key == "gte" || key == "len"
+			upper := key == "max" || key == "lte" || key == "len"
+			switch s.Type {
+			case "string":
+				if lower {
+					s.MinLength = intPtr(int(n))
+				}
+				if upper {
+					s.MaxLength = intPtr(int(n))
+				}
+			case "array":
+				if lower {
+					s.MinItems = intPtr(int(n))
+				}
+				if upper {
+					s.MaxItems = intPtr(int(n))
+				}
+			case "integer", "number":
+				if lower {
+					s.Minimum = &n
+				}
+				if upper {
+					s.Maximum = &n
+				}
+			}
+		}
+	}
+	return required
+}
+
+func intPtr(n int) *int { return &n }
+
+// SchemaHandler returns a http.Handler that serves the JSON Schemas of the
+// request and response data of all routes registered with JSON handlers,
+// keyed by method and pattern, e.g. "POST /add". Routes of mounted Routers
+// are included with the prefix, see Mount.
+func (rt *Router) SchemaHandler() http.Handler {
+	type routeSchemas struct {
+		Request  *Schema `json:"request"`
+		Response *Schema `json:"response"`
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		schemas := make(map[string]routeSchemas)
+		var err error
+		rt.eachRoute(func(pattern string, rte *route) {
+			if err != nil {
+				return
+			}
+			for method, e := range rte.endpoints {
+				th, ok := e.handler.(typedHandler)
+				if !ok {
+					continue
+				}
+				reqType, respType := th.dataTypes()
+				var rs routeSchemas
+				if rs.Request, err = schemaOf(reqType); err != nil {
+					return
+				}
+				if rs.Response, err = schemaOf(respType); err != nil {
+					return
+				}
+				schemas[method+" "+pattern] = rs
+			}
+		})
+		if err != nil {
+			WriteJSONError(w, err)
+			return
+		}
+		WriteJSON(w, schemas)
+	})
+}
@@ -0,0 +1,45 @@
+package generichttp_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/olivere/generichttp"
+)
+
+func TestSchemaHandlerSubrouter(t *testing.T) {
+	router := generichttp.NewRouter()
+	router.Post("/items", generichttp.JSON(createRouterItem))
+	v1 := router.Subrouter("/api/v1")
+	v1.Get("/items/{id}", generichttp.JSON(getRouterItem))
+	v1.Subrouter("/admin").Post("/items", generichttp.JSON(createRouterItem))
+	router.Get("/schemas", router.SchemaHandler())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/schemas", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	var schemas map[string]struct {
+		Request  *generichttp.Schema `json:"request"`
+		Response *generichttp.Schema `json:"response"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &schemas); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for key, s := range schemas {
+		keys = append(keys, key)
+		if s.Response == nil || s.Response.Properties["name"] == nil {
+			t.Errorf("%s: response schema = %+v, want the properties of routerItem", key, s.Response)
+		}
+	}
+	slices.Sort(keys)
+	want := []string{"GET /api/v1/items/{id}", "POST /api/v1/admin/items", "POST /items"}
+	if !slices.Equal(keys, want) {
+		t.Errorf("schemas = %v, want %v", keys, want)
+	}
+}
@@ -0,0 +1,58 @@
+package generichttp
+
+import "net/http"
+
+// SecurityHeadersOptions configures the SecurityHeaders middleware.
+// A header with an empty value is not being written.
+type SecurityHeadersOptions struct {
+	// ContentTypeOptions is the value of X-Content-Type-Options.
+	ContentTypeOptions string
+	// FrameOptions is the value of X-Frame-Options.
+	FrameOptions string
+	// ReferrerPolicy is the value of Referrer-Policy.
+	ReferrerPolicy string
+	// ContentSecurityPolicy is the value of Content-Security-Policy.
+	ContentSecurityPolicy string
+}
+
+// DefaultSecurityHeaders returns sensible defaults for public-facing
+// servers. Override or clear individual fields as needed.
+func DefaultSecurityHeaders() SecurityHeadersOptions {
+	return SecurityHeadersOptions{
+		ContentTypeOptions:    "nosniff",
+		FrameOptions:          "DENY",
+		ReferrerPolicy:        "no-referrer",
+		ContentSecurityPolicy: "default-src 'self'",
+	}
+}
+
+// StrictAPISecurityHeaders returns defaults for JSON APIs that are never
+// rendered by a browser. It omits browser-only headers like X-Frame-Options
+// and Referrer-Policy, and forbids loading any content.
+func StrictAPISecurityHeaders() SecurityHeadersOptions {
+	return SecurityHeadersOptions{
+		ContentTypeOptions:    "nosniff",
+		ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+	}
+}
+
+// SecurityHeaders returns a middleware that sets the security headers
+// configured in opts on every response.
+func SecurityHeaders(opts SecurityHeadersOptions) Middleware {
+	headers := map[string]string{
+		"X-Content-Type-Options":  opts.ContentTypeOptions,
+		"X-Frame-Options":         opts.FrameOptions,
+		"Referrer-Policy":         opts.ReferrerPolicy,
+		"Content-Security-Policy": opts.ContentSecurityPolicy,
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for name, value := range headers {
+				if value != "" {
+					w.Header().Set(name, value)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package generichttp
+
+import (
+	"context"
+	"sync"
+)
+
+// SingleFlight deduplicates concurrent calls of an expensive operation,
+// e.g. a database query or an upstream request, by key: while a call for
+// a key is in flight, callers with the same key wait for it and share its
+// result. Unlike the Coalesce middleware, it works inside handlers, on
+// keys and values of the handler's choosing:
+//
+//	var products generichttp.SingleFlight[string, *Product]
+//
+//	func getProduct(w http.ResponseWriter, r generichttp.Request[struct{}]) (*generichttp.Response[Product], error) {
+//		p, err := products.Do(r.Context(), r.PathValue("id"), func(ctx context.Context) (*Product, error) {
+//			return db.LoadProduct(ctx, r.PathValue("id"))
+//		})
+//		if err != nil {
+//			return nil, err
+//		}
+//		return generichttp.OK(p), nil
+//	}
+//
+// Results are not cached: a call after one has finished starts a new one.
+// The shared value is returned to all callers as is, so it must not be
+// modified by them. The zero value is ready to use and a SingleFlight
+// must not be copied after first use.
+type SingleFlight[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*flightCall[V]
+}
+
+// flightCall is a call in flight of a SingleFlight. val and err are set
+// before done is closed.
+type flightCall[V any] struct {
+	done    chan struct{}
+	val     V
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// Do calls fn for key unless a call for key is in flight, and returns the
+// result of the call. fn runs with a context that has the values of ctx
+// and is canceled when all callers waiting for the call have given up,
+// i.e. a single caller that goes away doesn't fail the others. A caller
+// whose ctx is done stops waiting and gets the error of ctx. A panic in fn
+// is returned as an error to all callers.
+func (g *SingleFlight[K, V]) Do(ctx context.Context, key K, fn func(ctx context.Context) (V, error)) (V, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[K]*flightCall[V])
+	}
+	c, found := g.calls[key]
+	if found {
+		c.waiters++
+	} else {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		c = &flightCall[V]{done: make(chan struct{}), waiters: 1, cancel: cancel}
+		g.calls[key] = c
+		go g.call(callCtx, key, c, fn)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.val, c.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			// Nobody waits for the call anymore: cancel it and let the
+			// next caller start a new one.
+			c.cancel()
+			if g.calls[key] == c {
+				delete(g.calls, key)
+			}
+		}
+		g.mu.Unlock()
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// Forget makes the next call for key start a new call, even if one is in
+// flight, e.g. after the data of key was changed. Callers of the call in
+// flight still get its result.
+func (g *SingleFlight[K, V]) Forget(key K) {
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+}
+
+// call runs fn for c and publishes its result.
+func (g *SingleFlight[K, V]) call(ctx context.Context, key K, c *flightCall[V], fn func(ctx context.Context) (V, error)) {
+	defer func() {
+		if p := recover(); p != nil {
+			c.err = panicError{value: p}
+		}
+		g.mu.Lock()
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+		g.mu.Unlock()
+		c.cancel()
+		close(c.done)
+	}()
+	c.val, c.err = fn(ctx)
+}
@@ -0,0 +1,217 @@
+package generichttp_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/olivere/generichttp"
+)
+
+type flightTestKey struct{}
+
+// slowLoad is an expensive operation that blocks until release is closed
+// or its context is canceled.
+type slowLoad struct {
+	calls    atomic.Int32
+	started  chan struct{}
+	release  chan struct{}
+	canceled chan error
+}
+
+func newSlowLoad() *slowLoad {
+	return &slowLoad{
+		started:  make(chan struct{}, 16),
+		release:  make(chan struct{}),
+		canceled: make(chan error, 16),
+	}
+}
+
+func (l *slowLoad) load(ctx context.Context) (string, error) {
+	n := l.calls.Add(1)
+	l.started <- struct{}{}
+	select {
+	case <-l.release:
+		value, _ := ctx.Value(flightTestKey{}).(string)
+		return value + "-" + string(rune('0'+n)), nil
+	case <-ctx.Done():
+		l.canceled <- ctx.Err()
+		return "", ctx.Err()
+	}
+}
+
+type flightResult struct {
+	val string
+	err error
+}
+
+func TestSingleFlightShared(t *testing.T) {
+	var g generichttp.SingleFlight[string, string]
+	l := newSlowLoad()
+	ctx := context.WithValue(context.Background(), flightTestKey{}, "first")
+
+	results := make([]flightResult, 5)
+	var wg sync.WaitGroup
+	do := func(i int, ctx context.Context) {
+		defer wg.Done()
+		val, err := g.Do(ctx, "item", l.load)
+		results[i] = flightResult{val, err}
+	}
+	wg.Add(len(results))
+	go do(0, ctx)
+	<-l.started
+	for i := 1; i < len(results); i++ {
+		go do(i, context.WithValue(context.Background(), flightTestKey{}, "other"))
+	}
+	time.Sleep(50 * time.Millisecond) // let the others start waiting
+	close(l.release)
+	wg.Wait()
+
+	if n := l.calls.Load(); n != 1 {
+		t.Errorf("called %d times, want 1", n)
+	}
+	for i, res := range results {
+		if res.err != nil || res.val != "first-1" {
+			t.Errorf("caller %d got %q, %v; want the result of the first call", i, res.val, res.err)
+		}
+	}
+
+	// The result is not cached.
+	if val, err := g.Do(ctx, "item", l.load); err != nil || val != "first-2" {
+		t.Errorf("next call got %q, %v; want a new call", val, err)
+	}
+}
+
+func TestSingleFlightKeys(t *testing.T) {
+	var g generichttp.SingleFlight[int, int]
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	var calls atomic.Int32
+	for key := range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			val, err := g.Do(context.Background(), key, func(ctx context.Context) (int, error) {
+				calls.Add(1)
+				<-release
+				return key * 10, nil
+			})
+			if err != nil || val != key*10 {
+				t.Errorf("key %d: got %d, %v", key, val, err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := calls.Load(); n != 3 {
+		t.Errorf("called %d times, want one per key", n)
+	}
+}
+
+func TestSingleFlightCancel(t *testing.T) {
+	var g generichttp.SingleFlight[string, string]
+	l := newSlowLoad()
+
+	first, cancelFirst := context.WithCancel(context.Background())
+	firstDone := make(chan flightResult, 1)
+	go func() {
+		val, err := g.Do(first, "item", l.load)
+		firstDone <- flightResult{val, err}
+	}()
+	<-l.started
+	second := make(chan flightResult, 1)
+	go func() {
+		val, err := g.Do(context.Background(), "item", l.load)
+		second <- flightResult{val, err}
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// The first caller going away doesn't fail the call for the second.
+	cancelFirst()
+	if res := <-firstDone; !errors.Is(res.err, context.Canceled) {
+		t.Errorf("first caller got %q, %v; want context.Canceled", res.val, res.err)
+	}
+	select {
+	case err := <-l.canceled:
+		t.Fatalf("call canceled with %v while a caller is waiting", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(l.release)
+	if res := <-second; res.err != nil || res.val != "-1" {
+		t.Errorf("second caller got %q, %v; want the result of the call", res.val, res.err)
+	}
+}
+
+func TestSingleFlightCancelAll(t *testing.T) {
+	var g generichttp.SingleFlight[string, string]
+	l := newSlowLoad()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := g.Do(ctx, "item", l.load)
+		done <- err
+	}()
+	<-l.started
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("caller got %v, want context.Canceled", err)
+	}
+	select {
+	case err := <-l.canceled:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("call canceled with %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("call not canceled after all callers gave up")
+	}
+
+	// The next caller starts a new call.
+	close(l.release)
+	if val, err := g.Do(context.Background(), "item", l.load); err != nil || val != "-2" {
+		t.Errorf("next call got %q, %v; want a new call", val, err)
+	}
+}
+
+func TestSingleFlightForget(t *testing.T) {
+	var g generichttp.SingleFlight[string, string]
+	l := newSlowLoad()
+	first := make(chan flightResult, 1)
+	go func() {
+		val, err := g.Do(context.Background(), "item", l.load)
+		first <- flightResult{val, err}
+	}()
+	<-l.started
+	g.Forget("item")
+	second := make(chan flightResult, 1)
+	go func() {
+		val, err := g.Do(context.Background(), "item", l.load)
+		second <- flightResult{val, err}
+	}()
+	<-l.started
+	close(l.release)
+	a, b := <-first, <-second
+	if a.err != nil || b.err != nil || a.val == b.val || l.calls.Load() != 2 {
+		t.Errorf("got %q and %q, want two separate calls", a.val, b.val)
+	}
+}
+
+func TestSingleFlightPanic(t *testing.T) {
+	var g generichttp.SingleFlight[string, string]
+	errBoom := errors.New("boom")
+	_, err := g.Do(context.Background(), "item", func(ctx context.Context) (string, error) {
+		panic(errBoom)
+	})
+	if err == nil || err.Error() != "panic: boom" || !errors.Is(err, errBoom) {
+		t.Errorf("got %v, want the panic as an error", err)
+	}
+	if val, err := g.Do(context.Background(), "item", func(ctx context.Context) (string, error) {
+		return "ok", nil
+	}); err != nil || val != "ok" {
+		t.Errorf("next call got %q, %v; want a new call", val, err)
+	}
+}
@@ -0,0 +1,92 @@
+package generichttp
+
+import (
+	"net/http"
+	"strings"
+)
+
+// SmugglingOptions configures the RejectSmuggling middleware.
+type SmugglingOptions struct {
+	// RejectChunked rejects all requests with a chunked body, i.e. clients
+	// must send a Content-Length. Use it behind proxies that are known to
+	// disagree with the server about where chunked bodies end.
+	RejectChunked bool
+}
+
+// RejectSmuggling returns a middleware that rejects requests whose body
+// framing is ambiguous with a BadRequestError, and closes the connection.
+//
+// Request smuggling exploits a proxy and a server that disagree on where
+// a request ends, e.g. because one uses the Content-Length header and the
+// other the Transfer-Encoding header. The rest of the body is then taken
+// as the start of the next request on the connection, which may be that
+// of another user, and bypasses the checks of the proxy. The middleware
+// rejects requests with:
+//
+//   - several Content-Length values, or one that isn't a plain number;
+//   - both a Content-Length and a Transfer-Encoding;
+//   - a Transfer-Encoding other than "chunked", or one in an HTTP/1.0
+//     request;
+//   - a chunked body if SmugglingOptions.RejectChunked is set.
+//
+// The server of net/http already rejects or normalizes most of these
+// before the middleware sees the request, e.g. it drops the Content-Length
+// of a chunked request, so the checks are a second line of defense, e.g.
+// with other servers or adapters that pass the headers as received. It is
+// opt-in, as it may reject requests of lenient clients; use it if the
+// service runs behind proxies that you don't control.
+func RejectSmuggling(opts SmugglingOptions) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if msg := ambiguousFraming(r, opts); msg != "" {
+				w.Header().Set("Connection", "close")
+				writeJSONError(w, r, BadRequestError{Message: msg})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ambiguousFraming returns why the body framing of r is ambiguous, or ""
+// if it isn't.
+func ambiguousFraming(r *http.Request, opts SmugglingOptions) string {
+	lengths := r.Header.Values("Content-Length")
+	var encodings []string
+	encodings = append(encodings, r.Header.Values("Transfer-Encoding")...)
+	encodings = append(encodings, r.TransferEncoding...)
+	if len(lengths) > 1 || (len(lengths) == 1 && !isDigits(lengths[0])) {
+		return "Invalid Content-Length"
+	}
+	if len(encodings) == 0 {
+		return ""
+	}
+	if len(lengths) > 0 {
+		return "Both Content-Length and Transfer-Encoding"
+	}
+	for _, enc := range encodings {
+		if !strings.EqualFold(enc, "chunked") {
+			return "Invalid Transfer-Encoding"
+		}
+	}
+	if !r.ProtoAtLeast(1, 1) {
+		return "Transfer-Encoding in HTTP/1.0 request"
+	}
+	if opts.RejectChunked {
+		return "Chunked request bodies are not allowed"
+	}
+	return ""
+}
+
+// isDigits reports whether s is a non-empty string of ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package generichttp_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/olivere/generichttp"
+	"github.com/olivere/generichttp/testutil"
+)
+
+func TestRejectSmuggling(t *testing.T) {
+	tests := []struct {
+		name             string
+		opts             generichttp.SmugglingOptions
+		proto            string
+		contentLength    []string
+		transferEncoding []string
+		parsedEncoding   []string
+		message          string
+	}{
+		{name: "no body"},
+		{name: "content length", contentLength: []string{"12"}},
+		{name: "chunked", transferEncoding: []string{"chunked"}},
+		{name: "parsed chunked", parsedEncoding: []string{"chunked"}},
+		{name: "chunked mixed case", transferEncoding: []string{"Chunked"}},
+		{name: "several content lengths", contentLength: []string{"12", "12"}, message: "Invalid Content-Length"},
+		{name: "signed content length", contentLength: []string{"+12"}, message: "Invalid Content-Length"},
+		{name: "content length list", contentLength: []string{"12, 12"}, message: "Invalid Content-Length"},
+		{name: "empty content length", contentLength: []string{""}, message: "Invalid Content-Length"},
+		{name: "both", contentLength: []string{"12"}, transferEncoding: []string{"chunked"}, message: "Both Content-Length and Transfer-Encoding"},
+		{name: "both parsed", contentLength: []string{"12"}, parsedEncoding: []string{"chunked"}, message: "Both Content-Length and Transfer-Encoding"},
+		{name: "gzip", transferEncoding: []string{"gzip, chunked"}, message: "Invalid Transfer-Encoding"},
+		{name: "obfuscated", transferEncoding: []string{"chunked", "x"}, message: "Invalid Transfer-Encoding"},
+		{name: "HTTP/1.0", proto: "HTTP/1.0", transferEncoding: []string{"chunked"}, message: "Transfer-Encoding in HTTP/1.0 request"},
+		{name: "HTTP/1.0 content length", proto: "HTTP/1.0", contentLength: []string{"12"}},
+		{name: "reject chunked", opts: generichttp.SmugglingOptions{RejectChunked: true}, transferEncoding: []string{"chunked"}, message: "Chunked request bodies are not allowed"},
+		{name: "reject chunked content length", opts: generichttp.SmugglingOptions{RejectChunked: true}, contentLength: []string{"12"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called bool
+			h := generichttp.RejectSmuggling(tt.opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			r := httptest.NewRequest(http.MethodPost, "/items", nil)
+			if tt.proto != "" {
+				r.Proto = tt.proto
+				r.ProtoMajor, r.ProtoMinor, _ = http.ParseHTTPVersion(tt.proto)
+			}
+			r.Header["Content-Length"] = tt.contentLength
+			r.Header["Transfer-Encoding"] = tt.transferEncoding
+			r.TransferEncoding = tt.parsedEncoding
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if tt.message == "" {
+				if !called || w.Code != http.StatusNoContent {
+					t.Errorf("status = %d, handler called = %v; want the request passed on", w.Code, called)
+				}
+				return
+			}
+			if called {
+				t.Error("handler called for an ambiguous request")
+			}
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+			if got := w.Header().Get("Connection"); got != "close" {
+				t.Errorf("Connection = %q, want close", got)
+			}
+			testutil.AssertJSON(t, w, map[string]any{"message": tt.message})
+		})
+	}
+}
+
+func TestRejectSmugglingChunkedServer(t *testing.T) {
+	srv := httptest.NewServer(generichttp.RejectSmuggling(generichttp.SmugglingOptions{RejectChunked: true})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.Copy(w, r.Body)
+		})))
+	defer srv.Close()
+
+	// A body of unknown length is sent chunked.
+	resp, err := http.Post(srv.URL, "text/plain", io.MultiReader(strings.NewReader("hello")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("chunked: status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	resp, err = http.Post(srv.URL, "text/plain", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "hello" {
+		t.Errorf("content length: status = %d, body = %q; want %d, hello", resp.StatusCode, body, http.StatusOK)
+	}
+}
@@ -0,0 +1,127 @@
+package generichttp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StaticOptions configures StaticHandler.
+type StaticOptions struct {
+	// CacheControl is the Cache-Control header of files. It defaults to
+	// "public, max-age=3600". The fallback file is always served with
+	// "no-cache", so that clients pick up new deployments.
+	CacheControl string
+	// Fallback is the file served for paths that don't exist, e.g.
+	// "index.html" for single-page applications with client-side routing.
+	// If it is empty, missing files are answered with a NotFoundError.
+	Fallback string
+	// APIPrefixes are path prefixes, e.g. "/api/", for which missing files
+	// are always answered with a NotFoundError instead of the fallback.
+	APIPrefixes []string
+	// DisableCompression disables compression, see Compress.
+	DisableCompression bool
+}
+
+// staticFile is a file served by StaticHandler.
+type staticFile struct {
+	data    []byte
+	etag    string
+	modTime time.Time
+}
+
+// StaticHandler returns a http.Handler that serves the files of fsys, e.g.
+// an embed.FS with the assets of a single-page application. Directories
+// are served by their index.html.
+//
+// Files are served with a content type derived from their extension, a
+// Cache-Control header, and a strong ETag computed from their content, so
+// conditional and range requests work even for an embed.FS, which has no
+// modification times. Responses are compressed, see Compress, unless
+// disabled.
+//
+// Files are read into memory on first access and kept there, so use it
+// for static assets only.
+func StaticHandler(fsys fs.FS, opts StaticOptions) http.Handler {
+	if opts.CacheControl == "" {
+		opts.CacheControl = "public, max-age=3600"
+	}
+	var cache sync.Map // map[string]*staticFile
+
+	load := func(name string) (*staticFile, error) {
+		if f, found := cache.Load(name); found {
+			return f.(*staticFile), nil
+		}
+		info, err := fs.Stat(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			return nil, fs.ErrNotExist
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(data)
+		f := &staticFile{
+			data:    data,
+			etag:    `"` + hex.EncodeToString(sum[:16]) + `"`,
+			modTime: info.ModTime(),
+		}
+		cache.Store(name, f)
+		return f, nil
+	}
+
+	var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		if name == "" || strings.HasSuffix(r.URL.Path, "/") {
+			name = path.Join(name, "index.html")
+		}
+		cacheControl := opts.CacheControl
+		f, err := load(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			if info, serr := fs.Stat(fsys, name); serr == nil && info.IsDir() {
+				f, err = load(path.Join(name, "index.html"))
+			}
+		}
+		if errors.Is(err, fs.ErrNotExist) && opts.Fallback != "" && !isAPIPath(r.URL.Path, opts.APIPrefixes) {
+			name = opts.Fallback
+			f, err = load(name)
+		}
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				err = NotFoundError{}
+			}
+			writeJSONError(w, r, err)
+			return
+		}
+		if name == opts.Fallback {
+			cacheControl = "no-cache"
+		}
+		w.Header().Set("Cache-Control", cacheControl)
+		w.Header().Set("ETag", f.etag)
+		http.ServeContent(w, r, name, f.modTime, bytes.NewReader(f.data))
+	})
+	if !opts.DisableCompression {
+		h = Compress()(h)
+	}
+	return h
+}
+
+// isAPIPath reports whether p starts with one of the prefixes.
+func isAPIPath(p string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	return false
+}
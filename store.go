@@ -0,0 +1,383 @@
+package generichttp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// StoredResponse is a response stored in a ResponseStore.
+type StoredResponse struct {
+	StatusCode int         `json:"status"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       []byte      `json:"body,omitempty"`
+}
+
+// ResponseStore stores responses for the Cache and Idempotency middleware.
+// Implementations must be safe for concurrent use.
+//
+// Entries expire after the TTL given when storing them. Expired entries
+// must not be returned by Get and must not prevent Add; when their storage
+// is reclaimed is up to the implementation.
+type ResponseStore interface {
+	// Get returns the response stored for key, or nil if there is none.
+	Get(ctx context.Context, key string) (*StoredResponse, error)
+	// Set stores resp for key for the given duration, replacing an
+	// existing entry.
+	Set(ctx context.Context, key string, resp *StoredResponse, ttl time.Duration) error
+	// Add stores resp for key for the given duration if there is no entry
+	// for key yet, and reports whether it did. It must be atomic, e.g.
+	// with SET NX in Redis.
+	Add(ctx context.Context, key string, resp *StoredResponse, ttl time.Duration) (bool, error)
+	// Delete removes the entry for key, if any.
+	Delete(ctx context.Context, key string) error
+}
+
+// sweepInterval is the minimum interval between two sweeps of expired
+// entries in the stores of this package.
+const sweepInterval = time.Minute
+
+// memoryResponseStore is a ResponseStore in memory.
+type memoryResponseStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryResponseEntry
+	lastSweep time.Time
+}
+
+type memoryResponseEntry struct {
+	resp    *StoredResponse
+	expires time.Time
+}
+
+// NewMemoryResponseStore returns a ResponseStore that keeps responses in
+// memory. Expired entries are removed at most once a minute while the
+// store is in use. The number of entries is not limited, so keep TTLs
+// short for keys with a high cardinality, e.g. URLs with query strings.
+// The store is not shared between instances of a service.
+func NewMemoryResponseStore() ResponseStore {
+	return &memoryResponseStore{entries: make(map[string]memoryResponseEntry)}
+}
+
+// sweep removes expired entries, at most once per sweepInterval. The
+// caller must hold the lock.
+func (s *memoryResponseStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	for k, e := range s.entries {
+		if now.After(e.expires) {
+			delete(s.entries, k)
+		}
+	}
+	s.lastSweep = now
+}
+
+// Get implements ResponseStore.
+func (s *memoryResponseStore) Get(_ context.Context, key string) (*StoredResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, found := s.entries[key]; found && time.Now().Before(e.expires) {
+		return e.resp, nil
+	}
+	return nil, nil
+}
+
+// Set implements ResponseStore.
+func (s *memoryResponseStore) Set(_ context.Context, key string, resp *StoredResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.sweep(now)
+	s.entries[key] = memoryResponseEntry{resp: resp, expires: now.Add(ttl)}
+	return nil
+}
+
+// Add implements ResponseStore.
+func (s *memoryResponseStore) Add(_ context.Context, key string, resp *StoredResponse, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.sweep(now)
+	if e, found := s.entries[key]; found && now.Before(e.expires) {
+		return false, nil
+	}
+	s.entries[key] = memoryResponseEntry{resp: resp, expires: now.Add(ttl)}
+	return true, nil
+}
+
+// Delete implements ResponseStore.
+func (s *memoryResponseStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+// storedEntry is the encoding of a StoredResponse with its expiry, as used
+// by the file store.
+type storedEntry struct {
+	Expires  time.Time       `json:"expires"`
+	Response *StoredResponse `json:"response"`
+}
+
+// fileResponseStore is a ResponseStore in a directory.
+type fileResponseStore struct {
+	dir       string
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewFileResponseStore returns a ResponseStore that keeps responses in
+// files in dir, one file per key, named by the SHA-256 hash of the key.
+// The directory is created if it doesn't exist. Expired entries are
+// removed when they are read, and by a sweep of the directory at most once
+// a minute while the store is in use.
+//
+// The store survives restarts and can be shared between processes on the
+// same host, but not across hosts unless dir is on a file system that
+// supports atomic renames and hard links, which Add relies on.
+func NewFileResponseStore(dir string) (ResponseStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &fileResponseStore{dir: dir}, nil
+}
+
+// path returns the path of the file for key.
+func (s *fileResponseStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// read returns the entry in the file at path, or nil if there is none or
+// it expired.
+func (s *fileResponseStore) read(path string) (*StoredResponse, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	var e storedEntry
+	if err := json.Unmarshal(data, &e); err != nil || time.Now().After(e.Expires) {
+		// Expired or corrupt; remove it so that Add can succeed.
+		s.removeStale(path, fi)
+		return nil, nil
+	}
+	return e.Response, nil
+}
+
+// removeStale removes the file at path if it is still the file described
+// by fi. Another Set or Add may have replaced the stale file since it was
+// read; removing that one by path would drop a fresh entry and let a
+// second Add for the same key succeed. Set and Add hold the lock while
+// they replace files, so the check and the removal are atomic within the
+// process.
+func (s *fileResponseStore) removeStale(path string, fi fs.FileInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cur, err := os.Stat(path); err == nil && os.SameFile(fi, cur) {
+		_ = os.Remove(path)
+	}
+}
+
+// writeTemp writes the entry to a temporary file in the directory and
+// returns its path.
+func (s *fileResponseStore) writeTemp(resp *StoredResponse, ttl time.Duration) (string, error) {
+	s.sweep()
+	data, err := json.Marshal(storedEntry{Expires: time.Now().Add(ttl), Response: resp})
+	if err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(s.dir, "tmp-*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// sweep removes expired entries, at most once per sweepInterval.
+func (s *fileResponseStore) sweep() {
+	s.mu.Lock()
+	now := time.Now()
+	if now.Sub(s.lastSweep) < sweepInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = now
+	s.mu.Unlock()
+	matches, _ := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	for _, path := range matches {
+		_, _ = s.read(path)
+	}
+}
+
+// Get implements ResponseStore.
+func (s *fileResponseStore) Get(_ context.Context, key string) (*StoredResponse, error) {
+	return s.read(s.path(key))
+}
+
+// Set implements ResponseStore.
+func (s *fileResponseStore) Set(_ context.Context, key string, resp *StoredResponse, ttl time.Duration) error {
+	tmp, err := s.writeTemp(resp, ttl)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	err = os.Rename(tmp, s.path(key))
+	s.mu.Unlock()
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Add implements ResponseStore.
+func (s *fileResponseStore) Add(_ context.Context, key string, resp *StoredResponse, ttl time.Duration) (bool, error) {
+	path := s.path(key)
+	if existing, err := s.read(path); err != nil || existing != nil {
+		return false, err
+	}
+	tmp, err := s.writeTemp(resp, ttl)
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp)
+	// Link fails if the file exists, which makes Add atomic.
+	s.mu.Lock()
+	err = os.Link(tmp, path)
+	s.mu.Unlock()
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete implements ResponseStore.
+func (s *fileResponseStore) Delete(_ context.Context, key string) error {
+	err := os.Remove(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// RedisClient is the subset of a Redis client used by the ResponseStore
+// returned by NewRedisResponseStore. It keeps this package independent of
+// a specific Redis library; adapt the client of your choice, e.g. for
+// github.com/redis/go-redis:
+//
+//	type redisClient struct{ c *redis.Client }
+//
+//	func (rc redisClient) Get(ctx context.Context, key string) ([]byte, error) {
+//		b, err := rc.c.Get(ctx, key).Bytes()
+//		if err == redis.Nil {
+//			return nil, nil
+//		}
+//		return b, err
+//	}
+//
+//	func (rc redisClient) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+//		return rc.c.Set(ctx, key, value, ttl).Err()
+//	}
+//
+//	func (rc redisClient) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+//		return rc.c.SetNX(ctx, key, value, ttl).Result()
+//	}
+//
+//	func (rc redisClient) Del(ctx context.Context, key string) error {
+//		return rc.c.Del(ctx, key).Err()
+//	}
+type RedisClient interface {
+	// Get returns the value of key, or nil if it doesn't exist.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set sets the value of key with an expiry.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// SetNX sets the value of key with an expiry if key doesn't exist,
+	// and reports whether it did.
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	// Del deletes key.
+	Del(ctx context.Context, key string) error
+}
+
+// redisResponseStore is a ResponseStore in Redis.
+type redisResponseStore struct {
+	client RedisClient
+	prefix string
+}
+
+// NewRedisResponseStore returns a ResponseStore that keeps responses in
+// Redis, with keys prefixed by prefix, e.g. "responses:". Entries expire
+// by the TTL of their keys, so eviction is up to Redis. The store can be
+// shared between instances of a service.
+func NewRedisResponseStore(client RedisClient, prefix string) ResponseStore {
+	return &redisResponseStore{client: client, prefix: prefix}
+}
+
+// Get implements ResponseStore.
+func (s *redisResponseStore) Get(ctx context.Context, key string) (*StoredResponse, error) {
+	data, err := s.client.Get(ctx, s.prefix+key)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	var resp StoredResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Set implements ResponseStore.
+func (s *redisResponseStore) Set(ctx context.Context, key string, resp *StoredResponse, ttl time.Duration) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.prefix+key, data, ttl)
+}
+
+// Add implements ResponseStore.
+func (s *redisResponseStore) Add(ctx context.Context, key string, resp *StoredResponse, ttl time.Duration) (bool, error) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return false, err
+	}
+	return s.client.SetNX(ctx, s.prefix+key, data, ttl)
+}
+
+// Delete implements ResponseStore.
+func (s *redisResponseStore) Delete(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.prefix+key)
+}
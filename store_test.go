@@ -0,0 +1,159 @@
+package generichttp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newStores(t *testing.T) map[string]ResponseStore {
+	t.Helper()
+	file, err := NewFileResponseStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]ResponseStore{
+		"memory": NewMemoryResponseStore(),
+		"file":   file,
+	}
+}
+
+func TestResponseStore(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range newStores(t) {
+		t.Run(name, func(t *testing.T) {
+			resp := &StoredResponse{StatusCode: 201, Body: []byte(`{"id":1}`)}
+			if got, err := s.Get(ctx, "k"); err != nil || got != nil {
+				t.Fatalf("Get of missing key = %v, %v; want nil, nil", got, err)
+			}
+			if ok, err := s.Add(ctx, "k", resp, time.Minute); err != nil || !ok {
+				t.Fatalf("Add = %v, %v; want true, nil", ok, err)
+			}
+			if ok, err := s.Add(ctx, "k", resp, time.Minute); err != nil || ok {
+				t.Fatalf("second Add = %v, %v; want false, nil", ok, err)
+			}
+			got, err := s.Get(ctx, "k")
+			if err != nil || got == nil || got.StatusCode != 201 || string(got.Body) != `{"id":1}` {
+				t.Fatalf("Get = %+v, %v; want the stored response", got, err)
+			}
+			if err := s.Delete(ctx, "k"); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := s.Get(ctx, "k"); err != nil || got != nil {
+				t.Fatalf("Get after Delete = %v, %v; want nil, nil", got, err)
+			}
+			if err := s.Set(ctx, "k", resp, -time.Second); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := s.Get(ctx, "k"); err != nil || got != nil {
+				t.Fatalf("Get of expired entry = %v, %v; want nil, nil", got, err)
+			}
+		})
+	}
+}
+
+// TestResponseStoreAddExpiredConcurrently checks that of concurrent Adds
+// for a key with an expired entry exactly one succeeds, while concurrent
+// Gets remove the expired entry, i.e. that removing the expired entry never
+// removes the entry of a winning Add.
+func TestResponseStoreAddExpiredConcurrently(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range newStores(t) {
+		t.Run(name, func(t *testing.T) {
+			for round := 0; round < 50; round++ {
+				key := fmt.Sprintf("key-%d", round)
+				expired := &StoredResponse{StatusCode: 410}
+				if err := s.Set(ctx, key, expired, -time.Second); err != nil {
+					t.Fatal(err)
+				}
+				var (
+					adders, getters sync.WaitGroup
+					added           atomic.Int32
+					start           = make(chan struct{})
+				)
+				for i := 0; i < 8; i++ {
+					getters.Add(1)
+					go func() {
+						defer getters.Done()
+						<-start
+						for j := 0; j < 8; j++ {
+							if _, err := s.Get(ctx, key); err != nil {
+								t.Error(err)
+								return
+							}
+						}
+					}()
+				}
+				for i := 0; i < 8; i++ {
+					adders.Add(1)
+					go func() {
+						defer adders.Done()
+						<-start
+						for j := 0; j < 4; j++ {
+							ok, err := s.Add(ctx, key, &StoredResponse{StatusCode: 200}, time.Minute)
+							if err != nil {
+								t.Error(err)
+							}
+							if ok {
+								added.Add(1)
+							}
+						}
+					}()
+				}
+				close(start)
+				adders.Wait()
+				getters.Wait()
+				if n := added.Load(); n != 1 {
+					t.Fatalf("round %d: %d Adds succeeded, want 1", round, n)
+				}
+				got, err := s.Get(ctx, key)
+				if err != nil || got == nil || got.StatusCode != 200 {
+					t.Fatalf("round %d: Get = %+v, %v; want the added response", round, got, err)
+				}
+			}
+		})
+	}
+}
+
+// TestFileResponseStoreRemoveStale checks that an expired entry that was
+// replaced after it was read isn't removed, the interleaving that
+// TestResponseStoreAddExpiredConcurrently can only hit by chance.
+func TestFileResponseStoreRemoveStale(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileResponseStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := store.(*fileResponseStore)
+	path := s.path("k")
+	if err := s.Set(ctx, "k", &StoredResponse{StatusCode: 410}, -time.Second); err != nil {
+		t.Fatal(err)
+	}
+	stale, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set(ctx, "k", &StoredResponse{StatusCode: 200}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	s.removeStale(path, stale)
+	if got, err := s.Get(ctx, "k"); err != nil || got == nil || got.StatusCode != 200 {
+		t.Fatalf("Get = %+v, %v; want the entry that replaced the stale one", got, err)
+	}
+	if ok, err := s.Add(ctx, "k", &StoredResponse{StatusCode: 201}, time.Minute); err != nil || ok {
+		t.Fatalf("Add = %v, %v; want false, nil", ok, err)
+	}
+
+	fresh, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.removeStale(path, fresh)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("stat after removeStale of the same file = %v, want not exist", err)
+	}
+}
@@ -0,0 +1,65 @@
+package generichttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// WriteJSONArray renders the items received from items as a JSON array,
+// writing and flushing each item as it arrives, e.g. for large lists that
+// are read from a database cursor. Unlike newline-delimited JSON, the
+// response is a standard JSON array, e.g. [{"id":1},{"id":2}], or [] if
+// items is closed without sending any.
+//
+// Items are received one at a time, after the previous item has been
+// written, so a slow client slows down the sender of an unbuffered
+// channel. WriteJSONArray returns when items is closed, ctx is done, or
+// an item cannot be encoded or written. The sender should therefore stop
+// sending when ctx is done, as no one receives its items anymore.
+//
+// If WriteJSONArray fails before the first item, nothing has been
+// written, so the caller can still render an error, e.g. with
+// WriteJSONError. If it fails later, the array is left unterminated, so
+// that clients don't mistake a truncated array for a complete one.
+func WriteJSONArray[T any](w http.ResponseWriter, ctx context.Context, items <-chan T) error {
+	rc := http.NewResponseController(w)
+	n := 0
+	for {
+		var item T
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case item, ok = <-items:
+		}
+		if !ok {
+			break
+		}
+		b, err := Marshal(item)
+		if err != nil {
+			return err
+		}
+		sep := []byte{','}
+		if n == 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			sep[0] = '['
+		}
+		n++
+		if _, err := w.Write(append(sep, b...)); err != nil {
+			return err
+		}
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+	}
+	end := "]\n"
+	if n == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		end = "[]\n"
+	}
+	_, err := w.Write([]byte(end))
+	return err
+}
@@ -0,0 +1,98 @@
+package generichttp
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// TeeResponseWriter is a http.ResponseWriter that passes a response through
+// to the underlying http.ResponseWriter and duplicates the body to another
+// io.Writer, e.g. a bytes.Buffer for caching or an audit log. It captures
+// the status code, the header as written, and the size of the body.
+//
+// It always implements http.Flusher and http.Hijacker, so a type assertion
+// doesn't tell whether the underlying writer supports them: if it doesn't,
+// Flush does nothing and Hijack returns an error that wraps
+// http.ErrNotSupported. It supports http.ResponseController, which reports
+// the same errors.
+type TeeResponseWriter struct {
+	http.ResponseWriter
+	tee     io.Writer
+	status  int
+	header  http.Header
+	written int64
+}
+
+// NewTeeResponseWriter returns a TeeResponseWriter that writes to w and
+// duplicates the body to tee. Errors from tee are ignored, so they don't
+// affect the response. If tee is nil, the body is not duplicated.
+func NewTeeResponseWriter(w http.ResponseWriter, tee io.Writer) *TeeResponseWriter {
+	return &TeeResponseWriter{ResponseWriter: w, tee: tee}
+}
+
+// Status returns the status code written, or 200 if the handler wrote a
+// body without calling WriteHeader, or 0 if it wrote nothing at all.
+func (w *TeeResponseWriter) Status() int {
+	return w.status
+}
+
+// WrittenHeader returns a copy of the header as it was when the status
+// code was written, or nil if nothing was written yet.
+func (w *TeeResponseWriter) WrittenHeader() http.Header {
+	return w.header
+}
+
+// Written returns the number of bytes of the body written.
+func (w *TeeResponseWriter) Written() int64 {
+	return w.written
+}
+
+// WriteHeader captures the status code and header and writes the header.
+func (w *TeeResponseWriter) WriteHeader(code int) {
+	if w.status == 0 && code >= http.StatusOK {
+		w.status = code
+		w.header = w.ResponseWriter.Header().Clone()
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes p and duplicates it.
+func (w *TeeResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	if w.tee != nil && n > 0 {
+		_, _ = w.tee.Write(p[:n])
+	}
+	return n, err
+}
+
+// Flush implements http.Flusher. It does nothing if the underlying writer
+// cannot flush.
+func (w *TeeResponseWriter) Flush() {
+	_ = w.FlushError()
+}
+
+// FlushError flushes like Flush, but returns an error that wraps
+// http.ErrNotSupported if the underlying writer cannot flush. It is used
+// by http.ResponseController.
+func (w *TeeResponseWriter) FlushError() error {
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack implements http.Hijacker, e.g. for WebSockets. It returns an
+// error that wraps http.ErrNotSupported if the underlying writer cannot
+// hijack the connection.
+func (w *TeeResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the underlying http.ResponseWriter for
+// http.ResponseController.
+func (w *TeeResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
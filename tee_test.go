@@ -0,0 +1,114 @@
+package generichttp_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/olivere/generichttp"
+)
+
+func TestTeeResponseWriter(t *testing.T) {
+	rec := &plainResponseWriter{header: http.Header{}}
+	var body bytes.Buffer
+	w := generichttp.NewTeeResponseWriter(rec, &body)
+	if w.Status() != 0 || w.WrittenHeader() != nil {
+		t.Fatalf("before writing: Status = %d, WrittenHeader = %v; want 0, nil", w.Status(), w.WrittenHeader())
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusEarlyHints)
+	w.WriteHeader(http.StatusCreated)
+	w.Header().Set("X-After", "1")
+	io.WriteString(w, `{"id":1}`)
+
+	if w.Status() != http.StatusCreated {
+		t.Errorf("Status = %d, want %d", w.Status(), http.StatusCreated)
+	}
+	if want := []int{http.StatusEarlyHints, http.StatusCreated}; !slices.Equal(rec.codes, want) {
+		t.Errorf("codes written = %v, want %v", rec.codes, want)
+	}
+	if ct := w.WrittenHeader().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("WrittenHeader Content-Type = %q, want application/json", ct)
+	}
+	if v := w.WrittenHeader().Get("X-After"); v != "" {
+		t.Errorf("WrittenHeader X-After = %q, want the header as of WriteHeader", v)
+	}
+	if body.String() != `{"id":1}` || rec.body.String() != `{"id":1}` {
+		t.Errorf("tee = %q, body = %q; want both %q", body.String(), rec.body.String(), `{"id":1}`)
+	}
+	if w.Written() != 8 {
+		t.Errorf("Written = %d, want 8", w.Written())
+	}
+}
+
+func TestTeeResponseWriterImplicitStatus(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := generichttp.NewTeeResponseWriter(rec, nil)
+	io.WriteString(w, "hello")
+	if w.Status() != http.StatusOK {
+		t.Errorf("Status = %d, want %d", w.Status(), http.StatusOK)
+	}
+	if w.WrittenHeader() == nil {
+		t.Error("WrittenHeader = nil, want the header written with the body")
+	}
+	if rec.Body.String() != "hello" {
+		t.Errorf("body = %q, want %q", rec.Body, "hello")
+	}
+}
+
+// plainResponseWriter is a http.ResponseWriter that can neither flush nor
+// hijack. It records the status codes written, including 1xx ones.
+type plainResponseWriter struct {
+	header http.Header
+	codes  []int
+	body   bytes.Buffer
+}
+
+func (w *plainResponseWriter) Header() http.Header         { return w.header }
+func (w *plainResponseWriter) Write(p []byte) (int, error) { return w.body.Write(p) }
+func (w *plainResponseWriter) WriteHeader(code int)        { w.codes = append(w.codes, code) }
+
+func TestTeeResponseWriterUnsupported(t *testing.T) {
+	w := generichttp.NewTeeResponseWriter(&plainResponseWriter{header: http.Header{}}, nil)
+	w.Flush() // must not panic
+
+	if _, _, err := w.Hijack(); !errors.Is(err, http.ErrNotSupported) {
+		t.Errorf("Hijack error = %v, want http.ErrNotSupported", err)
+	}
+	rc := http.NewResponseController(w)
+	if err := rc.Flush(); !errors.Is(err, http.ErrNotSupported) {
+		t.Errorf("ResponseController.Flush error = %v, want http.ErrNotSupported", err)
+	}
+	if _, _, err := rc.Hijack(); !errors.Is(err, http.ErrNotSupported) {
+		t.Errorf("ResponseController.Hijack error = %v, want http.ErrNotSupported", err)
+	}
+}
+
+// hijackResponseWriter is a http.ResponseWriter that can flush and hijack.
+type hijackResponseWriter struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (w *hijackResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked = true
+	return nil, nil, nil
+}
+
+func TestTeeResponseWriterSupported(t *testing.T) {
+	under := &hijackResponseWriter{ResponseRecorder: httptest.NewRecorder()}
+	w := generichttp.NewTeeResponseWriter(under, nil)
+	w.Flush()
+	if !under.Flushed {
+		t.Error("Flush didn't flush the underlying writer")
+	}
+	if _, _, err := w.Hijack(); err != nil || !under.hijacked {
+		t.Errorf("Hijack = %v, hijacked %v; want the underlying writer hijacked", err, under.hijacked)
+	}
+}
@@ -0,0 +1,96 @@
+package generichttp
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// TenantOptions configures the Tenant middleware.
+type TenantOptions struct {
+	// Header is the name of the request header with the tenant identifier,
+	// e.g. "X-Tenant". If it is empty, the tenant is taken from the
+	// subdomain of the Host header.
+	Header string
+	// Domain is the domain below which tenants have their subdomains,
+	// e.g. "example.com" for "acme.example.com". If it is empty, the first
+	// label of hosts with at least three labels is used.
+	Domain string
+	// Resolver reports whether id identifies a known tenant. If it is nil,
+	// all tenants are known. An error is rendered as an internal server error.
+	Resolver func(ctx context.Context, id string) (bool, error)
+	// UnknownTenantError is rendered for requests with a missing or unknown
+	// tenant. It defaults to a NotFoundError; use e.g. a ForbiddenError
+	// for a 403.
+	UnknownTenantError error
+}
+
+// tenantKey is the context key of the tenant identifier.
+type tenantKey struct{}
+
+// Tenant returns a middleware that extracts a tenant identifier from the
+// request, validates it, and stores it in the request context. Use
+// TenantFromContext to retrieve it in handlers.
+func Tenant(opts TenantOptions) Middleware {
+	unknown := opts.UnknownTenantError
+	if unknown == nil {
+		unknown = NotFoundError{Message: "Unknown tenant"}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var id string
+			if opts.Header != "" {
+				id = strings.TrimSpace(r.Header.Get(opts.Header))
+			} else {
+				id = subdomain(r.Host, opts.Domain)
+			}
+			if id == "" {
+				writeJSONError(w, r, unknown)
+				return
+			}
+			if opts.Resolver != nil {
+				known, err := opts.Resolver(r.Context(), id)
+				if err != nil {
+					writeJSONError(w, r, err)
+					return
+				}
+				if !known {
+					writeJSONError(w, r, unknown)
+					return
+				}
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tenantKey{}, id)))
+		})
+	}
+}
+
+// TenantFromContext returns the tenant identifier stored by the Tenant
+// middleware.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(tenantKey{}).(string)
+	return id, ok
+}
+
+// subdomain returns the tenant subdomain of host, or an empty string.
+func subdomain(host, domain string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if net.ParseIP(host) != nil {
+		return ""
+	}
+	if domain != "" {
+		sub, found := strings.CutSuffix(host, "."+strings.ToLower(domain))
+		if !found || strings.Contains(sub, ".") {
+			return ""
+		}
+		return sub
+	}
+	labels := strings.Split(host, ".")
+	if len(labels) < 3 {
+		return ""
+	}
+	return labels[0]
+}
@@ -0,0 +1,176 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// AssertJSON decodes the body of rec as JSON and compares it to want,
+// which is encoded as JSON first, so it may be e.g. the response data of
+// a handler, a map, or a generichttp.Response for a response in the
+// {"data": ...} envelope. It reports the differences, one per JSON path,
+// as errors of t and returns whether the body matched.
+//
+// ignore lists paths of volatile fields that aren't compared, e.g.
+// timestamps and generated IDs. Paths are dot-separated object keys and
+// array indexes, with * matching any key or index, e.g. "id",
+// "data.createdAt", or "items.*.id". An ignored field may be missing on
+// either side.
+func AssertJSON(t testing.TB, rec *httptest.ResponseRecorder, want any, ignore ...string) bool {
+	t.Helper()
+	got, err := decodeJSON(rec.Body.Bytes())
+	if err != nil {
+		t.Errorf("response body is not valid JSON: %v\nbody: %s", err, rec.Body.String())
+		return false
+	}
+	data, err := json.Marshal(want)
+	if err != nil {
+		t.Errorf("cannot encode want as JSON: %v", err)
+		return false
+	}
+	wantValue, err := decodeJSON(data)
+	if err != nil {
+		t.Errorf("cannot decode want: %v", err)
+		return false
+	}
+	ignored := make([][]string, len(ignore))
+	for i, path := range ignore {
+		ignored[i] = strings.Split(path, ".")
+	}
+	var diffs []string
+	diffJSON(&diffs, nil, got, wantValue, ignored)
+	if len(diffs) == 0 {
+		return true
+	}
+	t.Errorf("response body differs:\n\t%s\nbody: %s", strings.Join(diffs, "\n\t"), rec.Body.String())
+	return false
+}
+
+// decodeJSON decodes data as a generic JSON value, keeping numbers as
+// json.Number.
+func decodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// diffJSON appends the differences between got and want at path to diffs.
+func diffJSON(diffs *[]string, path []string, got, want any, ignored [][]string) {
+	if isIgnored(path, ignored) {
+		return
+	}
+	switch w := want.(type) {
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make(map[string]bool, len(w)+len(g))
+		for k := range w {
+			keys[k] = true
+		}
+		for k := range g {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			p := append(path[:len(path):len(path)], k)
+			gv, inGot := g[k]
+			wv, inWant := w[k]
+			switch {
+			case isIgnored(p, ignored):
+			case !inGot:
+				*diffs = append(*diffs, fmt.Sprintf("%s: missing, want %s", pathString(p), jsonString(wv)))
+			case !inWant:
+				*diffs = append(*diffs, fmt.Sprintf("%s: got %s, want nothing", pathString(p), jsonString(gv)))
+			default:
+				diffJSON(diffs, p, gv, wv, ignored)
+			}
+		}
+		return
+	case []any:
+		g, ok := got.([]any)
+		if !ok {
+			break
+		}
+		if len(g) != len(w) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: got %d elements, want %d", pathString(path), len(g), len(w)))
+		}
+		for i := 0; i < len(g) && i < len(w); i++ {
+			diffJSON(diffs, append(path[:len(path):len(path)], strconv.Itoa(i)), g[i], w[i], ignored)
+		}
+		return
+	case json.Number:
+		if g, ok := got.(json.Number); ok && equalNumbers(g, w) {
+			return
+		}
+	default:
+		if got == want {
+			return
+		}
+	}
+	*diffs = append(*diffs, fmt.Sprintf("%s: got %s, want %s", pathString(path), jsonString(got), jsonString(want)))
+}
+
+// equalNumbers reports whether a and b are the same number, e.g. 1 and
+// 1.0.
+func equalNumbers(a, b json.Number) bool {
+	if a == b {
+		return true
+	}
+	x, errA := a.Float64()
+	y, errB := b.Float64()
+	return errA == nil && errB == nil && x == y
+}
+
+// isIgnored reports whether path matches one of the ignored paths.
+func isIgnored(path []string, ignored [][]string) bool {
+	for _, ig := range ignored {
+		if len(ig) != len(path) {
+			continue
+		}
+		match := true
+		for i := range ig {
+			if ig[i] != "*" && ig[i] != path[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// pathString returns path in the syntax of the ignore paths of AssertJSON,
+// or "(root)" for the root.
+func pathString(path []string) string {
+	if len(path) == 0 {
+		return "(root)"
+	}
+	return strings.Join(path, ".")
+}
+
+// jsonString returns v encoded as JSON.
+func jsonString(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
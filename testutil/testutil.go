@@ -0,0 +1,118 @@
+// Package testutil helps testing generichttp handlers.
+//
+// NewRequest builds the request of a handler with its data already
+// decoded, so that the handler can be called directly, without encoding
+// and decoding a body:
+//
+//	req := testutil.NewRequest(CreateItem{Name: "Widget"},
+//		testutil.WithPathValue("shop", "acme"),
+//		testutil.WithPrincipal(user),
+//	)
+//	resp, err := createItem(httptest.NewRecorder(), req)
+//
+// Handlers called this way skip everything JSON and Auto do around them,
+// e.g. query binding, normalization, and validation. Test those by serving
+// requests through the handler returned by JSON or Auto instead.
+package testutil
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/olivere/generichttp"
+)
+
+// ReqOption configures the request built by NewRequest. It returns the
+// request to use, which may be a copy, e.g. with a different context.
+type ReqOption func(r *http.Request) *http.Request
+
+// NewRequest returns a Request with body as its data, for calling a
+// handler in a test. The embedded http.Request is a GET request for "/"
+// with an empty body and context.Background(), unless changed by opts.
+// Attributes can be set with WithAttribute; without it, Request.Set has
+// no effect.
+func NewRequest[T any](body T, opts ...ReqOption) generichttp.Request[T] {
+	r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	for _, opt := range opts {
+		r = opt(r)
+	}
+	return generichttp.Request[T]{Request: r, Data: &body}
+}
+
+// WithMethod sets the method of the request.
+func WithMethod(method string) ReqOption {
+	return func(r *http.Request) *http.Request {
+		r.Method = method
+		return r
+	}
+}
+
+// WithPath sets the path of the request URL.
+func WithPath(path string) ReqOption {
+	return func(r *http.Request) *http.Request {
+		r.URL.Path = path
+		r.RequestURI = r.URL.RequestURI()
+		return r
+	}
+}
+
+// WithHeader adds a value to a header of the request.
+func WithHeader(key, value string) ReqOption {
+	return func(r *http.Request) *http.Request {
+		r.Header.Add(key, value)
+		return r
+	}
+}
+
+// WithQuery adds a value to a query parameter of the request URL.
+func WithQuery(key, value string) ReqOption {
+	return func(r *http.Request) *http.Request {
+		q := r.URL.Query()
+		q.Add(key, value)
+		r.URL.RawQuery = q.Encode()
+		r.RequestURI = r.URL.RequestURI()
+		return r
+	}
+}
+
+// WithPathValue sets a path value of the request, as returned by
+// http.Request.PathValue for a wildcard of the route pattern, e.g. "id" for
+// "/items/{id}".
+func WithPathValue(name, value string) ReqOption {
+	return func(r *http.Request) *http.Request {
+		r.SetPathValue(name, value)
+		return r
+	}
+}
+
+// WithContext sets the context of the request. Use it before options that
+// add values to the context, as it replaces them.
+func WithContext(ctx context.Context) ReqOption {
+	return func(r *http.Request) *http.Request {
+		return r.WithContext(ctx)
+	}
+}
+
+// WithContextValue adds a value to the context of the request.
+func WithContextValue(key, value any) ReqOption {
+	return func(r *http.Request) *http.Request {
+		return r.WithContext(context.WithValue(r.Context(), key, value))
+	}
+}
+
+// WithPrincipal sets the authenticated principal of the request, see
+// generichttp.WithPrincipal.
+func WithPrincipal(principal any) ReqOption {
+	return func(r *http.Request) *http.Request {
+		return generichttp.WithPrincipal(r, principal)
+	}
+}
+
+// WithAttribute sets an attribute of the request, see
+// generichttp.SetAttribute.
+func WithAttribute(key string, value any) ReqOption {
+	return func(r *http.Request) *http.Request {
+		return generichttp.SetAttribute(r, key, value)
+	}
+}
@@ -0,0 +1,205 @@
+package generichttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// decodeJSONBodyWithTimes decodes the JSON body of r into v like
+// decodeJSONBody, for types with time struct tags, see NewRequest.
+func decodeJSONBodyWithTimes(r *http.Request, v any) error {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return io.EOF
+	}
+	if data, err = decodeTimes(data, reflect.TypeOf(v)); err != nil {
+		return err
+	}
+	if err := Unmarshal(data, v); err != nil {
+		return rewrittenError{err}
+	}
+	return nil
+}
+
+// decodeTimes rewrites the values of fields with a time struct tag in the
+// JSON data to RFC 3339, so that data can then be decoded into t.
+func decodeTimes(data []byte, t reflect.Type) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var tree any
+	if err := decodeValue(dec, &tree); err != nil {
+		return nil, err
+	}
+	tree, err := rewriteTimes(t, tree, "")
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(tree)
+}
+
+// rewriteTimes walks t and the decoded JSON value v in parallel and rewrites
+// the values of fields with a time struct tag.
+func rewriteTimes(t reflect.Type, v any, path string) (any, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return v, nil
+		}
+		return obj, rewriteStructTimes(t, obj, path)
+	case reflect.Slice, reflect.Array:
+		arr, ok := v.([]any)
+		if !ok {
+			return v, nil
+		}
+		for i := range arr {
+			var err error
+			if arr[i], err = rewriteTimes(t.Elem(), arr[i], fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return nil, err
+			}
+		}
+	case reflect.Map:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return v, nil
+		}
+		for k := range obj {
+			var err error
+			if obj[k], err = rewriteTimes(t.Elem(), obj[k], fmt.Sprintf("%s[%s]", path, k)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
+
+// rewriteStructTimes rewrites the fields of struct type t in obj.
+func rewriteStructTimes(t reflect.Type, obj map[string]any, path string) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Tag.Get("json") == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if err := rewriteStructTimes(ft, obj, path); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		layout, tagged := f.Tag.Lookup("time")
+		if !tagged && !typeHasTag(f.Type, "time") {
+			continue
+		}
+		name := fieldName(f, "json")
+		if name == "" {
+			continue
+		}
+		key, found := lookupKey(obj, name)
+		if !found {
+			continue
+		}
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+		var err error
+		if tagged {
+			obj[key], err = rewriteTimeValue(obj[key], layout, fieldPath)
+		} else {
+			obj[key], err = rewriteTimes(f.Type, obj[key], fieldPath)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupKey finds the key of obj for a field name, preferring an exact
+// match, then a case-insensitive one, like encoding/json.
+func lookupKey(obj map[string]any, name string) (string, bool) {
+	if _, found := obj[name]; found {
+		return name, true
+	}
+	for key := range obj {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// rewriteTimeValue parses v, a time or an array of times, with layout and
+// returns it in RFC 3339.
+func rewriteTimeValue(v any, layout, path string) (any, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		for i := range v {
+			var err error
+			if v[i], err = rewriteTimeValue(v[i], layout, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return nil, err
+			}
+		}
+		return v, nil
+	}
+	tm, ok := parseTime(v, layout)
+	if !ok {
+		return nil, BadRequestError{Message: fmt.Sprintf("Invalid time in %s", path)}
+	}
+	return tm.Format(time.RFC3339Nano), nil
+}
+
+// parseTime parses a JSON string or number with layout.
+func parseTime(v any, layout string) (time.Time, bool) {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case json.Number:
+		s = v.String()
+	default:
+		return time.Time{}, false
+	}
+	switch layout {
+	case "unix":
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return time.Time{}, false
+		}
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC(), true
+	case "unixmilli":
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.UnixMilli(n).UTC(), true
+	}
+	if _, isString := v.(string); !isString {
+		return time.Time{}, false
+	}
+	tm, err := time.Parse(layout, s)
+	return tm, err == nil
+}
@@ -0,0 +1,125 @@
+package generichttp_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/olivere/generichttp"
+	"github.com/olivere/generichttp/testutil"
+)
+
+type timedStop struct {
+	At time.Time `json:"at" time:"15:04"`
+}
+
+type timedEvent struct {
+	Day       time.Time   `json:"day" time:"2006-01-02"`
+	Created   time.Time   `json:"created" time:"unix"`
+	Updated   *time.Time  `json:"updated" time:"unixmilli"`
+	Reminders []time.Time `json:"reminders" time:"unix"`
+	Stops     []timedStop `json:"stops"`
+	Default   time.Time   `json:"default"`
+}
+
+func TestTimeLayoutRoundTrip(t *testing.T) {
+	day := time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)
+	created := time.Date(2024, time.March, 1, 12, 30, 15, 500_000_000, time.UTC)
+	updated := time.Date(2024, time.March, 2, 8, 0, 0, 123_000_000, time.UTC)
+	reminder := time.Date(2024, time.March, 3, 9, 0, 0, 0, time.UTC)
+	stop := time.Date(0, time.January, 1, 17, 45, 0, 0, time.UTC)
+	def := time.Date(2024, time.March, 4, 10, 0, 0, 0, time.FixedZone("", 2*60*60))
+
+	body := map[string]any{
+		"day":       day.Format("2006-01-02"),
+		"created":   json.Number(strconv.FormatFloat(float64(created.UnixNano())/1e9, 'f', -1, 64)),
+		"updated":   strconv.FormatInt(updated.UnixMilli(), 10),
+		"reminders": []any{reminder.Unix(), strconv.FormatInt(reminder.Unix(), 10)},
+		"stops":     []any{map[string]any{"at": stop.Format("15:04")}},
+		"default":   def.Format(time.RFC3339),
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for kind, h := range map[string]func(generichttp.Handler[timedEvent, timedEvent], ...generichttp.Option) http.Handler{
+		"JSON": generichttp.JSON[timedEvent, timedEvent],
+		"Auto": generichttp.Auto[timedEvent, timedEvent],
+	} {
+		t.Run(kind, func(t *testing.T) {
+			var got timedEvent
+			handler := h(func(w http.ResponseWriter, req generichttp.Request[timedEvent]) (*generichttp.Response[timedEvent], error) {
+				got = *req.Data
+				return generichttp.OK(req.Data), nil
+			})
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(data)))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+			}
+			for _, c := range []struct {
+				name      string
+				got, want time.Time
+			}{
+				{"day", got.Day, day},
+				{"created", got.Created, created},
+				{"reminders[0]", at(got.Reminders, 0), reminder},
+				{"reminders[1]", at(got.Reminders, 1), reminder},
+				{"stops[0].at", at(got.Stops, 0).At, stop},
+				{"default", got.Default, def},
+			} {
+				if !c.got.Equal(c.want) {
+					t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+				}
+			}
+			if got.Updated == nil || !got.Updated.Equal(updated) {
+				t.Errorf("updated = %v, want %v", got.Updated, updated)
+			}
+		})
+	}
+}
+
+// at returns s[i] or the zero value if s is too short.
+func at[T any](s []T, i int) T {
+	var zero T
+	if i >= len(s) {
+		return zero
+	}
+	return s[i]
+}
+
+func TestTimeLayoutMalformed(t *testing.T) {
+	h := generichttp.JSON(func(w http.ResponseWriter, req generichttp.Request[timedEvent]) (*generichttp.Response[timedEvent], error) {
+		return generichttp.OK(req.Data), nil
+	})
+	tests := []struct {
+		body string
+		msg  string
+	}{
+		{`{"day":"29.02.2024"}`, "Invalid time in day"},
+		{`{"day":20240229}`, "Invalid time in day"},
+		{`{"created":"yesterday"}`, "Invalid time in created"},
+		{`{"updated":1.5}`, "Invalid time in updated"},
+		{`{"reminders":[1709456400,"soon"]}`, "Invalid time in reminders[1]"},
+		{`{"stops":[{"at":"17:45"},{"at":"5pm"}]}`, "Invalid time in stops[1].at"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+			testutil.AssertJSON(t, w, map[string]any{"message": tt.msg})
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package generichttp
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Timeout returns a middleware that limits the duration of the next
+// handler to d. The handler gets a request context with the deadline, and
+// if it hasn't finished by then, the request is answered with a
+// GatewayTimeoutError. Writes of the handler after that fail with
+// http.ErrHandlerTimeout. See WithTimeout to set a timeout per route.
+//
+// Like http.TimeoutHandler, the response is buffered until the handler
+// finishes, so that a timeout can still be rendered, i.e. it doesn't
+// support streaming, http.Flusher, or http.Hijacker. A handler that
+// ignores the context keeps running in the background after a timeout.
+func Timeout(d time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			serveTimeout(next, d, w, r)
+		})
+	}
+}
+
+// serveTimeout serves r with h, bounded by d. See Timeout.
+func serveTimeout(h http.Handler, d time.Duration, w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), d)
+	defer cancel()
+	tw := &timeoutWriter{header: make(http.Header)}
+	done := make(chan struct{})
+	panicked := make(chan any, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				panicked <- p
+			}
+		}()
+		h.ServeHTTP(tw, r.WithContext(ctx))
+		close(done)
+	}()
+	select {
+	case p := <-panicked:
+		panic(p)
+	case <-done:
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+		copyHeader(w.Header(), tw.header)
+		if tw.code == 0 {
+			tw.code = http.StatusOK
+		}
+		w.WriteHeader(tw.code)
+		_, err := w.Write(tw.buf.Bytes())
+		handleWriteError(err)
+	case <-ctx.Done():
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+		tw.timedOut = true
+		if ctx.Err() == context.DeadlineExceeded {
+			writeJSONError(w, r, GatewayTimeoutError{Message: "Request timed out"})
+		}
+	}
+}
+
+// timeoutWriter buffers the response of a handler bounded by Timeout.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	code     int
+	buf      bytes.Buffer
+	timedOut bool
+}
+
+// Header returns the header of the buffered response.
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// WriteHeader records the status code.
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.code != 0 {
+		return
+	}
+	tw.code = code
+}
+
+// Write buffers p, or fails with http.ErrHandlerTimeout after a timeout.
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+	return tw.buf.Write(p)
+}
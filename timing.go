@@ -0,0 +1,121 @@
+package generichttp
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// serverTimings accumulates the Server-Timing entries of a request. It is
+// safe for concurrent use, as handlers may time work in goroutines.
+type serverTimings struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+// serverTimingKey is the context key of the serverTimings of a request.
+type serverTimingKey struct{}
+
+// ServerTiming returns a middleware that emits the timings added with
+// AddServerTiming or Request.Timing as a Server-Timing header, which
+// browsers show in their developer tools. If enabled is false, it returns
+// the next handler unchanged and timings are not recorded, e.g. to turn
+// it off in production, as the header reveals internals of the backend.
+//
+// The header is written with the response header, so only timings added
+// before the handler starts writing the response are included.
+func ServerTiming(enabled bool) Middleware {
+	return func(next http.Handler) http.Handler {
+		if !enabled {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			st := &serverTimings{}
+			r = r.WithContext(context.WithValue(r.Context(), serverTimingKey{}, st))
+			next.ServeHTTP(&serverTimingResponseWriter{ResponseWriter: w, timings: st}, r)
+		})
+	}
+}
+
+// AddServerTiming adds a Server-Timing entry with the given name, e.g.
+// "db", and duration to the request with context ctx. It does nothing if
+// the ServerTiming middleware is not enabled.
+func AddServerTiming(ctx context.Context, name string, d time.Duration) {
+	st, ok := ctx.Value(serverTimingKey{}).(*serverTimings)
+	if !ok {
+		return
+	}
+	entry := fmt.Sprintf("%s;dur=%.1f", serverTimingName(name), float64(d)/float64(time.Millisecond))
+	st.mu.Lock()
+	st.entries = append(st.entries, entry)
+	st.mu.Unlock()
+}
+
+// Timing adds a Server-Timing entry to the request, see AddServerTiming.
+func (r Request[T]) Timing(name string, d time.Duration) {
+	AddServerTiming(r.Context(), name, d)
+}
+
+// serverTimingName returns name as a token, replacing characters that are
+// not allowed.
+func serverTimingName(name string) string {
+	return strings.Map(func(c rune) rune {
+		if c > 0x20 && c < 0x7f && !strings.ContainsRune(`"(),/:;<=>?@[\]{}`, c) {
+			return c
+		}
+		return '_'
+	}, name)
+}
+
+// serverTimingResponseWriter sets the Server-Timing header when the
+// response header is written.
+type serverTimingResponseWriter struct {
+	http.ResponseWriter
+	timings     *serverTimings
+	wroteHeader bool
+}
+
+// WriteHeader sets the Server-Timing header and writes the header.
+func (w *serverTimingResponseWriter) WriteHeader(code int) {
+	if !w.wroteHeader && code >= http.StatusOK {
+		w.wroteHeader = true
+		w.timings.mu.Lock()
+		if len(w.timings.entries) > 0 {
+			w.Header().Set("Server-Timing", strings.Join(w.timings.entries, ", "))
+		}
+		w.timings.mu.Unlock()
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes p, writing the header first if necessary.
+func (w *serverTimingResponseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush implements http.Flusher.
+func (w *serverTimingResponseWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack implements http.Hijacker, e.g. for WebSockets.
+func (w *serverTimingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the underlying http.ResponseWriter for
+// http.ResponseController.
+func (w *serverTimingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,36 @@
+package generichttp
+
+import (
+	"net/http"
+	"net/textproto"
+)
+
+// DeclareTrailers announces HTTP trailers, i.e. headers that are sent
+// after the response body, e.g. a checksum of a streamed export. Call it
+// before writing the header or body, and set the values with SetTrailer
+// after writing the body:
+//
+//	generichttp.DeclareTrailers(w, "X-Checksum")
+//	h := sha256.New()
+//	out := io.MultiWriter(w, h)
+//	for _, row := range rows {
+//		fmt.Fprintln(out, row)
+//	}
+//	generichttp.SetTrailer(w, "X-Checksum", hex.EncodeToString(h.Sum(nil)))
+//
+// Trailers are only sent with HTTP/1.1 chunked responses, i.e. without a
+// Content-Length, and with HTTP/2. Many clients ignore them, e.g. fetch in
+// browsers, and some proxies drop them, so don't send information in
+// trailers that clients cannot do without.
+func DeclareTrailers(w http.ResponseWriter, names ...string) {
+	for _, name := range names {
+		w.Header().Add("Trailer", textproto.CanonicalMIMEHeaderKey(name))
+	}
+}
+
+// SetTrailer sets the value of a trailer after writing the response body.
+// Declare it with DeclareTrailers before writing the body; trailers that
+// weren't declared are still sent, but clients can't prepare for them.
+func SetTrailer(w http.ResponseWriter, name, value string) {
+	w.Header().Set(http.TrailerPrefix+name, value)
+}
@@ -0,0 +1,282 @@
+package generichttp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+// Validator is implemented by request data that validates itself.
+// JSON and Auto call Validate after decoding the request body and
+// render a returned error with WriteJSONError, so return e.g. a
+// BadRequestError to signal invalid input.
+//
+// Before calling Validate, JSON and Auto check the maxlen struct tags of
+// the request data. It limits the length of strings (in runes), slices,
+// arrays, and maps, e.g. `maxlen:"1024"`. Fields of nested structs are
+// checked as well.
+//
+// They also check constraints between the fields of a struct, given by
+// these struct tags:
+//
+//   - exclusive:"group": at most one of the fields of the group may be set.
+//     With exclusive:"group,required", exactly one of them must be set.
+//   - required_together:"group": either all or none of the fields of the
+//     group must be set, e.g. the number, expiry, and CVC of a card.
+//   - required_if:"name=value": the field must be set if the field with
+//     the JSON name in the same struct has the value, e.g.
+//     required_if:"method=card". With required_if:"name", the field must
+//     be set if the other field is set.
+//
+// A field is set if it doesn't have its zero value, so use pointers for
+// fields whose zero value is valid input. Groups are local to a struct,
+// including the fields of embedded structs. A violation results in a
+// ValidationError with the paths of the fields involved, e.g.
+// "contact.email". For example, this requires either an email or a phone
+// number, but not both:
+//
+//	type Contact struct {
+//		Email string `json:"email" exclusive:"contact,required"`
+//		Phone string `json:"phone" exclusive:"contact,required"`
+//	}
+//
+// Validate is called only if all of these checks pass.
+type Validator interface {
+	Validate() error
+}
+
+// validateRequest validates decoded request data. It checks strings for
+// valid UTF-8 and the enum struct tags if enabled in opts, the maxlen
+// struct tags, and the constraints between fields first, then calls
+// Validate if data implements Validator.
+func validateRequest(data any, opts *Options) error {
+	if data == nil {
+		return nil
+	}
+	v := reflect.ValueOf(data)
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		return nil
+	}
+	if opts.ValidateUTF8 {
+		if !validUTF8(v) {
+			return errInvalidUTF8
+		}
+		if err := walkFields(v, "", checkUTF8); err != nil {
+			return err
+		}
+	}
+	if typeHasTag(v.Type(), "maxlen") {
+		if err := walkFields(v, "", checkMaxLen); err != nil {
+			return err
+		}
+	}
+	if opts.ValidateEnum && typeHasTag(v.Type(), "enum") {
+		if err := walkFields(v, "", checkEnum); err != nil {
+			return err
+		}
+	}
+	if hasCrossFieldTags(v.Type()) {
+		if err := checkCrossFields(v, ""); err != nil {
+			return err
+		}
+	}
+	if validator, ok := data.(Validator); ok {
+		return validator.Validate()
+	}
+	return nil
+}
+
+// checkMaxLen checks the maxlen struct tag of field f with value v.
+func checkMaxLen(f reflect.StructField, v reflect.Value, path string) error {
+	tag := f.Tag.Get("maxlen")
+	if tag == "" {
+		return nil
+	}
+	max, err := strconv.Atoi(tag)
+	if err != nil {
+		return fmt.Errorf("generichttp: invalid maxlen tag on field %s: %q", f.Name, tag)
+	}
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	var n int
+	switch v.Kind() {
+	case reflect.String:
+		n = utf8.RuneCountInString(v.String())
+	case reflect.Slice, reflect.Array, reflect.Map:
+		n = v.Len()
+	default:
+		return nil
+	}
+	if n > max {
+		return BadRequestError{Message: fmt.Sprintf("%s exceeds the maximum length of %d", path, max)}
+	}
+	return nil
+}
+
+// tagCache caches the results of typeHasTag.
+var tagCache sync.Map // map[tagCacheKey]bool
+
+type tagCacheKey struct {
+	t   reflect.Type
+	tag string
+}
+
+// typeHasTag reports whether t or any type reachable from t has a struct
+// field with the given struct tag. It is used to skip walking request data
+// without the respective tags.
+func typeHasTag(t reflect.Type, tag string) bool {
+	key := tagCacheKey{t: t, tag: tag}
+	if found, ok := tagCache.Load(key); ok {
+		return found.(bool)
+	}
+	found := hasTag(t, tag, make(map[reflect.Type]bool))
+	tagCache.Store(key, found)
+	return found
+}
+
+func hasTag(t reflect.Type, tag string, visited map[reflect.Type]bool) bool {
+	if visited[t] {
+		return false
+	}
+	visited[t] = true
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return hasTag(t.Elem(), tag, visited)
+	case reflect.Map:
+		return hasTag(t.Elem(), tag, visited)
+	case reflect.Interface:
+		return true // unknown until runtime
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if _, found := f.Tag.Lookup(tag); found {
+				return true
+			}
+			if hasTag(f.Type, tag, visited) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// walkFields calls fn for every struct field reachable from v, including
+// fields of nested structs and of structs in slices, arrays, and maps.
+// The path passed to fn is the dotted path of JSON names of the field,
+// e.g. "address.street" or "items[2].name".
+func walkFields(v reflect.Value, path string, fn func(f reflect.StructField, v reflect.Value, path string) error) error {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() && !f.Anonymous {
+				continue
+			}
+			fieldPath := path
+			if !f.Anonymous || f.Tag.Get("json") != "" {
+				name := fieldName(f, "json")
+				if name == "" {
+					continue
+				}
+				if fieldPath != "" {
+					fieldPath += "."
+				}
+				fieldPath += name
+			}
+			if err := fn(f, v.Field(i), fieldPath); err != nil {
+				return err
+			}
+			if err := walkFields(v.Field(i), fieldPath, fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := walkFields(v.Index(i), fmt.Sprintf("%s[%d]", path, i), fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := walkFields(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key()), fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// errInvalidUTF8 is returned for requests with invalid UTF-8.
+var errInvalidUTF8 = BadRequestError{Message: "Invalid UTF-8 in request"}
+
+// checkBodyUTF8 reads the body of r, which must be limited in size by the
+// caller, see limitBody, and checks it for valid UTF-8. It replaces the
+// body with the bytes read.
+func checkBodyUTF8(r *http.Request) error {
+	if r.Body == nil {
+		return nil
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		if err, ok := bodyError(err).(RequestEntityTooLargeError); ok {
+			return err
+		}
+		return BadRequestError{Message: "Unable to read request body"}
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if !utf8.Valid(body) {
+		return errInvalidUTF8
+	}
+	return nil
+}
+
+// checkUTF8 checks the strings in field f with value v for valid UTF-8.
+func checkUTF8(f reflect.StructField, v reflect.Value, path string) error {
+	if !validUTF8(v) {
+		return BadRequestError{Message: fmt.Sprintf("Invalid UTF-8 in %s", path)}
+	}
+	return nil
+}
+
+// validUTF8 reports whether all strings in v are valid UTF-8. It checks
+// strings in pointers, slices, arrays, and maps, including map keys, but
+// doesn't descend into structs; see walkFields for that.
+func validUTF8(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return utf8.ValidString(v.String())
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil() || validUTF8(v.Elem())
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if !validUTF8(v.Index(i)) {
+				return false
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if !validUTF8(iter.Key()) || !validUTF8(iter.Value()) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package generichttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/olivere/generichttp"
+	"github.com/olivere/generichttp/testutil"
+)
+
+type utf8Comment struct {
+	Author string   `json:"author" form:"author"`
+	Tags   []string `json:"tags" form:"tags"`
+}
+
+func echoUTF8Comment(w http.ResponseWriter, req generichttp.Request[utf8Comment]) (*generichttp.Response[utf8Comment], error) {
+	return generichttp.OK(req.Data), nil
+}
+
+func TestValidateUTF8(t *testing.T) {
+	tests := []struct {
+		name        string
+		h           http.Handler
+		contentType string
+		body        string
+		code        int
+		want        any
+	}{
+		{
+			name:        "valid",
+			h:           generichttp.JSON(echoUTF8Comment, generichttp.WithValidateUTF8()),
+			contentType: "application/json",
+			body:        `{"author":"Zoë","tags":["ü"]}`,
+			code:        http.StatusOK,
+			want:        map[string]any{"author": "Zoë", "tags": []string{"ü"}},
+		},
+		{
+			name:        "invalid byte sequence in JSON",
+			h:           generichttp.JSON(echoUTF8Comment, generichttp.WithValidateUTF8()),
+			contentType: "application/json",
+			body:        "{\"author\":\"Zo\xc3\x28\"}",
+			code:        http.StatusBadRequest,
+			want:        map[string]any{"message": "Invalid UTF-8 in request"},
+		},
+		{
+			name:        "invalid byte sequence in JSON with Auto",
+			h:           generichttp.Auto(echoUTF8Comment, generichttp.WithValidateUTF8()),
+			contentType: "application/json",
+			body:        "{\"tags\":[\"\xff\"]}",
+			code:        http.StatusBadRequest,
+			want:        map[string]any{"message": "Invalid UTF-8 in request"},
+		},
+		{
+			name:        "invalid byte sequence in form data",
+			h:           generichttp.Auto(echoUTF8Comment, generichttp.WithValidateUTF8()),
+			contentType: "application/x-www-form-urlencoded",
+			body:        "author=Zo%C3%28",
+			code:        http.StatusBadRequest,
+			want:        map[string]any{"message": "Invalid UTF-8 in author"},
+		},
+		{
+			name:        "replaced without the option",
+			h:           generichttp.JSON(echoUTF8Comment),
+			contentType: "application/json",
+			body:        "{\"author\":\"Zo\xff\"}",
+			code:        http.StatusOK,
+			want:        map[string]any{"author": "Zo�", "tags": nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			tt.h.ServeHTTP(w, r)
+			if w.Code != tt.code {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.code, w.Body)
+			}
+			testutil.AssertJSON(t, w, tt.want)
+		})
+	}
+}
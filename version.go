@@ -0,0 +1,133 @@
+package generichttp
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// apiVersionKey is the context key of the API version chosen by Versioned.
+type apiVersionKey struct{}
+
+// Versioned returns a handler that dispatches requests to one of the
+// versions of an endpoint by the vendor media type in the Accept header,
+// e.g. "application/vnd.myapi.v2+json" for version 2 with vendor "myapi":
+//
+//	router.Get("/items/{id}", generichttp.Versioned("myapi", map[int]http.Handler{
+//		1: generichttp.JSON(getItemV1),
+//		2: generichttp.JSON(getItemV2),
+//	}))
+//
+// Requests without a vendor media type, e.g. with "application/json" or
+// without an Accept header, and requests for "application/vnd.myapi+json"
+// get the latest version. Requests for an unknown version get a
+// NotAcceptableError. The handler sees an Accept header of
+// "application/json" and can get the version with APIVersion. If the
+// client asked for a vendor media type, the JSON response is sent with the
+// media type of the chosen version as its Content-Type.
+//
+// Versioning by header complements versioning by path, e.g. with
+// Subrouter; only one of them should be used per endpoint.
+func Versioned(vendor string, versions map[int]http.Handler) http.Handler {
+	vendor = strings.ToLower(vendor)
+	nums := make([]int, 0, len(versions))
+	for v := range versions {
+		nums = append(nums, v)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(nums)))
+	// Offers are in order of preference, so that wildcards get the latest
+	// version.
+	offers := make([]string, 0, len(nums)+1)
+	byMediaType := make(map[string]int, len(nums)+1)
+	for _, v := range nums {
+		mt := "application/vnd." + vendor + ".v" + strconv.Itoa(v) + "+json"
+		offers = append(offers, mt)
+		byMediaType[mt] = v
+	}
+	if len(nums) > 0 {
+		mt := "application/vnd." + vendor + "+json"
+		offers = append(offers, mt)
+		byMediaType[mt] = nums[0]
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+		if len(nums) == 0 {
+			writeJSONError(w, r, NotFoundError{})
+			return
+		}
+		accept := r.Header.Get("Accept")
+		mediaType := ""
+		version := nums[0]
+		if strings.TrimSpace(accept) != "" {
+			mediaType = NegotiateContentType(accept, offers)
+			if v, found := byMediaType[mediaType]; found {
+				version = v
+			} else if acceptsVendor(accept, vendor) {
+				writeJSONError(w, r, NotAcceptableError{Message: "Unsupported API version"})
+				return
+			}
+		}
+		r2 := r.WithContext(context.WithValue(r.Context(), apiVersionKey{}, version))
+		r2.Header = r.Header.Clone()
+		r2.Header.Set("Accept", mediaTypeJSON)
+		if mediaType != "" {
+			versioned := "application/vnd." + vendor + ".v" + strconv.Itoa(version) + "+json"
+			w = &versionResponseWriter{ResponseWriter: w, mediaType: versioned}
+		}
+		versions[version].ServeHTTP(w, r2)
+	})
+}
+
+// APIVersion returns the API version chosen by Versioned for the request
+// with the given context.
+func APIVersion(ctx context.Context) (int, bool) {
+	v, ok := ctx.Value(apiVersionKey{}).(int)
+	return v, ok
+}
+
+// acceptsVendor reports whether the Accept header asks for a media type of
+// the vendor.
+func acceptsVendor(accept, vendor string) bool {
+	for _, r := range parseAccept(accept) {
+		if r.q > 0 && strings.HasPrefix(r.value, "application/vnd."+vendor) {
+			return true
+		}
+	}
+	return false
+}
+
+// versionResponseWriter replaces a JSON Content-Type with the vendor media
+// type of an API version.
+type versionResponseWriter struct {
+	http.ResponseWriter
+	mediaType   string
+	wroteHeader bool
+}
+
+// WriteHeader replaces the Content-Type and writes the header.
+func (w *versionResponseWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if mt, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type")); mt == mediaTypeJSON {
+			w.Header().Set("Content-Type", w.mediaType)
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes the header if needed, and p.
+func (w *versionResponseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap returns the underlying http.ResponseWriter for
+// http.ResponseController.
+func (w *versionResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,77 @@
+package generichttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/olivere/generichttp"
+	"github.com/olivere/generichttp/testutil"
+)
+
+type versionedItem struct {
+	Handler int    `json:"handler"`
+	Version int    `json:"version"`
+	Accept  string `json:"accept"`
+}
+
+func versionedItemHandler(handler int) http.Handler {
+	return generichttp.JSON(func(w http.ResponseWriter, req generichttp.Request[struct{}]) (*generichttp.Response[versionedItem], error) {
+		version, _ := generichttp.APIVersion(req.Context())
+		return generichttp.OK(&versionedItem{Handler: handler, Version: version, Accept: req.Header.Get("Accept")}), nil
+	})
+}
+
+func TestVersioned(t *testing.T) {
+	h := generichttp.Versioned("myapi", map[int]http.Handler{
+		1: versionedItemHandler(1),
+		2: versionedItemHandler(2),
+	})
+	tests := []struct {
+		name        string
+		accept      string
+		version     int
+		contentType string
+	}{
+		{"v1", "application/vnd.myapi.v1+json", 1, "application/vnd.myapi.v1+json"},
+		{"v2", "application/vnd.myapi.v2+json", 2, "application/vnd.myapi.v2+json"},
+		{"case", "Application/VND.MyAPI.V1+JSON", 1, "application/vnd.myapi.v1+json"},
+		{"q values", "application/vnd.myapi.v1+json;q=0.5, application/vnd.myapi.v2+json", 2, "application/vnd.myapi.v2+json"},
+		{"unversioned vendor type", "application/vnd.myapi+json", 2, "application/vnd.myapi.v2+json"},
+		{"default without Accept", "", 2, "application/json"},
+		{"default for JSON", "application/json", 2, "application/json"},
+		{"default for wildcard", "*/*", 2, "application/vnd.myapi.v2+json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/items/7", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if got := w.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("Vary = %q, want Accept", got)
+			}
+			testutil.AssertJSON(t, w, versionedItem{Handler: tt.version, Version: tt.version, Accept: "application/json"})
+		})
+	}
+}
+
+func TestVersionedUnknown(t *testing.T) {
+	h := generichttp.Versioned("myapi", map[int]http.Handler{1: versionedItemHandler(1)})
+	r := httptest.NewRequest(http.MethodGet, "/items/7", nil)
+	r.Header.Set("Accept", "application/vnd.myapi.v3+json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusNotAcceptable {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotAcceptable)
+	}
+	testutil.AssertJSON(t, w, map[string]any{"message": "Unsupported API version"})
+}
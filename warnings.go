@@ -0,0 +1,55 @@
+package generichttp
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// warningsAttribute is the attribute that holds the warnings of a request.
+const warningsAttribute = "generichttp.warnings"
+
+// AddWarning adds a non-fatal warning to the response, e.g. that a
+// deprecated field was used or that the data is incomplete, without
+// failing the request:
+//
+//	if req.Data.Legacy != "" {
+//		req.AddWarning("Field legacy is deprecated, use name")
+//	}
+//
+// JSON and Auto send each warning of a successful response in a Warning
+// header with code 299, e.g. `Warning: 299 - "Field legacy is deprecated,
+// use name"`, so the shape of the body doesn't change. Use Warnings to
+// include them in the response data as well. Warnings are stored in the
+// attributes of the request and are not safe for concurrent use.
+func (r Request[T]) AddWarning(msg string) {
+	AddWarning(r.Request, msg)
+}
+
+// AddWarning adds a warning to the response of r, e.g. in middleware, see
+// Request.AddWarning. It has no effect if r has no attributes, i.e. if it
+// isn't served by JSON or Auto and SetAttribute wasn't called on it.
+func AddWarning(r *http.Request, msg string) {
+	if attrs, ok := r.Context().Value(attributesKey{}).(attributes); ok {
+		warnings, _ := attrs[warningsAttribute].([]string)
+		attrs[warningsAttribute] = append(warnings, msg)
+	}
+}
+
+// Warnings returns the warnings added to the response of r, in order.
+func Warnings(r *http.Request) []string {
+	warnings, _ := Attribute[[]string](r, warningsAttribute)
+	return warnings
+}
+
+// writeWarnings adds the warnings of r to the header of w.
+func writeWarnings(w http.ResponseWriter, r *http.Request) {
+	for _, msg := range Warnings(r) {
+		addWarningHeader(w.Header(), msg)
+	}
+}
+
+// addWarningHeader adds a Warning header with code 299, "miscellaneous
+// persistent warning", and msg as its text.
+func addWarningHeader(h http.Header, msg string) {
+	h.Add("Warning", "299 - "+strconv.Quote(msg))
+}
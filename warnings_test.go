@@ -0,0 +1,119 @@
+package generichttp_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/olivere/generichttp"
+)
+
+type legacyItem struct {
+	Name   string `json:"name" xml:"name"`
+	Legacy string `json:"legacy,omitempty" xml:"legacy,omitempty"`
+}
+
+type itemWithWarnings struct {
+	Data     legacyItem `json:"data" xml:"data"`
+	Warnings []string   `json:"warnings,omitempty" xml:"warnings>warning,omitempty"`
+}
+
+func TestWarnings(t *testing.T) {
+	// checkLegacy warns before the handler, like middleware would.
+	checkLegacy := func(next generichttp.Handler[legacyItem, itemWithWarnings]) generichttp.Handler[legacyItem, itemWithWarnings] {
+		return func(w http.ResponseWriter, req generichttp.Request[legacyItem]) (*generichttp.Response[itemWithWarnings], error) {
+			if req.Data.Legacy != "" {
+				req.AddWarning("Field legacy is deprecated, use name")
+			}
+			return next(w, req)
+		}
+	}
+	h := generichttp.Wrap(func(w http.ResponseWriter, req generichttp.Request[legacyItem]) (*generichttp.Response[itemWithWarnings], error) {
+		if req.Data.Name == "" {
+			req.AddWarning(`Missing "name", using the default`)
+			req.Data.Name = "Widget"
+		}
+		if req.Data.Name == "fail" {
+			return nil, errors.New("failed")
+		}
+		return generichttp.Created(&itemWithWarnings{
+			Data:     legacyItem{Name: req.Data.Name},
+			Warnings: generichttp.Warnings(req.Request),
+		}), nil
+	}, checkLegacy)
+
+	tests := []struct {
+		name     string
+		body     string
+		accept   string
+		code     int
+		header   []string
+		response string
+	}{
+		{
+			name:     "no warnings",
+			body:     `{"name":"Gadget"}`,
+			code:     http.StatusCreated,
+			response: `{"data":{"name":"Gadget"}}` + "\n",
+		},
+		{
+			name:     "warnings",
+			body:     `{"legacy":"x"}`,
+			code:     http.StatusCreated,
+			header:   []string{`299 - "Field legacy is deprecated, use name"`, `299 - "Missing \"name\", using the default"`},
+			response: `{"data":{"name":"Widget"},"warnings":["Field legacy is deprecated, use name","Missing \"name\", using the default"]}` + "\n",
+		},
+		{
+			name:     "XML",
+			body:     `{"legacy":"x","name":"Gadget"}`,
+			accept:   "application/xml",
+			code:     http.StatusCreated,
+			header:   []string{`299 - "Field legacy is deprecated, use name"`},
+			response: `<itemWithWarnings><data><name>Gadget</name></data><warnings><warning>Field legacy is deprecated, use name</warning></warnings></itemWithWarnings>`,
+		},
+		{
+			name: "error",
+			body: `{"legacy":"x","name":"fail"}`,
+			code: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		for kind, handler := range map[string]http.Handler{"JSON": generichttp.JSON(h), "Auto": generichttp.Auto(h)} {
+			if tt.accept != "" && kind == "JSON" {
+				continue
+			}
+			t.Run(tt.name+"/"+kind, func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(tt.body))
+				r.Header.Set("Content-Type", "application/json")
+				if tt.accept != "" {
+					r.Header.Set("Accept", tt.accept)
+				}
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+				if w.Code != tt.code {
+					t.Fatalf("status = %d, want %d: %s", w.Code, tt.code, w.Body)
+				}
+				if got := w.Header().Values("Warning"); !reflect.DeepEqual(got, tt.header) {
+					t.Errorf("Warning = %q, want %q", got, tt.header)
+				}
+				if tt.response == "" {
+					return
+				}
+				if got := strings.TrimPrefix(w.Body.String(), `<?xml version="1.0" encoding="UTF-8"?>`+"\n"); got != tt.response {
+					t.Errorf("body = %s, want %s", got, tt.response)
+				}
+			})
+		}
+	}
+}
+
+func TestAddWarningWithoutAttributes(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	generichttp.AddWarning(r, "ignored")
+	if got := generichttp.Warnings(r); got != nil {
+		t.Errorf("Warnings = %q, want none outside of JSON and Auto", got)
+	}
+}
@@ -0,0 +1,44 @@
+package generichttp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"syscall"
+)
+
+// OnClientAbort, if set, is called when writing a response body fails
+// because the client disconnected, e.g. to count aborted responses in
+// metrics. Set it on startup, before serving requests.
+var OnClientAbort func(err error)
+
+// isClientAbort reports whether err, returned from writing a response,
+// means that the client went away.
+func isClientAbort(err error) bool {
+	return errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, context.Canceled)
+}
+
+// handleWriteError handles an error from writing a response body. Client
+// disconnects are logged at debug level and reported to OnClientAbort,
+// writes after a Timeout are logged at debug level, and other errors are
+// logged as errors.
+func handleWriteError(err error) {
+	if err == nil {
+		return
+	}
+	if errors.Is(err, http.ErrHandlerTimeout) {
+		slog.Debug("generichttp: write after timeout", "error", err)
+		return
+	}
+	if isClientAbort(err) {
+		slog.Debug("generichttp: client aborted response", "error", err)
+		if OnClientAbort != nil {
+			OnClientAbort(err)
+		}
+		return
+	}
+	slog.Error("generichttp: unable to write response", "error", err)
+}